@@ -198,6 +198,7 @@ func (e *Engine) Run(ctx context.Context, task string, opts RunOptions) (*Final,
 	return e.runLoop(ctx, &engineLoopState{
 		runID:           runID,
 		model:           model,
+		trigger:         runTrigger(opts.Meta),
 		log:             log,
 		messages:        messages,
 		agentCtx:        agentCtx,
@@ -11,6 +11,7 @@ import (
 	"github.com/quailyquaily/mistermorph/guard"
 	"github.com/quailyquaily/mistermorph/internal/strutil"
 	"github.com/quailyquaily/mistermorph/llm"
+	"github.com/quailyquaily/mistermorph/tools"
 )
 
 // maxObservationChars is the maximum length of a tool observation kept in the
@@ -20,9 +21,10 @@ const maxObservationChars = 128 * 1024 // 128 KB
 
 
 type engineLoopState struct {
-	runID string
-	model string
-	log   *slog.Logger
+	runID   string
+	model   string
+	trigger string
+	log     *slog.Logger
 
 	messages        []llm.Message
 	agentCtx        *Context
@@ -43,6 +45,18 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 	if st == nil || st.agentCtx == nil {
 		return nil, nil, fmt.Errorf("nil engine state")
 	}
+	final, agentCtx, err := e.runSteps(tools.WithRunID(ctx, st.runID), st)
+	if final != nil {
+		if _, pending := final.Output.(PendingOutput); pending {
+			// Keep per-run tool state (e.g. bash sessions) alive until the run resumes.
+			return final, agentCtx, err
+		}
+	}
+	e.registry.FinishRun(st.runID)
+	return final, agentCtx, err
+}
+
+func (e *Engine) runSteps(ctx context.Context, st *engineLoopState) (*Final, *Context, error) {
 	log := st.log
 	if log == nil {
 		log = slog.Default()
@@ -182,7 +196,7 @@ func (e *Engine) runLoop(ctx context.Context, st *engineLoopState) (*Final, *Con
 				// OutputPublish guard hook (redact-only).
 				if e.guard != nil && e.guard.Enabled() {
 					if s, ok := fp.Output.(string); ok && strings.TrimSpace(s) != "" {
-						gr, _ := e.guard.Evaluate(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC()}, guard.Action{
+						gr, _ := e.guard.Evaluate(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC(), Trigger: st.trigger}, guard.Action{
 							Type:    guard.ActionOutputPublish,
 							Content: s,
 						})
@@ -313,7 +327,7 @@ func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState,
 
 	// Guard pre-tool decision.
 	if e.guard != nil && e.guard.Enabled() {
-		gr, _ := e.guard.Evaluate(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC()}, guard.Action{
+		gr, _ := e.guard.Evaluate(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC(), Trigger: st.trigger}, guard.Action{
 			Type:       guard.ActionToolCallPre,
 			ToolName:   tc.Name,
 			ToolParams: tc.Params,
@@ -331,6 +345,7 @@ func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState,
 			rs := resumeStateV1{
 				RunID:             st.runID,
 				Model:             st.model,
+				Trigger:           st.trigger,
 				Step:              step,
 				PlanRequired:      st.planRequired,
 				ParseFailures:     st.parseFailures,
@@ -349,7 +364,7 @@ func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState,
 				return "", err, nil, false
 			}
 			sum := fmt.Sprintf("ToolCallPre tool=%s", tc.Name)
-			id, err := e.guard.RequestApproval(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC()}, guard.Action{
+			id, err := e.guard.RequestApproval(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC(), Trigger: st.trigger}, guard.Action{
 				Type:       guard.ActionToolCallPre,
 				ToolName:   tc.Name,
 				ToolParams: tc.Params,
@@ -371,10 +386,10 @@ func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState,
 	}
 
 	toolCtx := ctx
-	if e.guard != nil && e.guard.Enabled() && strings.EqualFold(tc.Name, "url_fetch") {
-		// Only enforce guard-level URL allowlists for unauthenticated url_fetch calls.
+	if e.guard != nil && e.guard.Enabled() && (strings.EqualFold(tc.Name, "url_fetch") || strings.EqualFold(tc.Name, "crawl")) {
+		// Only enforce guard-level URL allowlists for unauthenticated url_fetch/crawl calls.
 		authProfile, _ := tc.Params["auth_profile"].(string)
-		if strings.TrimSpace(authProfile) == "" {
+		if strings.TrimSpace(authProfile) == "" || strings.EqualFold(tc.Name, "crawl") {
 			if p, ok := e.guard.NetworkPolicyForURLFetch(); ok && len(p.AllowedURLPrefixes) > 0 {
 				toolCtx = guard.WithNetworkPolicy(toolCtx, p)
 			}
@@ -392,10 +407,11 @@ func (e *Engine) executeToolWithGuard(ctx context.Context, st *engineLoopState,
 
 	// Guard post-tool redaction (runs even when toolErr != nil).
 	if e.guard != nil && e.guard.Enabled() {
-		gr, _ := e.guard.Evaluate(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC()}, guard.Action{
-			Type:     guard.ActionToolCallPost,
-			ToolName: tc.Name,
-			Content:  observation,
+		gr, _ := e.guard.Evaluate(ctx, guard.Meta{RunID: st.runID, Step: step, Time: time.Now().UTC(), Trigger: st.trigger}, guard.Action{
+			Type:       guard.ActionToolCallPost,
+			ToolName:   tc.Name,
+			ToolParams: tc.Params,
+			Content:    observation,
 		})
 		switch gr.Decision {
 		case guard.DecisionAllowWithRedact:
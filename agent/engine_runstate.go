@@ -10,9 +10,10 @@ import (
 type resumeStateV1 struct {
 	Version int `json:"v"`
 
-	RunID string `json:"run_id"`
-	Model string `json:"model"`
-	Step  int    `json:"step"`
+	RunID   string `json:"run_id"`
+	Model   string `json:"model"`
+	Trigger string `json:"trigger,omitempty"`
+	Step    int    `json:"step"`
 
 	PlanRequired  bool `json:"plan_required"`
 	ParseFailures int  `json:"parse_failures"`
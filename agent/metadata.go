@@ -44,3 +44,9 @@ func buildInjectedMetaMessage(meta map[string]any) (string, bool) {
 	}
 	return string(b), true
 }
+
+// runTrigger returns meta["trigger"] (e.g. "telegram", "cron"), used by guard rules.
+func runTrigger(meta map[string]any) string {
+	s, _ := meta["trigger"].(string)
+	return strings.TrimSpace(s)
+}
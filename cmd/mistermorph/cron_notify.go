@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+	"github.com/quailyquaily/mistermorph/scheduler"
+	"github.com/quailyquaily/mistermorph/tools"
+	"github.com/quailyquaily/mistermorph/tools/builtin"
+)
+
+// cronResultMessage is the notification text for a finished cron run: the
+// agent's summary on success, otherwise the status and error.
+func cronResultMessage(job models.CronJob, status string, errStr *string, summary *string) string {
+	if status == scheduler.StatusSuccess && summary != nil && strings.TrimSpace(*summary) != "" {
+		return strings.TrimSpace(*summary)
+	}
+	details := ""
+	if errStr != nil && strings.TrimSpace(*errStr) != "" {
+		details = ": " + strings.TrimSpace(*errStr)
+	}
+	return fmt.Sprintf("cron job %s (%s) %s%s", strings.TrimSpace(job.Name), job.ID, status, details)
+}
+
+// cronJobNotifier delivers cron results to a job's email and webhook targets
+// using the send_email/send_webhook tools registered in reg (and their config).
+// Telegram delivery is wired separately by the telegram runtime.
+func cronJobNotifier(reg *tools.Registry) func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+	return func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+		emailTo := ""
+		if job.NotifyEmail != nil {
+			emailTo = strings.TrimSpace(*job.NotifyEmail)
+		}
+		webhook := ""
+		if job.NotifyWebhook != nil {
+			webhook = strings.TrimSpace(*job.NotifyWebhook)
+		}
+		if emailTo == "" && webhook == "" {
+			return nil
+		}
+
+		msg := cronResultMessage(job, status, errStr, summary)
+		title := fmt.Sprintf("Scheduled job %s: %s", strings.TrimSpace(job.Name), status)
+		var errs []error
+
+		if emailTo != "" {
+			if se, ok := registeredTool[*builtin.SendEmailTool](reg, "send_email"); ok {
+				_, err := se.Send(ctx, builtin.EmailMessage{To: strings.Split(emailTo, ","), Subject: title, Body: msg})
+				if err != nil {
+					errs = append(errs, fmt.Errorf("notify email: %w", err))
+				}
+			} else {
+				errs = append(errs, fmt.Errorf("notify email: send_email is not enabled (tools.send_email.enabled)"))
+			}
+		}
+
+		if webhook != "" {
+			if sw, ok := registeredTool[*builtin.SendWebhookTool](reg, "send_webhook"); ok {
+				code, _, err := sw.Send(ctx, webhook, builtin.WebhookPayload{
+					Title:   title,
+					Message: msg,
+					Data: map[string]any{
+						"job_id":            job.ID,
+						"job_name":          job.Name,
+						"run_id":            run.ID,
+						"status":            status,
+						"scheduled_for_utc": time.Unix(run.ScheduledFor, 0).UTC().Format(time.RFC3339),
+					},
+				})
+				if err == nil && code >= 300 {
+					err = fmt.Errorf("HTTP %d", code)
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("notify webhook: %w", err))
+				}
+			} else {
+				errs = append(errs, fmt.Errorf("notify webhook: send_webhook is not enabled (tools.send_webhook.enabled)"))
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+func registeredTool[T tools.Tool](reg *tools.Registry, name string) (T, bool) {
+	var zero T
+	if reg == nil {
+		return zero, false
+	}
+	t, ok := reg.Get(name)
+	if !ok {
+		return zero, false
+	}
+	v, ok := t.(T)
+	return v, ok
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/db/models"
+	"github.com/quailyquaily/mistermorph/tools"
+	"github.com/quailyquaily/mistermorph/tools/builtin"
+)
+
+func TestCronJobNotifier(t *testing.T) {
+	var payload builtin.WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer srv.Close()
+
+	reg := tools.NewRegistry()
+	reg.Register(builtin.NewSendWebhookTool(map[string]builtin.WebhookTarget{"ops": {URL: srv.URL}}, nil, 5*time.Second, ""))
+	notify := cronJobNotifier(reg)
+
+	webhook := "ops"
+	summary := "3 new issues"
+	job := models.CronJob{ID: "j1", Name: "triage", NotifyWebhook: &webhook}
+	run := models.CronRun{ID: "r1", ScheduledFor: 1700000000}
+	if err := notify(context.Background(), job, run, "succeeded", nil, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Message != summary || payload.Title != "Scheduled job triage: succeeded" {
+		t.Fatalf("payload=%+v", payload)
+	}
+	if data, _ := payload.Data.(map[string]any); data["run_id"] != "r1" || data["scheduled_for_utc"] != "2023-11-14T22:13:20Z" {
+		t.Fatalf("data=%v", payload.Data)
+	}
+
+	email := "ops@example.com"
+	job.NotifyEmail = &email
+	errStr := "boom"
+	err := notify(context.Background(), job, run, "failed", &errStr, nil)
+	if err == nil || !strings.Contains(err.Error(), "send_email is not enabled") {
+		t.Fatalf("expected missing send_email error, got %v", err)
+	}
+	if payload.Message != "cron job triage (j1) failed: boom" {
+		t.Fatalf("failure message=%q", payload.Message)
+	}
+}
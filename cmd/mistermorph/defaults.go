@@ -90,11 +90,49 @@ func initViperDefaults() {
 	viper.SetDefault("guard.network.url_fetch.allow_proxy", false)
 	viper.SetDefault("guard.redaction.enabled", true)
 	viper.SetDefault("guard.redaction.patterns", []map[string]any{})
+	viper.SetDefault("guard.redaction.detectors.private_key_block", true)
+	viper.SetDefault("guard.redaction.detectors.aws_key", true)
+	viper.SetDefault("guard.redaction.detectors.gcp_key", true)
+	viper.SetDefault("guard.redaction.detectors.github_token", true)
+	viper.SetDefault("guard.redaction.detectors.slack_token", true)
+	viper.SetDefault("guard.redaction.detectors.jwt_like", true)
+	viper.SetDefault("guard.redaction.detectors.bearer_line", true)
+	viper.SetDefault("guard.redaction.detectors.simple_kv", true)
+	viper.SetDefault("guard.redaction.detectors.email", false)
+	viper.SetDefault("guard.redaction.detectors.credit_card", true)
+	viper.SetDefault("guard.redaction.detectors.iban", true)
+	viper.SetDefault("guard.redaction.detectors.national_id", false)
+	viper.SetDefault("guard.redaction.detectors.phone", false)
 	viper.SetDefault("guard.bash.require_approval", true)
+	viper.SetDefault("guard.bash.allow_read_only", false)
+	viper.SetDefault("guard.bash.deny_programs", []string{"mkfs*", "shutdown", "reboot", "halt", "poweroff"})
+	viper.SetDefault("guard.bash.read_only_programs", []string{})
+	viper.SetDefault("guard.file_write.require_approval", false)
+	viper.SetDefault("guard.git.require_approval", true)
+	viper.SetDefault("guard.run_code.require_approval", false)
+	viper.SetDefault("guard.rules_file", "")
+	viper.SetDefault("guard.injection.enabled", true)
+	viper.SetDefault("guard.injection.threshold", 3)
+	viper.SetDefault("guard.injection.escalate_approval", false)
+	viper.SetDefault("guard.injection.escalate_tools", []string{
+		"bash", "run_code", "write_file", "edit_file", "git", "file_archive", "sql_query",
+		"url_fetch", "crawl", "send_email", "send_webhook", "schedule_job", "unschedule_job",
+		"telegram_send_*",
+	})
+	viper.SetDefault("guard.dlp.enabled", true)
+	viper.SetDefault("guard.dlp.action", "deny")
+	viper.SetDefault("guard.dlp.tools", []string{"url_fetch", "crawl", "web_search", "send_email", "send_webhook"})
+	viper.SetDefault("guard.dlp.canary_tokens", []string{})
+	viper.SetDefault("guard.dlp.secret_patterns", true)
 	viper.SetDefault("guard.audit.jsonl_path", "")
 	viper.SetDefault("guard.audit.rotate_max_bytes", int64(100*1024*1024))
 	viper.SetDefault("guard.approvals.enabled", true)
 
+	// MCP server mode (mcp-serve).
+	viper.SetDefault("mcp.serve.expose_tools", true)
+	viper.SetDefault("mcp.serve.run_task", true)
+	viper.SetDefault("mcp.serve.tools", []string{})
+
 	// Scheduler (cron) - disabled by default.
 	viper.SetDefault("scheduler.enabled", false)
 	viper.SetDefault("scheduler.concurrency", 1)
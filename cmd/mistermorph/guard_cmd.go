@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/guard"
+	"github.com/quailyquaily/mistermorph/internal/pathutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+func newGuardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "guard",
+		Short: "Inspect and test guard policy",
+	}
+
+	cmd.AddCommand(newGuardTestCmd())
+	return cmd
+}
+
+// guardTestSample is one sample action in a `guard test` file.
+type guardTestSample struct {
+	Name    string         `yaml:"name"`
+	Type    string         `yaml:"type"` // tool_call_pre (default), tool_call_post, output_publish
+	Tool    string         `yaml:"tool"`
+	Params  map[string]any `yaml:"params"`
+	Content string         `yaml:"content"`
+	Trigger string         `yaml:"trigger"`
+	Time    string         `yaml:"time"` // RFC3339; default now
+	Expect  string         `yaml:"expect"`
+}
+
+func newGuardTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test <samples.yaml>",
+		Short: "Dry-run guard rules against sample actions",
+		Long: "Evaluates each sample action with the configured guard policy (guard.* config plus the rules file)\n" +
+			"and prints the decision. Nothing is executed and no audit events are written.\n" +
+			"Exits non-zero when a sample's `expect` does not match.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rulesFile, _ := cmd.Flags().GetString("rules")
+			if strings.TrimSpace(rulesFile) == "" {
+				rulesFile = viper.GetString("guard.rules_file")
+			}
+			cfg := guardConfigFromViper()
+			if strings.TrimSpace(rulesFile) != "" {
+				rs, err := guard.LoadRulesFile(pathutil.ExpandHomePath(strings.TrimSpace(rulesFile)))
+				if err != nil {
+					return err
+				}
+				cfg.Rules = rs
+			}
+
+			b, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			var samples []guardTestSample
+			if err := yaml.Unmarshal(b, &samples); err != nil {
+				return fmt.Errorf("invalid samples file: %w", err)
+			}
+			failed, err := runGuardTest(cmd.Context(), guard.New(cfg, nil, nil), samples, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d samples did not match expect", failed, len(samples))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("rules", "", "Rules file (defaults to guard.rules_file).")
+
+	return cmd
+}
+
+var guardTestActionTypes = map[string]guard.ActionType{
+	"":               guard.ActionToolCallPre,
+	"tool_call_pre":  guard.ActionToolCallPre,
+	"tool_call_post": guard.ActionToolCallPost,
+	"output_publish": guard.ActionOutputPublish,
+}
+
+func runGuardTest(ctx context.Context, g *guard.Guard, samples []guardTestSample, w io.Writer) (int, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	failed := 0
+	for i, s := range samples {
+		name := strings.TrimSpace(s.Name)
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		typ, ok := guardTestActionTypes[strings.ToLower(strings.TrimSpace(s.Type))]
+		if !ok {
+			return failed, fmt.Errorf("sample %s: unknown type %q", name, s.Type)
+		}
+		meta := guard.Meta{RunID: "guard_test", Step: i, Trigger: strings.TrimSpace(s.Trigger)}
+		if strings.TrimSpace(s.Time) != "" {
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(s.Time))
+			if err != nil {
+				return failed, fmt.Errorf("sample %s: invalid time %q (expected RFC3339)", name, s.Time)
+			}
+			meta.Time = t
+		}
+		res, err := g.Evaluate(ctx, meta, guard.Action{
+			Type:       typ,
+			ToolName:   strings.TrimSpace(s.Tool),
+			ToolParams: s.Params,
+			Content:    s.Content,
+		})
+		if err != nil {
+			return failed, fmt.Errorf("sample %s: %w", name, err)
+		}
+
+		status := "-"
+		if expect := strings.ToLower(strings.TrimSpace(s.Expect)); expect != "" {
+			if expect == guard.RuleDecisionRedact {
+				expect = string(guard.DecisionAllowWithRedact)
+			}
+			status = "PASS"
+			if expect != string(res.Decision) {
+				status = "FAIL"
+				failed++
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\tdecision=%s\trisk=%s", status, name, res.Decision, res.RiskLevel)
+		if len(res.Reasons) > 0 {
+			fmt.Fprintf(w, "\treasons=%s", strings.Join(res.Reasons, ","))
+		}
+		if status == "FAIL" {
+			fmt.Fprintf(w, "\texpected=%s", s.Expect)
+		}
+		fmt.Fprintln(w)
+		if res.RedactedContent != "" {
+			fmt.Fprintf(w, "\tredacted: %s\n", res.RedactedContent)
+		}
+	}
+	return failed, nil
+}
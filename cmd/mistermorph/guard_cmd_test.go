@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quailyquaily/mistermorph/guard"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRunGuardTest(t *testing.T) {
+	rs, err := guard.ParseRules([]byte(`
+rules:
+  - name: cron-no-email
+    tools: [send_email]
+    triggers: [cron]
+    decision: deny
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := guard.New(guard.Config{Enabled: true, Bash: guard.BashConfig{RequireApproval: true}, Rules: rs}, nil, nil)
+
+	var samples []guardTestSample
+	if err := yaml.Unmarshal([]byte(`
+- name: cron email
+  tool: send_email
+  trigger: cron
+  expect: deny
+- name: chat email
+  tool: send_email
+  trigger: telegram
+  expect: deny
+- tool: bash
+  params: {cmd: ls}
+  expect: require_approval
+- name: publish
+  type: output_publish
+  content: "token=abcdefghijklmnop"
+  expect: redact
+`), &samples); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	failed, err := runGuardTest(context.Background(), g, samples, &out)
+	if err != nil {
+		t.Fatalf("runGuardTest: %v", err)
+	}
+	if failed != 1 {
+		t.Fatalf("failed = %d, want 1\n%s", failed, out.String())
+	}
+	for _, want := range []string{
+		"PASS\tcron email\tdecision=deny\trisk=high\treasons=rule:cron-no-email\n",
+		"FAIL\tchat email\tdecision=allow",
+		"PASS\t#3\tdecision=require_approval",
+		"PASS\tpublish\tdecision=allow_with_redaction",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, out.String())
+		}
+	}
+
+	if _, err := runGuardTest(context.Background(), g, []guardTestSample{{Type: "later"}}, &out); err == nil {
+		t.Fatalf("expected unknown type error")
+	}
+}
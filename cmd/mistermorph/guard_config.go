@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"github.com/quailyquaily/mistermorph/db"
 	"github.com/quailyquaily/mistermorph/guard"
 	"github.com/quailyquaily/mistermorph/internal/pathutil"
+	"github.com/quailyquaily/mistermorph/secrets"
 	"github.com/spf13/viper"
 )
 
@@ -20,34 +22,21 @@ func guardFromViper(log *slog.Logger) *guard.Guard {
 		log = slog.Default()
 	}
 
-	var patterns []guard.RegexPattern
-	_ = viper.UnmarshalKey("guard.redaction.patterns", &patterns)
+	cfg := guardConfigFromViper()
 
-	cfg := guard.Config{
-		Enabled: true,
-		Network: guard.NetworkConfig{
-			URLFetch: guard.URLFetchNetworkPolicy{
-				AllowedURLPrefixes: viper.GetStringSlice("guard.network.url_fetch.allowed_url_prefixes"),
-				DenyPrivateIPs:     viper.GetBool("guard.network.url_fetch.deny_private_ips"),
-				ResolveDNS:         viper.GetBool("guard.network.url_fetch.resolve_dns"),
-				FollowRedirects:    viper.GetBool("guard.network.url_fetch.follow_redirects"),
-				AllowProxy:         viper.GetBool("guard.network.url_fetch.allow_proxy"),
-			},
-		},
-		Redaction: guard.RedactionConfig{
-			Enabled:  viper.GetBool("guard.redaction.enabled"),
-			Patterns: patterns,
-		},
-		Bash: guard.BashConfig{
-			RequireApproval: viper.GetBool("guard.bash.require_approval"),
-		},
-		Audit: guard.AuditConfig{
-			JSONLPath:      strings.TrimSpace(viper.GetString("guard.audit.jsonl_path")),
-			RotateMaxBytes: viper.GetInt64("guard.audit.rotate_max_bytes"),
-		},
-		Approvals: guard.ApprovalsConfig{
-			Enabled: viper.GetBool("guard.approvals.enabled"),
-		},
+	if rulesFile := strings.TrimSpace(viper.GetString("guard.rules_file")); rulesFile != "" {
+		rs, err := guard.LoadRulesFile(pathutil.ExpandHomePath(rulesFile))
+		if err != nil {
+			// Fail closed: a broken policy file must not silently fall back to built-ins.
+			log.Error("guard_rules_error", "path", rulesFile, "error", err.Error())
+			rs, _ = guard.CompileRules([]guard.Rule{{
+				Name:     "rules_file_invalid",
+				Decision: string(guard.DecisionDeny),
+				Risk:     guard.RiskCritical,
+				Reason:   "guard_rules_file_invalid",
+			}})
+		}
+		cfg.Rules = rs
 	}
 
 	jsonlPath := strings.TrimSpace(cfg.Audit.JSONLPath)
@@ -88,9 +77,101 @@ func guardFromViper(log *slog.Logger) *guard.Guard {
 	log.Info("guard_enabled",
 		"url_fetch_prefixes", len(cfg.Network.URLFetch.AllowedURLPrefixes),
 		"bash_require_approval", cfg.Bash.RequireApproval,
+		"rules", cfg.Rules.Len(),
 		"audit_jsonl", jsonlPath,
 		"approvals_enabled", approvals != nil,
 	)
 
-	return guard.New(cfg, sink, approvals)
+	g := guard.New(cfg, sink, approvals)
+	if cfg.DLP.Enabled {
+		registerAuthProfileSecrets(g)
+	}
+	return g
+}
+
+// registerAuthProfileSecrets marks every resolvable auth_profiles credential as
+// a sensitive value, so the agent cannot copy it into outbound tool params.
+// Profiles whose secret cannot be resolved are skipped.
+func registerAuthProfileSecrets(g *guard.Guard) {
+	var profiles map[string]secrets.AuthProfile
+	_ = viper.UnmarshalKey("auth_profiles", &profiles)
+	if len(profiles) == 0 {
+		return
+	}
+	aliases := make(map[string]string)
+	_ = viper.UnmarshalKey("secrets.aliases", &aliases)
+	resolver := &secrets.EnvResolver{Aliases: aliases}
+	for id, p := range profiles {
+		sec, err := resolver.Resolve(context.Background(), p.Credential.SecretRef)
+		if err != nil {
+			continue
+		}
+		g.AddSensitiveValues("auth_profile:"+id, sec)
+	}
+}
+
+// guardConfigFromViper builds the guard config (without rules) from guard.* keys.
+func guardConfigFromViper() guard.Config {
+	var patterns []guard.RegexPattern
+	_ = viper.UnmarshalKey("guard.redaction.patterns", &patterns)
+	detectors := make(map[string]bool)
+	for _, name := range guard.RedactionDetectors() {
+		key := "guard.redaction.detectors." + name
+		if viper.IsSet(key) {
+			detectors[name] = viper.GetBool(key)
+		}
+	}
+
+	return guard.Config{
+		Enabled: true,
+		Network: guard.NetworkConfig{
+			URLFetch: guard.URLFetchNetworkPolicy{
+				AllowedURLPrefixes: viper.GetStringSlice("guard.network.url_fetch.allowed_url_prefixes"),
+				DenyPrivateIPs:     viper.GetBool("guard.network.url_fetch.deny_private_ips"),
+				ResolveDNS:         viper.GetBool("guard.network.url_fetch.resolve_dns"),
+				FollowRedirects:    viper.GetBool("guard.network.url_fetch.follow_redirects"),
+				AllowProxy:         viper.GetBool("guard.network.url_fetch.allow_proxy"),
+			},
+		},
+		Redaction: guard.RedactionConfig{
+			Enabled:   viper.GetBool("guard.redaction.enabled"),
+			Patterns:  patterns,
+			Detectors: detectors,
+		},
+		Bash: guard.BashConfig{
+			RequireApproval:  viper.GetBool("guard.bash.require_approval"),
+			AllowReadOnly:    viper.GetBool("guard.bash.allow_read_only"),
+			DenyPrograms:     viper.GetStringSlice("guard.bash.deny_programs"),
+			ReadOnlyPrograms: viper.GetStringSlice("guard.bash.read_only_programs"),
+		},
+		FileWrite: guard.FileWriteConfig{
+			RequireApproval: viper.GetBool("guard.file_write.require_approval"),
+		},
+		Git: guard.GitConfig{
+			RequireApproval: viper.GetBool("guard.git.require_approval"),
+		},
+		RunCode: guard.RunCodeConfig{
+			RequireApproval: viper.GetBool("guard.run_code.require_approval"),
+		},
+		Injection: guard.InjectionConfig{
+			Enabled:          viper.GetBool("guard.injection.enabled"),
+			Threshold:        viper.GetInt("guard.injection.threshold"),
+			EscalateApproval: viper.GetBool("guard.injection.escalate_approval"),
+			EscalateTools:    viper.GetStringSlice("guard.injection.escalate_tools"),
+		},
+		DLP: guard.DLPConfig{
+			Enabled:        viper.GetBool("guard.dlp.enabled"),
+			Action:         strings.TrimSpace(viper.GetString("guard.dlp.action")),
+			Tools:          viper.GetStringSlice("guard.dlp.tools"),
+			CanaryTokens:   viper.GetStringSlice("guard.dlp.canary_tokens"),
+			SecretPatterns: viper.GetBool("guard.dlp.secret_patterns"),
+		},
+		Audit: guard.AuditConfig{
+			JSONLPath:      strings.TrimSpace(viper.GetString("guard.audit.jsonl_path")),
+			RotateMaxBytes: viper.GetInt64("guard.audit.rotate_max_bytes"),
+		},
+		Approvals: guard.ApprovalsConfig{
+			Enabled: viper.GetBool("guard.approvals.enabled"),
+		},
+	}
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/quailyquaily/mistermorph/mcp"
+	"github.com/quailyquaily/mistermorph/tools"
+	"github.com/spf13/viper"
+)
+
+var (
+	mcpConnsOnce sync.Once
+	mcpConns     []*mcp.Connection
+)
+
+// registerMCPTools mounts tools from servers configured under `mcp.servers`.
+// Connections are established once per process and shared by every registry.
+func registerMCPTools(r *tools.Registry) {
+	mcpConnsOnce.Do(func() {
+		var servers []mcp.ServerConfig
+		if err := viper.UnmarshalKey("mcp.servers", &servers); err != nil {
+			slog.Default().Warn("mcp_config_invalid", "error", err.Error())
+			return
+		}
+		if len(servers) == 0 {
+			return
+		}
+		scratch := tools.NewRegistry()
+		mcpConns = mcp.Mount(context.Background(), scratch, servers, mcp.ClientInfo{Name: "mistermorph", Version: "dev"}, slog.Default())
+	})
+	for _, conn := range mcpConns {
+		for _, t := range conn.Tools {
+			if _, exists := r.Get(t.Name()); exists {
+				slog.Default().Warn("mcp_tool_name_conflict", "server", conn.Name, "tool", t.Name())
+				continue
+			}
+			r.Register(t)
+		}
+	}
+}
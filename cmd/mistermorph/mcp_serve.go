@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/agent"
+	"github.com/quailyquaily/mistermorph/guard"
+	"github.com/quailyquaily/mistermorph/llm"
+	"github.com/quailyquaily/mistermorph/mcp"
+	"github.com/quailyquaily/mistermorph/tools"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newMCPServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp-serve",
+		Short: "Expose tools and a run_task tool over MCP (stdio)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// stdout carries the protocol; logs already go to stderr.
+			logger, err := loggerFromViper()
+			if err != nil {
+				return err
+			}
+			slog.SetDefault(logger)
+
+			reg := registryFromViper()
+			sharedGuard := guardFromViper(logger)
+
+			var exposed []tools.Tool
+			if flagOrViperBool(cmd, "mcp-expose-tools", "mcp.serve.expose_tools") {
+				allow := make(map[string]bool)
+				for _, name := range viper.GetStringSlice("mcp.serve.tools") {
+					if name = strings.TrimSpace(name); name != "" {
+						allow[name] = true
+					}
+				}
+				for _, t := range reg.All() {
+					if len(allow) > 0 && !allow[t.Name()] {
+						continue
+					}
+					exposed = append(exposed, t)
+				}
+			}
+
+			if flagOrViperBool(cmd, "mcp-run-task", "mcp.serve.run_task") {
+				client, err := llmClientFromConfig(llmClientConfig{
+					Provider:       llmProviderFromViper(),
+					Endpoint:       llmEndpointFromViper(),
+					APIKey:         llmAPIKeyFromViper(),
+					RequestTimeout: viper.GetDuration("llm.request_timeout"),
+				})
+				if err != nil {
+					return err
+				}
+				exposed = append(exposed, newRunTaskTool(runTaskDeps{
+					logger:  logger,
+					logOpts: logOptionsFromViper(),
+					client:  client,
+					reg:     reg,
+					guard:   sharedGuard,
+					model:   llmModelFromViper(),
+					timeout: viper.GetDuration("timeout"),
+					cfg: agent.Config{
+						MaxSteps:       viper.GetInt("max_steps"),
+						ParseRetries:   viper.GetInt("parse_retries"),
+						MaxTokenBudget: viper.GetInt("max_token_budget"),
+						PlanMode:       viper.GetString("plan.mode"),
+					},
+				}))
+			}
+
+			names := make([]string, 0, len(exposed))
+			for _, t := range exposed {
+				names = append(names, t.Name())
+			}
+			logger.Info("mcp_serve_start", "tools", names, "guard", sharedGuard.Enabled())
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			srv := mcp.NewServer(mcp.ServerInfo{Name: "mistermorph", Version: "dev"}, exposed, guardedToolCall(sharedGuard, logger))
+			err = srv.Serve(ctx, os.Stdin, os.Stdout)
+			_ = sharedGuard.Close()
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("mcp-expose-tools", true, "Expose the configured tool registry (subject to guard policy).")
+	cmd.Flags().Bool("mcp-run-task", true, "Expose a run_task tool that runs a full agent task.")
+
+	return cmd
+}
+
+// guardedToolCall applies the same guard pipeline the agent loop uses:
+// pre-call policy (deny / approval), url_fetch network policy and post-call redaction.
+// There is no operator in the loop over MCP, so require_approval is treated as deny.
+// All calls share one run id, because the stdio server serves a single client
+// session. After flagged output, calls to escalate_tools are therefore refused
+// for the rest of the session: the taint only clears on an approval.
+func guardedToolCall(g *guard.Guard, log *slog.Logger) mcp.CallFunc {
+	runID := fmt.Sprintf("mcp_%x", rand.Uint64())
+	return func(ctx context.Context, t tools.Tool, params map[string]any) (string, error) {
+		if g == nil || !g.Enabled() {
+			return t.Execute(ctx, params)
+		}
+		meta := guard.Meta{RunID: runID, Step: 0, Time: time.Now().UTC(), Trigger: "mcp"}
+		pre, _ := g.Evaluate(ctx, meta, guard.Action{
+			Type:       guard.ActionToolCallPre,
+			ToolName:   t.Name(),
+			ToolParams: params,
+		})
+		switch pre.Decision {
+		case guard.DecisionDeny:
+			return "", fmt.Errorf("blocked by guard (%s)", strings.Join(pre.Reasons, "; "))
+		case guard.DecisionRequireApproval:
+			log.Warn("mcp_tool_requires_approval", "tool", t.Name(), "reasons", pre.Reasons)
+			return "", fmt.Errorf("blocked by guard: approval required (%s); approvals are not available over MCP", strings.Join(pre.Reasons, "; "))
+		}
+
+		toolCtx := ctx
+		if strings.EqualFold(t.Name(), "url_fetch") || strings.EqualFold(t.Name(), "crawl") {
+			authProfile, _ := params["auth_profile"].(string)
+			if strings.TrimSpace(authProfile) == "" || strings.EqualFold(t.Name(), "crawl") {
+				if p, ok := g.NetworkPolicyForURLFetch(); ok && len(p.AllowedURLPrefixes) > 0 {
+					toolCtx = guard.WithNetworkPolicy(toolCtx, p)
+				}
+			}
+		}
+
+		out, err := t.Execute(toolCtx, params)
+		post, _ := g.Evaluate(ctx, meta, guard.Action{
+			Type:       guard.ActionToolCallPost,
+			ToolName:   t.Name(),
+			ToolParams: params,
+			Content:    out,
+		})
+		switch post.Decision {
+		case guard.DecisionAllowWithRedact:
+			if strings.TrimSpace(post.RedactedContent) != "" {
+				out = post.RedactedContent
+			}
+		case guard.DecisionDeny:
+			return "", fmt.Errorf("blocked by guard (tool output)")
+		}
+		return out, err
+	}
+}
+
+type runTaskDeps struct {
+	logger  *slog.Logger
+	logOpts agent.LogOptions
+	client  llm.Client
+	reg     *tools.Registry
+	guard   *guard.Guard
+	model   string
+	timeout time.Duration
+	cfg     agent.Config
+}
+
+type runTaskParams struct {
+	Task  string `json:"task" desc:"Task for the agent to complete."`
+	Model string `json:"model,omitempty" desc:"Optional model override."`
+}
+
+// newRunTaskTool exposes a full agent run (skills selection, secrets profiles and guard)
+// as a single tool. The nested run executes registry tools directly, so the outer
+// MCP guard wrapper only sees the task text.
+func newRunTaskTool(d runTaskDeps) tools.Tool {
+	return tools.NewTyped("run_task", "Runs a mistermorph agent task with the configured tools and skills, and returns the final output.",
+		func(ctx context.Context, p runTaskParams) (string, error) {
+			model := strings.TrimSpace(p.Model)
+			if model == "" {
+				model = d.model
+			}
+			if d.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d.timeout)
+				defer cancel()
+			}
+			final, _, err := runOneTask(ctx, d.logger, d.logOpts, d.client, d.reg, d.cfg, d.guard, p.Task, model, map[string]any{"trigger": "mcp"})
+			if err != nil {
+				return "", err
+			}
+			if id, ok := pendingApprovalID(final); ok {
+				return "", fmt.Errorf("approval required: %s", id)
+			}
+			if final == nil || final.Output == nil {
+				return "", nil
+			}
+			if s, ok := final.Output.(string); ok {
+				return s, nil
+			}
+			b, err := json.MarshalIndent(final.Output, "", "  ")
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/quailyquaily/mistermorph/tools"
+	"github.com/quailyquaily/mistermorph/tools/plugin"
+	"github.com/spf13/viper"
+)
+
+var (
+	pluginsOnce   sync.Once
+	pluginsLoaded []*plugin.Plugin
+)
+
+// registerPluginTools starts plugins configured under `tools.plugins` (once per
+// process) and registers their declared tools.
+func registerPluginTools(r *tools.Registry) {
+	pluginsOnce.Do(func() {
+		var cfgs []plugin.Config
+		if err := viper.UnmarshalKey("tools.plugins", &cfgs); err != nil {
+			slog.Default().Warn("plugins_config_invalid", "error", err.Error())
+			return
+		}
+		if len(cfgs) == 0 {
+			return
+		}
+		pluginsLoaded = plugin.Load(context.Background(), tools.NewRegistry(), cfgs, slog.Default())
+	})
+	for _, p := range pluginsLoaded {
+		for _, t := range p.AsTools() {
+			if _, exists := r.Get(t.Name()); exists {
+				slog.Default().Warn("plugin_tool_name_conflict", "plugin", p.Name(), "tool", t.Name())
+				continue
+			}
+			r.Register(t)
+		}
+	}
+}
@@ -18,23 +18,100 @@ func registryFromViper() *tools.Registry {
 
 	viper.SetDefault("tools.read_file.max_bytes", 256*1024)
 	viper.SetDefault("tools.read_file.deny_paths", []string{"config.yaml"})
+	viper.SetDefault("tools.read_file.max_document_bytes", 32*1024*1024)
+
+	viper.SetDefault("tools.list_dir.enabled", true)
+	viper.SetDefault("tools.list_dir.max_entries", 500)
+	viper.SetDefault("tools.glob.enabled", true)
+	viper.SetDefault("tools.glob.max_results", 500)
+	viper.SetDefault("tools.grep.enabled", true)
+	viper.SetDefault("tools.grep.max_results", 200)
+	viper.SetDefault("tools.grep.max_file_bytes", int64(4*1024*1024))
+
+	viper.SetDefault("tools.git.enabled", true)
+	viper.SetDefault("tools.git.allow_write", false)
+	viper.SetDefault("tools.git.timeout", 30*time.Second)
+	viper.SetDefault("tools.git.max_output_bytes", 64*1024)
+
+	viper.SetDefault("tools.sql_query.enabled", true)
+	viper.SetDefault("tools.sql_query.databases", map[string]string{})
+	viper.SetDefault("tools.sql_query.max_rows", 200)
+	viper.SetDefault("tools.sql_query.timeout", 10*time.Second)
+	viper.SetDefault("tools.sql_query.max_csv_bytes", int64(64*1024*1024))
+
+	viper.SetDefault("tools.json_query.enabled", true)
+	viper.SetDefault("tools.json_query.max_input_bytes", int64(64*1024*1024))
+	viper.SetDefault("tools.json_query.max_output_bytes", 32*1024)
+	viper.SetDefault("tools.file_archive.enabled", true)
+	viper.SetDefault("tools.file_archive.max_entries", 10000)
+	viper.SetDefault("tools.file_archive.max_total_bytes", int64(512*1024*1024))
+
+	viper.SetDefault("tools.run_code.enabled", false)
+	viper.SetDefault("tools.run_code.python", "python3")
+	viper.SetDefault("tools.run_code.timeout", 60*time.Second)
+	viper.SetDefault("tools.run_code.max_output_bytes", 64*1024)
+	viper.SetDefault("tools.run_code.allow_host_python", false)
+	viper.SetDefault("tools.run_code.javascript_max_memory_mb", 256)
+	viper.SetDefault("tools.run_code.sandbox.enabled", false)
+	viper.SetDefault("tools.run_code.sandbox.allow_network", false)
+	viper.SetDefault("tools.run_code.sandbox.cpu_seconds", 0)
+	viper.SetDefault("tools.run_code.sandbox.memory_mb", 0)
+	viper.SetDefault("tools.run_code.sandbox.max_processes", 0)
 
 	viper.SetDefault("tools.write_file.enabled", true)
 	viper.SetDefault("tools.write_file.max_bytes", 512*1024)
 
+	viper.SetDefault("tools.edit_file.enabled", true)
+	viper.SetDefault("tools.edit_file.max_bytes", 1024*1024)
+	viper.SetDefault("tools.edit_file.deny_paths", []string{"config.yaml"})
+	viper.SetDefault("tools.edit_file.allowed_dirs", []string{})
+
 	viper.SetDefault("tools.bash.enabled", false)
 	viper.SetDefault("tools.bash.confirm", false)
 	viper.SetDefault("tools.bash.timeout", 30*time.Second)
 	viper.SetDefault("tools.bash.max_output_bytes", 256*1024)
 	viper.SetDefault("tools.bash.deny_paths", []string{"config.yaml"})
+	viper.SetDefault("tools.bash.session.enabled", false)
+	viper.SetDefault("tools.bash.session.idle_timeout", 10*time.Minute)
+	viper.SetDefault("tools.bash.session.max_sessions", 16)
+	viper.SetDefault("tools.bash.sandbox.enabled", false)
+	viper.SetDefault("tools.bash.sandbox.allow_network", false)
+	viper.SetDefault("tools.bash.sandbox.cpu_seconds", 0)
+	viper.SetDefault("tools.bash.sandbox.memory_mb", 0)
+	viper.SetDefault("tools.bash.sandbox.max_processes", 0)
 
 	viper.SetDefault("tools.url_fetch.enabled", true)
 	viper.SetDefault("tools.url_fetch.timeout", 30*time.Second)
 	viper.SetDefault("tools.url_fetch.max_bytes", int64(512*1024))
+	viper.SetDefault("tools.url_fetch.default_format", "markdown")
+	viper.SetDefault("tools.url_fetch.page_bytes", int64(64*1024))
+	viper.SetDefault("tools.url_fetch.max_download_bytes", int64(256*1024*1024))
+	viper.SetDefault("tools.url_fetch.download_timeout", 10*time.Minute)
+	viper.SetDefault("tools.crawl.enabled", true)
+	viper.SetDefault("tools.crawl.max_pages", 50)
+	viper.SetDefault("tools.crawl.max_depth", 3)
+	viper.SetDefault("tools.crawl.delay", 500*time.Millisecond)
+	viper.SetDefault("tools.crawl.timeout", 5*time.Minute)
 	viper.SetDefault("tools.web_search.enabled", true)
 	viper.SetDefault("tools.web_search.timeout", 20*time.Second)
 	viper.SetDefault("tools.web_search.max_results", 5)
-	viper.SetDefault("tools.web_search.base_url", "https://duckduckgo.com/html/")
+	viper.SetDefault("tools.web_search.provider", "duckduckgo")
+	viper.SetDefault("tools.web_search.base_url", "")
+	viper.SetDefault("tools.web_search.auth_profile", "")
+
+	viper.SetDefault("tools.send_email.enabled", false)
+	viper.SetDefault("tools.send_email.server", "")
+	viper.SetDefault("tools.send_email.from", "")
+	viper.SetDefault("tools.send_email.auth_profile", "")
+	viper.SetDefault("tools.send_email.allowed_recipients", []string{})
+	viper.SetDefault("tools.send_email.timeout", 30*time.Second)
+
+	viper.SetDefault("tools.send_webhook.enabled", false)
+	viper.SetDefault("tools.send_webhook.webhooks", map[string]any{})
+	viper.SetDefault("tools.send_webhook.allowed_url_prefixes", []string{})
+	viper.SetDefault("tools.send_webhook.timeout", 15*time.Second)
+
+	viper.SetDefault("tools.wasm.enabled", false)
 
 	userAgent := strings.TrimSpace(viper.GetString("user_agent"))
 
@@ -84,11 +161,61 @@ func registryFromViper() *tools.Registry {
 	resolver := &secrets.EnvResolver{Aliases: secretsAliases}
 	profileStore := secrets.NewProfileStore(authProfiles)
 
-	r.Register(builtin.NewReadFileToolWithOptions(
+	readFile := builtin.NewReadFileToolWithOptions(
 		int64(viper.GetInt("tools.read_file.max_bytes")),
 		viper.GetStringSlice("tools.read_file.deny_paths"),
 		viper.GetStringSlice("tools.read_file.allowed_dirs"),
-	))
+	)
+	readFile.MaxDocumentBytes = viper.GetInt64("tools.read_file.max_document_bytes")
+	r.Register(readFile)
+
+	// list_dir/glob/grep/git/sql_query/json_query/file_archive share read_file's path policy.
+	fsPolicy := builtin.FSPolicy{
+		DenyPaths:   viper.GetStringSlice("tools.read_file.deny_paths"),
+		AllowedDirs: viper.GetStringSlice("tools.read_file.allowed_dirs"),
+	}
+	if viper.GetBool("tools.list_dir.enabled") {
+		r.Register(builtin.NewListDirTool(fsPolicy, viper.GetInt("tools.list_dir.max_entries")))
+	}
+	if viper.GetBool("tools.glob.enabled") {
+		r.Register(builtin.NewGlobTool(fsPolicy, viper.GetInt("tools.glob.max_results")))
+	}
+	if viper.GetBool("tools.grep.enabled") {
+		r.Register(builtin.NewGrepTool(fsPolicy, viper.GetInt("tools.grep.max_results"), viper.GetInt64("tools.grep.max_file_bytes")))
+	}
+	if viper.GetBool("tools.git.enabled") {
+		r.Register(builtin.NewGitTool(
+			fsPolicy,
+			viper.GetDuration("tools.git.timeout"),
+			viper.GetInt("tools.git.max_output_bytes"),
+			viper.GetBool("tools.git.allow_write"),
+		))
+	}
+	if viper.GetBool("tools.sql_query.enabled") {
+		r.Register(builtin.NewSQLQueryTool(
+			viper.GetStringMapString("tools.sql_query.databases"),
+			fsPolicy,
+			viper.GetInt("tools.sql_query.max_rows"),
+			viper.GetDuration("tools.sql_query.timeout"),
+			viper.GetInt64("tools.sql_query.max_csv_bytes"),
+		))
+	}
+	if viper.GetBool("tools.json_query.enabled") {
+		r.Register(builtin.NewJSONQueryTool(
+			fsPolicy,
+			strings.TrimSpace(viper.GetString("file_cache_dir")),
+			viper.GetInt64("tools.json_query.max_input_bytes"),
+			viper.GetInt("tools.json_query.max_output_bytes"),
+		))
+	}
+	if viper.GetBool("tools.file_archive.enabled") {
+		r.Register(builtin.NewFileArchiveTool(
+			fsPolicy,
+			strings.TrimSpace(viper.GetString("file_cache_dir")),
+			viper.GetInt("tools.file_archive.max_entries"),
+			viper.GetInt64("tools.file_archive.max_total_bytes"),
+		))
+	}
 
 	r.Register(builtin.NewWriteFileTool(
 		viper.GetBool("tools.write_file.enabled"),
@@ -96,6 +223,21 @@ func registryFromViper() *tools.Registry {
 		strings.TrimSpace(viper.GetString("file_cache_dir")),
 	))
 
+	if viper.GetBool("tools.edit_file.enabled") {
+		allowedDirs := viper.GetStringSlice("tools.edit_file.allowed_dirs")
+		if len(allowedDirs) == 0 {
+			if dir := strings.TrimSpace(viper.GetString("file_cache_dir")); dir != "" {
+				allowedDirs = []string{dir}
+			}
+		}
+		r.Register(builtin.NewEditFileTool(
+			true,
+			viper.GetInt("tools.edit_file.max_bytes"),
+			viper.GetStringSlice("tools.edit_file.deny_paths"),
+			allowedDirs,
+		))
+	}
+
 	if viper.GetBool("tools.bash.enabled") {
 		bt := builtin.NewBashTool(
 			true,
@@ -104,6 +246,26 @@ func registryFromViper() *tools.Registry {
 			viper.GetInt("tools.bash.max_output_bytes"),
 		)
 		bt.DenyPaths = viper.GetStringSlice("tools.bash.deny_paths")
+		if viper.GetBool("tools.bash.session.enabled") {
+			bt.Sessions = builtin.NewBashSessions(
+				viper.GetDuration("tools.bash.session.idle_timeout"),
+				viper.GetInt("tools.bash.session.max_sessions"),
+			)
+		}
+		if viper.GetBool("tools.bash.sandbox.enabled") {
+			ws := strings.TrimSpace(viper.GetString("tools.bash.sandbox.workspace"))
+			if ws == "" {
+				ws = strings.TrimSpace(viper.GetString("file_cache_dir"))
+			}
+			bt.Sandbox = &builtin.BashSandbox{
+				Enabled:      true,
+				Workspace:    ws,
+				AllowNetwork: viper.GetBool("tools.bash.sandbox.allow_network"),
+				CPUSeconds:   viper.GetInt("tools.bash.sandbox.cpu_seconds"),
+				MemoryMB:     viper.GetInt("tools.bash.sandbox.memory_mb"),
+				MaxProcesses: viper.GetInt("tools.bash.sandbox.max_processes"),
+			}
+		}
 		if secretsEnabled {
 			// Safety default: allow bash for local automation, but deny curl to avoid "bash + curl" carrying auth.
 			bt.DenyTokens = append(bt.DenyTokens, "curl")
@@ -111,8 +273,29 @@ func registryFromViper() *tools.Registry {
 		r.Register(bt)
 	}
 
+	if viper.GetBool("tools.run_code.enabled") {
+		rc := builtin.NewRunCodeTool(
+			strings.TrimSpace(viper.GetString("file_cache_dir")),
+			viper.GetString("tools.run_code.python"),
+			viper.GetDuration("tools.run_code.timeout"),
+			viper.GetInt("tools.run_code.max_output_bytes"),
+		)
+		rc.AllowHostPython = viper.GetBool("tools.run_code.allow_host_python")
+		rc.JSMaxMemoryMB = viper.GetInt("tools.run_code.javascript_max_memory_mb")
+		if viper.GetBool("tools.run_code.sandbox.enabled") {
+			rc.Sandbox = &builtin.BashSandbox{
+				Enabled:      true,
+				AllowNetwork: viper.GetBool("tools.run_code.sandbox.allow_network"),
+				CPUSeconds:   viper.GetInt("tools.run_code.sandbox.cpu_seconds"),
+				MemoryMB:     viper.GetInt("tools.run_code.sandbox.memory_mb"),
+				MaxProcesses: viper.GetInt("tools.run_code.sandbox.max_processes"),
+			}
+		}
+		r.Register(rc)
+	}
+
 	if viper.GetBool("tools.url_fetch.enabled") {
-		r.Register(builtin.NewURLFetchToolWithAuth(
+		uf := builtin.NewURLFetchToolWithAuth(
 			true,
 			viper.GetDuration("tools.url_fetch.timeout"),
 			viper.GetInt64("tools.url_fetch.max_bytes"),
@@ -124,17 +307,90 @@ func registryFromViper() *tools.Registry {
 				Profiles:      profileStore,
 				Resolver:      resolver,
 			},
-		))
+		)
+		uf.DefaultFormat = viper.GetString("tools.url_fetch.default_format")
+		uf.PageBytes = viper.GetInt64("tools.url_fetch.page_bytes")
+		uf.MaxDownloadBytes = viper.GetInt64("tools.url_fetch.max_download_bytes")
+		uf.DownloadTimeout = viper.GetDuration("tools.url_fetch.download_timeout")
+		r.Register(uf)
+
+		// crawl reuses url_fetch's client, limits and guard network policy.
+		if viper.GetBool("tools.crawl.enabled") {
+			r.Register(builtin.NewCrawlTool(
+				uf,
+				viper.GetInt("tools.crawl.max_pages"),
+				viper.GetInt("tools.crawl.max_depth"),
+				viper.GetDuration("tools.crawl.delay"),
+				viper.GetDuration("tools.crawl.timeout"),
+			))
+		}
 	}
 
 	if viper.GetBool("tools.web_search.enabled") {
-		r.Register(builtin.NewWebSearchTool(
-			true,
+		var jsonCfg builtin.WebSearchJSONConfig
+		_ = viper.UnmarshalKey("tools.web_search.json", &jsonCfg)
+		backend, err := builtin.NewWebSearchBackend(
+			viper.GetString("tools.web_search.provider"),
 			viper.GetString("tools.web_search.base_url"),
-			viper.GetDuration("tools.web_search.timeout"),
-			viper.GetInt("tools.web_search.max_results"),
+			jsonCfg,
+		)
+		if err != nil {
+			slog.Default().Warn("web_search_config_invalid", "error", err.Error())
+		} else {
+			ws := builtin.NewWebSearchTool(
+				true,
+				viper.GetString("tools.web_search.base_url"),
+				viper.GetDuration("tools.web_search.timeout"),
+				viper.GetInt("tools.web_search.max_results"),
+				userAgent,
+			)
+			ws.Backend = backend
+			ws.AuthProfile = strings.TrimSpace(viper.GetString("tools.web_search.auth_profile"))
+			ws.Auth = &builtin.URLFetchAuth{
+				Enabled:       secretsEnabled,
+				AllowProfiles: allowProfiles,
+				Profiles:      profileStore,
+				Resolver:      resolver,
+			}
+			r.Register(ws)
+		}
+	}
+
+	if viper.GetBool("tools.send_email.enabled") {
+		se := builtin.NewSendEmailTool(
+			viper.GetString("tools.send_email.server"),
+			viper.GetString("tools.send_email.from"),
+			viper.GetStringSlice("tools.send_email.allowed_recipients"),
+			viper.GetDuration("tools.send_email.timeout"),
+		)
+		se.AuthProfile = strings.TrimSpace(viper.GetString("tools.send_email.auth_profile"))
+		se.Auth = &builtin.URLFetchAuth{
+			Enabled:       secretsEnabled,
+			AllowProfiles: allowProfiles,
+			Profiles:      profileStore,
+			Resolver:      resolver,
+		}
+		r.Register(se)
+	}
+
+	if viper.GetBool("tools.send_webhook.enabled") {
+		var webhooks map[string]builtin.WebhookTarget
+		if err := viper.UnmarshalKey("tools.send_webhook.webhooks", &webhooks); err != nil {
+			slog.Default().Warn("send_webhook_config_invalid", "error", err.Error())
+		}
+		sw := builtin.NewSendWebhookTool(
+			webhooks,
+			viper.GetStringSlice("tools.send_webhook.allowed_url_prefixes"),
+			viper.GetDuration("tools.send_webhook.timeout"),
 			userAgent,
-		))
+		)
+		sw.Auth = &builtin.URLFetchAuth{
+			Enabled:       secretsEnabled,
+			AllowProfiles: allowProfiles,
+			Profiles:      profileStore,
+			Resolver:      resolver,
+		}
+		r.Register(sw)
 	}
 
 	if viper.GetBool("scheduler.enabled") {
@@ -144,6 +400,10 @@ func registryFromViper() *tools.Registry {
 		r.Register(builtin.NewUnscheduleJobTool(viper.GetString("db.dsn")))
 	}
 
+	registerPluginTools(r)
+	registerWASMTools(r)
+	registerMCPTools(r)
+
 	return r
 }
 
@@ -74,7 +74,9 @@ func newRootCmd() *cobra.Command {
 	cmd.AddCommand(newSubmitCmd())
 	cmd.AddCommand(newTelegramCmd())
 	cmd.AddCommand(newToolsCmd())
+	cmd.AddCommand(newMCPServeCmd())
 	cmd.AddCommand(newSkillsCmd())
+	cmd.AddCommand(newGuardCmd())
 	cmd.AddCommand(newVersionCmd())
 
 	return cmd
@@ -89,6 +89,7 @@ func newServeCmd() *cobra.Command {
 				schedCfg.Enabled = true
 				schedCfg.Concurrency = viper.GetInt("scheduler.concurrency")
 				schedCfg.Tick = viper.GetDuration("scheduler.tick")
+				schedCfg.OnRunFinished = cronJobNotifier(reg)
 
 				runner := func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
 					final, runCtx, err := runOneTask(ctx, logger, logOpts, client, reg, baseCfg, sharedGuard, task, model, meta)
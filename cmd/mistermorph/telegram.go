@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -253,22 +254,14 @@ func newTelegramCmd() *cobra.Command {
 				schedCfg.Enabled = true
 				schedCfg.Concurrency = viper.GetInt("scheduler.concurrency")
 				schedCfg.Tick = viper.GetDuration("scheduler.tick")
+				notifyTargets := cronJobNotifier(reg)
 				schedCfg.OnRunFinished = func(ctx context.Context, job models.CronJob, run models.CronRun, status string, errStr *string, summary *string) error {
+					err := notifyTargets(ctx, job, run, status, errStr, summary)
 					if job.NotifyTelegramChatID == nil || *job.NotifyTelegramChatID == 0 {
-						return nil
-					}
-
-					var msg string
-					if status == scheduler.StatusSuccess && summary != nil && strings.TrimSpace(*summary) != "" {
-						msg = strings.TrimSpace(*summary)
-					} else {
-						details := ""
-						if errStr != nil && strings.TrimSpace(*errStr) != "" {
-							details = ": " + strings.TrimSpace(*errStr)
-						}
-						msg = fmt.Sprintf("cron job %s (%s) %s%s", strings.TrimSpace(job.Name), job.ID, status, details)
+						return err
 					}
-					return api.sendMessageChunked(ctx, *job.NotifyTelegramChatID, msg)
+					msg := cronResultMessage(job, status, errStr, summary)
+					return errors.Join(err, api.sendMessageChunked(ctx, *job.NotifyTelegramChatID, msg))
 				}
 
 				runner := func(ctx context.Context, task string, model string, meta map[string]any) (*string, error) {
@@ -814,6 +807,10 @@ var (
 	memoryResolver memory.IdentityResolver
 )
 
+// maxGuardedMemoryItems bounds how many private memory items are registered
+// with the guard as sensitive values per run.
+const maxGuardedMemoryItems = 500
+
 func initMemory(ctx context.Context) (memory.Store, memory.IdentityResolver, error) {
 	memoryOnce.Do(func() {
 		cfg := dbConfigFromViper()
@@ -873,6 +870,8 @@ func runTelegramTask(ctx context.Context, logger *slog.Logger, logOpts agent.Log
 		"If you need to send a Telegram voice message: call telegram_send_voice. If you do not already have a voice file path, do NOT ask the user for one; instead call telegram_send_voice without path and provide a short `text` to synthesize from the current context.",
 	)
 
+	runGuard := guardFromViper(logger)
+
 	if viper.GetBool("memory.enabled") && job.FromUserID > 0 {
 		reqCtx := memory.ContextPublic
 		if strings.ToLower(strings.TrimSpace(job.ChatType)) == "private" {
@@ -899,6 +898,19 @@ func runTelegramTask(ctx context.Context, logger *slog.Logger, logOpts agent.Log
 				reg.Register(mt)
 			}
 
+			if runGuard != nil && reqCtx == memory.ContextPrivate {
+				// Private items are visible in this chat; keep them out of outbound tool params.
+				items, err := memory.LoadSnapshot(ctx, store, id.SubjectID, reqCtx, maxGuardedMemoryItems)
+				if err != nil {
+					return nil, nil, loadedSkills, fmt.Errorf("memory snapshot: %w", err)
+				}
+				for _, it := range items {
+					if it.Visibility == memory.PrivateOnly {
+						runGuard.AddSensitiveValues("memory:"+it.Namespace+"/"+it.Key, it.Value)
+					}
+				}
+			}
+
 			if viper.GetBool("memory.injection.enabled") {
 				maxItems := viper.GetInt("memory.injection.max_items")
 				maxChars := viper.GetInt("memory.injection.max_chars")
@@ -925,7 +937,7 @@ func runTelegramTask(ctx context.Context, logger *slog.Logger, logOpts agent.Log
 		agent.WithLogger(logger),
 		agent.WithLogOptions(logOpts),
 		agent.WithSkillAuthProfiles(skillAuthProfiles, viper.GetBool("secrets.require_skill_profiles")),
-		agent.WithGuard(guardFromViper(logger)),
+		agent.WithGuard(runGuard),
 	)
 	meta := map[string]any{
 		"trigger":               "telegram",
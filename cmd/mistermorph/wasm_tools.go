@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/quailyquaily/mistermorph/tools"
+	"github.com/quailyquaily/mistermorph/tools/wasm"
+	"github.com/spf13/viper"
+)
+
+var (
+	wasmToolsOnce sync.Once
+	wasmTools     []*wasm.Tool
+)
+
+// registerWASMTools compiles modules configured under `tools.wasm.tools`
+// (once per process) and registers them. The guest filesystem root is file_cache_dir.
+func registerWASMTools(r *tools.Registry) {
+	if !viper.GetBool("tools.wasm.enabled") {
+		return
+	}
+	wasmToolsOnce.Do(func() {
+		var cfgs []wasm.ToolConfig
+		if err := viper.UnmarshalKey("tools.wasm.tools", &cfgs); err != nil {
+			slog.Default().Warn("wasm_config_invalid", "error", err.Error())
+			return
+		}
+		root := strings.TrimSpace(viper.GetString("file_cache_dir"))
+		for _, cfg := range cfgs {
+			t, err := wasm.Load(context.Background(), cfg, root)
+			if err != nil {
+				slog.Default().Warn("wasm_tool_load_error", "tool", cfg.Name, "error", err.Error())
+				continue
+			}
+			slog.Default().Info("wasm_tool_loaded", "tool", t.Name(), "module", cfg.Module)
+			wasmTools = append(wasmTools, t)
+		}
+	})
+	for _, t := range wasmTools {
+		if _, exists := r.Get(t.Name()); exists {
+			slog.Default().Warn("wasm_tool_name_conflict", "tool", t.Name())
+			continue
+		}
+		r.Register(t)
+	}
+}
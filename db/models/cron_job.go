@@ -21,8 +21,12 @@ type CronJob struct {
 	// If true, disable the job after its next scheduled enqueue (one-shot execution).
 	RunOnce bool `gorm:"not null;default:0"`
 
-	// Optional notification target (best-effort; depends on runtime wiring).
+	// Optional notification targets (best-effort; depend on runtime wiring).
 	NotifyTelegramChatID *int64 `gorm:"index"`
+	// Comma-separated recipients, delivered via the send_email tool's SMTP config.
+	NotifyEmail *string `gorm:"type:text"`
+	// Configured send_webhook name or allowlisted URL.
+	NotifyWebhook *string `gorm:"type:text"`
 
 	// Optional overrides (best-effort; depends on runtime wiring).
 	Provider *string `gorm:"type:text"`
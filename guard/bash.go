@@ -0,0 +1,452 @@
+package guard
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/quailyquaily/mistermorph/internal/shellparse"
+)
+
+// Program classes used by ClassifyBash. Anything not listed is "unclassified"
+// (medium risk): it still needs approval, but is not called out as dangerous.
+var (
+	// Programs here must not be able to write files, run commands or print the
+	// environment through any option; the few options that can (git --output,
+	// tar -I, sort -o, jq env, ag --pager ...) are checked in classifyProgram.
+	// Pagers (less, man), in-place editors (sed, yq -i), tools with an
+	// output-file argument (uniq, xxd -r, tree -o, iconv -o) and ps (which can
+	// print other processes' environments) are deliberately left out.
+	bashReadOnlyPrograms = programSet(
+		"ls", "cat", "head", "tail", "wc", "grep", "egrep", "fgrep", "ag",
+		"stat", "file", "du", "df", "pwd", "echo", "printf", "true", "false", "test", "[", "[[", ":",
+		"date", "cal", "whoami", "id", "groups", "uname", "hostname", "uptime", "free", "pgrep",
+		"which", "whereis", "type", "cd", "pushd", "popd", "dirs", "unset",
+		"read", "sleep", "exit", "return", "shift", "wait",
+		"cut", "tr", "diff", "cmp", "comm", "basename", "dirname", "realpath", "readlink",
+		"jq", "column", "nl", "tac", "rev", "fold", "fmt", "paste", "join", "expand", "unexpand",
+		"seq", "expr", "bc", "md5sum", "sha1sum", "sha256sum", "sha512sum", "cksum", "b2sum",
+		"od", "hexdump", "strings", "locale", "getconf", "nproc", "lscpu", "lsblk",
+		"tput", "help", "base64", "numfmt", "zcat", "zgrep",
+		// Wrappers: the command they run is classified on its own.
+		"nice", "ionice", "nohup", "time", "timeout", "xargs", "command", "builtin", "exec",
+		"stdbuf", "watch", "unbuffer", "setsid", "flock", "eval",
+	)
+	bashNetworkPrograms = programSet(
+		"curl", "wget", "ssh", "scp", "sftp", "rsync", "nc", "ncat", "netcat", "socat", "telnet", "ftp",
+		"dig", "nslookup", "host", "ping", "traceroute", "mtr", "aria2c", "http", "https", "xh",
+		"apt", "apt-get", "yum", "dnf", "apk", "brew", "pip", "pip3", "pipx", "uv", "npm", "npx",
+		"pnpm", "yarn", "gem", "cargo", "docker", "podman", "kubectl", "helm", "terraform",
+		"aws", "gcloud", "az", "gh", "gsutil",
+	)
+	bashDestructivePrograms = programSet(
+		"rm", "rmdir", "shred", "unlink", "dd", "truncate", "mv", "chmod", "chown", "chgrp", "chattr",
+		"kill", "killall", "pkill", "fdisk", "parted", "wipefs", "mkswap", "mount", "umount",
+		"systemctl", "service", "iptables", "nft", "ufw", "useradd", "userdel", "usermod", "passwd",
+	)
+	bashWritePrograms = programSet(
+		"cp", "mkdir", "touch", "tee", "ln", "install", "patch", "tar", "zip", "unzip", "gzip", "gunzip",
+		"bzip2", "xz", "7z", "split", "csplit", "crontab", "make",
+	)
+	bashExecPrograms = programSet(
+		"python", "python2", "python3", "node", "deno", "bun", "ruby", "perl", "php", "lua", "rscript",
+		"java", "go", "osascript", "pwsh", "powershell", "source", ".",
+	)
+	bashPrivilegedPrograms = programSet("sudo", "su", "doas", "chroot", "pkexec", "nsenter", "unshare")
+
+	gitReadOnly = programSet(
+		"status", "log", "diff", "show", "rev-parse", "ls-files", "ls-tree", "blame", "describe",
+		"grep", "shortlog", "cat-file", "rev-list", "whatchanged", "help", "version", "merge-base",
+	)
+	// bashSafeParams are variables whose expansion is not treated as reading
+	// the environment.
+	bashSafeParams = programSet(
+		"HOME", "PWD", "OLDPWD", "USER", "LOGNAME", "SHELL", "PATH", "TERM", "LANG", "LC_ALL", "LC_CTYPE",
+		"TZ", "TMPDIR", "HOSTNAME", "UID", "EUID", "PPID", "RANDOM", "LINENO", "SECONDS", "OSTYPE",
+		"BASH_VERSION", "IFS", "OPTARG", "OPTIND", "REPLY", "PIPESTATUS",
+	)
+
+	gitNetwork     = programSet("push", "pull", "fetch", "clone", "ls-remote", "submodule", "remote-https")
+	gitDestructive = programSet("reset", "clean", "rm", "restore", "filter-branch", "filter-repo", "gc", "prune")
+)
+
+func programSet(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+var riskRank = map[RiskLevel]int{RiskLow: 0, RiskMedium: 1, RiskHigh: 2, RiskCritical: 3}
+
+// ClassifyBash parses cmd and returns its overall risk with one reason per
+// finding (e.g. bash_network:curl, bash_destructive:rm, bash_writes:>out.txt).
+// A command is low risk only if every program it runs, including those behind
+// pipes, substitutions, wrappers and bash -c, is read-only and nothing is
+// redirected to a file. Programs matching cfg.DenyPrograms make it critical.
+func ClassifyBash(cmd string, cfg BashConfig) (RiskLevel, []string) {
+	risk := RiskLow
+	var reasons []string
+	add := func(r RiskLevel, reason string) {
+		if riskRank[r] > riskRank[risk] {
+			risk = r
+		}
+		for _, existing := range reasons {
+			if existing == reason {
+				return
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+
+	script, err := shellparse.Parse(cmd)
+	if err != nil {
+		add(RiskHigh, "bash_parse_error")
+		return risk, reasons
+	}
+	if script.Opaque {
+		add(RiskHigh, "bash_opaque")
+	}
+	extraReadOnly := make(map[string]bool)
+	for _, p := range cfg.ReadOnlyPrograms {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			extraReadOnly[p] = true
+		}
+	}
+
+	for _, c := range script.Commands {
+		if c.Dynamic {
+			add(RiskHigh, "bash_dynamic_command")
+			continue
+		}
+		// GIT_PAGER=, PAGER=, LESSOPEN=, LD_PRELOAD= ... can make any program run
+		// another command.
+		for _, name := range c.Assigns {
+			add(RiskHigh, "bash_env_assignment:"+name)
+		}
+		prog := c.Program()
+		if denied := matchProgram(prog, cfg.DenyPrograms); denied != "" {
+			add(RiskCritical, "bash_denied_program:"+prog)
+			continue
+		}
+		if extraReadOnly[prog] {
+			continue
+		}
+		r, reason := classifyProgram(prog, c.Args)
+		if reason != "" {
+			add(r, reason)
+		}
+	}
+	for _, r := range script.Redirects {
+		// bash opens a socket for /dev/tcp/host/port and /dev/udp/host/port.
+		if t := strings.TrimSpace(r.Target); strings.HasPrefix(t, "/dev/tcp/") || strings.HasPrefix(t, "/dev/udp/") {
+			add(RiskHigh, "bash_network:"+t)
+			continue
+		}
+		if r.Writes() {
+			add(RiskMedium, "bash_writes:"+r.Op+r.Target)
+		}
+	}
+	// Expanding an inherited variable ($OPENAI_API_KEY) prints the environment
+	// as surely as printenv does.
+	setVars := programSet(script.Vars...)
+	for _, p := range script.Params {
+		if !setVars[p] && !bashSafeParams[p] {
+			add(RiskMedium, "bash_reads_env:$"+p)
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "bash_read_only")
+	}
+	return risk, reasons
+}
+
+func matchProgram(prog string, globs []string) string {
+	for _, g := range globs {
+		g = strings.ToLower(strings.TrimSpace(g))
+		if g == "" {
+			continue
+		}
+		if ok, _ := path.Match(g, prog); ok {
+			return g
+		}
+	}
+	return ""
+}
+
+// classifyProgram returns ("", "") for read-only invocations.
+func classifyProgram(prog string, args []string) (RiskLevel, string) {
+	switch prog {
+	case "git":
+		return classifyGit(args)
+	case "sed":
+		for _, a := range args {
+			if a == "--in-place" || strings.HasPrefix(a, "--in-place=") || (strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.Contains(a, "i")) {
+				return RiskMedium, "bash_writes:sed -i"
+			}
+		}
+		// Scripts can run commands (e) and write files (w, W).
+		return RiskMedium, "bash_unclassified:sed"
+	case "rg":
+		if hasAnyFlag(args, "--pre") || hasFlagPrefix(args, "--pre=") {
+			return RiskHigh, "bash_exec:rg --pre"
+		}
+		return "", ""
+	case "env":
+		if envRunsCommand(args) {
+			return "", "" // the wrapped command is classified on its own
+		}
+		return RiskMedium, "bash_reads_env:env"
+	case "printenv":
+		return RiskMedium, "bash_reads_env:printenv"
+	case "export", "declare", "typeset", "local", "set":
+		return classifyShellVars(prog, args)
+	case "find":
+		for _, a := range args {
+			switch a {
+			case "-delete":
+				return RiskHigh, "bash_destructive:find -delete"
+			case "-fprint", "-fprint0", "-fprintf", "-fls":
+				return RiskMedium, "bash_writes:find " + a
+			}
+		}
+		return "", "" // -exec commands are classified separately
+	case "sort":
+		if sortWritesOutput(args) {
+			return RiskMedium, "bash_writes:sort -o"
+		}
+		return "", ""
+	case "ag":
+		if hasFlagPrefix(args, "--pager") {
+			return RiskHigh, "bash_exec:ag --pager"
+		}
+		return "", ""
+	case "jq":
+		for _, a := range args {
+			if a == "-f" || a == "--from-file" {
+				return RiskMedium, "bash_unclassified:jq " + a
+			}
+			if jqEnvRe.MatchString(a) {
+				return RiskMedium, "bash_reads_env:jq"
+			}
+		}
+		return "", ""
+	case "awk", "gawk", "mawk", "nawk":
+		for _, a := range args {
+			if strings.Contains(a, "system(") || strings.Contains(a, ">") || strings.Contains(a, "|") {
+				return RiskHigh, "bash_exec:" + prog
+			}
+		}
+		return "", ""
+	case "tar":
+		for _, a := range args {
+			for _, f := range tarExecFlags {
+				if a == f || (strings.HasPrefix(f, "--") && strings.HasPrefix(a, f+"=")) || (!strings.HasPrefix(f, "--") && strings.HasPrefix(a, f)) {
+					return RiskHigh, "bash_exec:tar " + f
+				}
+			}
+		}
+		if len(args) > 0 && (args[0] == "--list" || (!strings.HasPrefix(args[0], "--") && strings.Contains(args[0], "t") && !strings.ContainsAny(args[0], "cxru"))) {
+			return "", ""
+		}
+		return RiskMedium, "bash_writes:tar"
+	case "crontab":
+		if hasAnyFlag(args, "-l") {
+			return "", ""
+		}
+		if hasAnyFlag(args, "-r") {
+			return RiskHigh, "bash_destructive:crontab -r"
+		}
+		return RiskMedium, "bash_writes:crontab"
+	case "systemctl", "service":
+		if hasAnyFlag(args, "status", "is-active", "is-enabled", "list-units", "show") {
+			return "", ""
+		}
+		return RiskHigh, "bash_destructive:" + prog
+	case "bash", "sh", "zsh", "dash", "ksh":
+		// bash -c "..." is parsed and its commands are classified on their own.
+		for _, a := range args {
+			if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.HasSuffix(a, "c") {
+				return "", ""
+			}
+		}
+		return RiskHigh, "bash_exec:" + prog
+	}
+	switch {
+	case bashReadOnlyPrograms[prog]:
+		return "", ""
+	case bashPrivilegedPrograms[prog]:
+		return RiskHigh, "bash_privileged:" + prog
+	case bashDestructivePrograms[prog], strings.HasPrefix(prog, "mkfs"):
+		return RiskHigh, "bash_destructive:" + prog
+	case bashNetworkPrograms[prog]:
+		return RiskHigh, "bash_network:" + prog
+	case bashExecPrograms[prog]:
+		return RiskHigh, "bash_exec:" + prog
+	case bashWritePrograms[prog]:
+		return RiskMedium, "bash_writes:" + prog
+	}
+	return RiskMedium, "bash_unclassified:" + prog
+}
+
+// tarExecFlags make tar run a command (compression program, per-file command,
+// checkpoint or volume scripts, remote shell).
+var tarExecFlags = []string{
+	"-I", "-F", "--use-compress-program", "--to-command", "--checkpoint-action",
+	"--info-script", "--new-volume-script", "--rsh-command", "--rmt-command",
+}
+
+// jqEnvRe matches the jq builtins that read the environment.
+var jqEnvRe = regexp.MustCompile(`\$ENV\b|\benv\b`)
+
+// sortWritesOutput reports -o FILE, -oFILE, -ro FILE, --output[=FILE].
+func sortWritesOutput(args []string) bool {
+	for _, a := range args {
+		if a == "--output" || strings.HasPrefix(a, "--output=") {
+			return true
+		}
+		if !strings.HasPrefix(a, "-") || strings.HasPrefix(a, "--") {
+			continue
+		}
+		for _, c := range a[1:] {
+			if c == 'o' {
+				return true
+			}
+			if strings.ContainsRune("ktST", c) {
+				break // the rest is the option's value
+			}
+		}
+	}
+	return false
+}
+
+// gitExecFlag returns the first argument of a read-only git subcommand that
+// runs a command (pager, external diff, textconv) or writes a file.
+func gitExecFlag(sub string, args []string) (RiskLevel, string) {
+	for _, a := range args {
+		switch {
+		case a == "--ext-diff" || a == "--textconv":
+			return RiskHigh, "bash_exec:git " + sub + " " + a
+		case strings.HasPrefix(a, "--open-files-in-pager"), sub == "grep" && strings.HasPrefix(a, "-O"):
+			return RiskHigh, "bash_exec:git " + sub + " " + strings.SplitN(a, "=", 2)[0]
+		case a == "--output" || strings.HasPrefix(a, "--output="):
+			return RiskMedium, "bash_writes:git " + sub + " --output"
+		}
+	}
+	return "", ""
+}
+
+func classifyGit(args []string) (RiskLevel, string) {
+	sub := ""
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		// Config overrides can set core.pager, core.fsmonitor, aliases ... to
+		// arbitrary commands.
+		if a == "-c" || a == "--config-env" || strings.HasPrefix(a, "--config-env=") || strings.HasPrefix(a, "--exec-path") {
+			return RiskHigh, "bash_exec:git " + a
+		}
+		if a == "-C" || a == "--git-dir" || a == "--work-tree" || a == "--namespace" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		sub, args = a, args[i+1:]
+		break
+	}
+	switch {
+	case sub == "":
+		return "", ""
+	case gitReadOnly[sub]:
+		return gitExecFlag(sub, args)
+	case gitNetwork[sub]:
+		return RiskHigh, "bash_network:git " + sub
+	case gitDestructive[sub]:
+		return RiskHigh, "bash_destructive:git " + sub
+	case sub == "config":
+		if hasAnyFlag(args, "-l", "--list", "--get", "--get-all", "--get-regexp") {
+			return "", ""
+		}
+	case sub == "stash":
+		if len(args) > 0 && (args[0] == "list" || args[0] == "show") {
+			return "", ""
+		}
+	case sub == "branch" || sub == "tag" || sub == "remote":
+		// Listing forms are read-only; a name argument or any other flag is a write.
+		for _, a := range args {
+			switch a {
+			case "-l", "--list", "-v", "-vv", "-a", "-r", "--all", "--show-current", "-n":
+				continue
+			}
+			return RiskMedium, "bash_writes:git " + sub
+		}
+		return "", ""
+	}
+	return RiskMedium, "bash_writes:git " + sub
+}
+
+// envRunsCommand reports whether env's arguments name a command to run, as
+// opposed to printing the environment. -S strings are parsed separately.
+func envRunsCommand(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			return i+1 < len(args)
+		case a == "-u" || a == "--unset" || a == "-C" || a == "--chdir":
+			i++
+		case a == "-S" || a == "--split-string" || strings.HasPrefix(a, "-S") || strings.HasPrefix(a, "--split-string="):
+			return true
+		case strings.HasPrefix(a, "-"), strings.Contains(a, "="):
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// classifyShellVars handles builtins that set, export or print shell variables.
+func classifyShellVars(prog string, args []string) (RiskLevel, string) {
+	if prog == "export" {
+		return RiskHigh, "bash_env_assignment:export"
+	}
+	if len(args) == 0 || hasAnyFlag(args, "-p") {
+		return RiskMedium, "bash_reads_env:" + prog
+	}
+	// set -a / -o allexport exports every later assignment; declare -x exports.
+	exportFlag := "x"
+	if prog == "set" {
+		if hasAnyFlag(args, "allexport") {
+			return RiskHigh, "bash_env_assignment:set -o allexport"
+		}
+		exportFlag = "a"
+	}
+	for _, a := range args {
+		if (strings.HasPrefix(a, "-") || strings.HasPrefix(a, "+")) && a != "--" && strings.Contains(a, exportFlag) {
+			return RiskHigh, "bash_env_assignment:" + prog + " " + a
+		}
+	}
+	return "", ""
+}
+
+func hasAnyFlag(args []string, flags ...string) bool {
+	for _, a := range args {
+		for _, f := range flags {
+			if a == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasFlagPrefix(args []string, prefix string) bool {
+	for _, a := range args {
+		if strings.HasPrefix(a, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,15 @@ type Config struct {
 	Network   NetworkConfig
 	Redaction RedactionConfig
 	Bash      BashConfig
+	FileWrite FileWriteConfig
+	Git       GitConfig
+	RunCode   RunCodeConfig
+
+	// Rules are evaluated before the built-in policy; the first match decides.
+	Rules *RuleSet
+
+	Injection InjectionConfig
+	DLP       DLPConfig
 
 	Audit     AuditConfig
 	Approvals ApprovalsConfig
@@ -26,6 +35,9 @@ type URLFetchNetworkPolicy struct {
 type RedactionConfig struct {
 	Enabled  bool
 	Patterns []RegexPattern
+	// Detectors turns built-in detectors (see RedactionDetectors) on or off by
+	// name. Detectors not listed keep their default.
+	Detectors map[string]bool
 }
 
 type RegexPattern struct {
@@ -33,8 +45,55 @@ type RegexPattern struct {
 	Re   string
 }
 
+// BashConfig gates the bash tool. Commands are parsed and classified (see
+// ClassifyBash): read-only ones may skip approval, denied programs are blocked.
 type BashConfig struct {
 	RequireApproval bool
+	// AllowReadOnly allows commands classified as read-only without approval.
+	AllowReadOnly bool
+	// DenyPrograms are program name globs (e.g. "mkfs*") that are always denied.
+	DenyPrograms []string
+	// ReadOnlyPrograms adds programs to the built-in read-only list.
+	ReadOnlyPrograms []string
+}
+
+// FileWriteConfig gates tools that modify local files (write_file, edit_file).
+type FileWriteConfig struct {
+	RequireApproval bool
+}
+
+// GitConfig gates the git tool's mutating operations (commit, checkout).
+type GitConfig struct {
+	RequireApproval bool
+}
+
+// RunCodeConfig gates the run_code tool.
+type RunCodeConfig struct {
+	RequireApproval bool
+}
+
+// InjectionConfig scans tool output for prompt-injection signals (see ScanInjection).
+type InjectionConfig struct {
+	Enabled bool
+	// Threshold is the score at which output is wrapped as untrusted (default 3).
+	Threshold int
+	// EscalateApproval requires approval for calls to EscalateTools after
+	// flagged output in the same run, until an approval for the run is granted.
+	EscalateApproval bool
+	EscalateTools    []string
+}
+
+// DLPConfig checks outbound tool params for data that must not leave (see ScanEgress).
+type DLPConfig struct {
+	Enabled bool
+	// Action is deny (default) or require_approval. Canary tokens are always denied.
+	Action string
+	// Tools are tool name globs whose params are scanned.
+	Tools []string
+	// CanaryTokens are planted strings that should never appear in outbound calls.
+	CanaryTokens []string
+	// SecretPatterns also flags params matching the redaction patterns.
+	SecretPatterns bool
 }
 
 type AuditConfig struct {
@@ -0,0 +1,223 @@
+package guard
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sensitive values shorter than this are ignored: short strings match too
+// much unrelated text to be useful.
+const minSensitiveValueLen = 8
+
+// EgressFinding is one match found by ScanEgress.
+type EgressFinding struct {
+	Kind  string // canary, sensitive_value, secret_pattern
+	Label string // sensitive value label or pattern name; empty for canaries
+	Param string // dotted param path (e.g. headers.Authorization)
+}
+
+// Reason returns the audit reason for f. It never includes the matched value.
+func (f EgressFinding) Reason() string {
+	switch f.Kind {
+	case "canary":
+		return "egress_canary_token:" + f.Param
+	case "sensitive_value":
+		return "egress_sensitive_value:" + f.Label + "@" + f.Param
+	default:
+		return "egress_secret_pattern:" + f.Label + "@" + f.Param
+	}
+}
+
+// sensitiveValues is the set of known sensitive strings registered with
+// AddSensitiveValues, keyed by value.
+type sensitiveValues struct {
+	mu     sync.RWMutex
+	labels map[string]string
+}
+
+// AddSensitiveValues registers values (resolved credentials, private memory
+// items, ...) that must not leave through outbound tool params. label names
+// their origin in audit reasons (e.g. auth_profile:github). Values shorter
+// than 8 characters are ignored.
+func (g *Guard) AddSensitiveValues(label string, values ...string) {
+	if g == nil {
+		return
+	}
+	label = strings.TrimSpace(label)
+	if label == "" {
+		label = "value"
+	}
+	s := &g.sensitive
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minSensitiveValueLen {
+			continue
+		}
+		if s.labels == nil {
+			s.labels = make(map[string]string)
+		}
+		s.labels[v] = label
+	}
+}
+
+func (s *sensitiveValues) snapshot() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]string, len(s.labels))
+	for v, l := range s.labels {
+		out[v] = l
+	}
+	return out
+}
+
+// ScanEgress looks for canary tokens, known sensitive values and secret
+// patterns in every string inside params. Values are also matched after URL
+// decoding, and sensitive values in base64 or hex form.
+func (g *Guard) ScanEgress(params map[string]any) []EgressFinding {
+	if g == nil || len(params) == 0 {
+		return nil
+	}
+	cfg := g.cfg.DLP
+	values := g.sensitive.snapshot()
+
+	var out []EgressFinding
+	seen := make(map[string]bool)
+	add := func(f EgressFinding) {
+		if r := f.Reason(); !seen[r] {
+			seen[r] = true
+			out = append(out, f)
+		}
+	}
+	walkStringParams("", params, func(param, s string) {
+		hay := egressVariants(s)
+		for _, c := range cfg.CanaryTokens {
+			if c = strings.TrimSpace(c); c != "" && containsAny(hay, c, false) {
+				add(EgressFinding{Kind: "canary", Param: param})
+			}
+		}
+		for v, label := range values {
+			if containsAny(hay, v, true) || containsEncoded(s, v) {
+				add(EgressFinding{Kind: "sensitive_value", Label: label, Param: param})
+			}
+		}
+		if cfg.SecretPatterns && g.redactor != nil {
+			for _, h := range hay {
+				for _, name := range g.redactor.Detect(h) {
+					add(EgressFinding{Kind: "secret_pattern", Label: name, Param: param})
+				}
+			}
+		}
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Reason() < out[j].Reason() })
+	return out
+}
+
+// checkEgress denies (or, with DLP.Action require_approval, escalates)
+// outbound tool calls whose params carry sensitive data. Canary tokens are
+// always denied.
+func (g *Guard) checkEgress(a Action, res Result) Result {
+	cfg := g.cfg.DLP
+	if !cfg.Enabled || res.Decision == DecisionDeny || !toolMatchesAny(a.ToolName, cfg.Tools) {
+		return res
+	}
+	findings := g.ScanEgress(a.ToolParams)
+	if len(findings) == 0 {
+		return res
+	}
+	deny := !strings.EqualFold(strings.TrimSpace(cfg.Action), string(DecisionRequireApproval))
+	risk := RiskHigh
+	for _, f := range findings {
+		res.Reasons = append(res.Reasons, f.Reason())
+		if f.Kind == "canary" {
+			deny = true
+			risk = RiskCritical
+		}
+	}
+	if deny {
+		res.Decision = DecisionDeny
+	} else {
+		res.Decision = DecisionRequireApproval
+	}
+	if riskRank[res.RiskLevel] < riskRank[risk] {
+		res.RiskLevel = risk
+	}
+	return res
+}
+
+func walkStringParams(prefix string, v any, fn func(param, s string)) {
+	switch x := v.(type) {
+	case string:
+		fn(prefix, x)
+	case map[string]any:
+		for k, vv := range x {
+			walkStringParams(joinParamPath(prefix, k), vv, fn)
+		}
+	case map[string]string:
+		for k, vv := range x {
+			fn(joinParamPath(prefix, k), vv)
+		}
+	case []any:
+		for i, vv := range x {
+			walkStringParams(fmt.Sprintf("%s[%d]", prefix, i), vv, fn)
+		}
+	case []string:
+		for i, vv := range x {
+			fn(fmt.Sprintf("%s[%d]", prefix, i), vv)
+		}
+	}
+}
+
+func joinParamPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// egressVariants returns s plus its URL-decoded forms, so a value hidden in a
+// query string (%2F, +) is still found.
+func egressVariants(s string) []string {
+	out := []string{s}
+	if v, err := url.QueryUnescape(s); err == nil && v != s {
+		out = append(out, v)
+	}
+	if v, err := url.PathUnescape(s); err == nil && v != s && v != out[len(out)-1] {
+		out = append(out, v)
+	}
+	return out
+}
+
+func containsAny(hay []string, needle string, fold bool) bool {
+	if fold {
+		needle = strings.ToLower(needle)
+	}
+	for _, h := range hay {
+		if fold {
+			h = strings.ToLower(h)
+		}
+		if strings.Contains(h, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEncoded(s, v string) bool {
+	b := []byte(v)
+	for _, enc := range []string{
+		base64.RawStdEncoding.EncodeToString(b),
+		base64.RawURLEncoding.EncodeToString(b),
+	} {
+		if strings.Contains(s, enc) {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(s), hex.EncodeToString(b))
+}
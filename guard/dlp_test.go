@@ -0,0 +1,104 @@
+package guard
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newDLPGuard(action string) *Guard {
+	g := New(Config{
+		Enabled: true,
+		Network: NetworkConfig{URLFetch: URLFetchNetworkPolicy{AllowedURLPrefixes: []string{"https://"}}},
+		DLP: DLPConfig{
+			Enabled:        true,
+			Action:         action,
+			Tools:          []string{"url_fetch", "web_search", "send_*"},
+			CanaryTokens:   []string{"CANARY-7f3a9e"},
+			SecretPatterns: true,
+		},
+	}, nil, nil)
+	g.AddSensitiveValues("auth_profile:github", "ghp_exampleSecretValue123")
+	g.AddSensitiveValues("memory:profile/address", "12 Rue des Lilas, Lyon")
+	g.AddSensitiveValues("memory:profile/name", "Ann") // too short, ignored
+	return g
+}
+
+func TestEgress_Decisions(t *testing.T) {
+	g := newDLPGuard("")
+	b64 := base64.StdEncoding.EncodeToString([]byte("ghp_exampleSecretValue123"))
+
+	cases := []struct {
+		name     string
+		tool     string
+		params   map[string]any
+		decision Decision
+		risk     RiskLevel
+		reason   string
+	}{
+		{"clean fetch", "url_fetch", map[string]any{"url": "https://example.com/?q=weather"}, DecisionAllow, RiskLow, ""},
+		{"canary in query", "url_fetch", map[string]any{"url": "https://example.com/?x=" + url.QueryEscape("see CANARY-7f3a9e")}, DecisionDeny, RiskCritical, "egress_canary_token:url"},
+		{"secret in body", "url_fetch", map[string]any{"url": "https://example.com/", "method": "POST", "body": "k=ghp_exampleSecretValue123"}, DecisionDeny, RiskHigh, "egress_sensitive_value:auth_profile:github@body"},
+		{"secret base64 in header", "url_fetch", map[string]any{"url": "https://example.com/", "headers": map[string]any{"X-Data": b64}}, DecisionDeny, RiskHigh, "egress_sensitive_value:auth_profile:github@headers.X-Data"},
+		{"memory in search", "web_search", map[string]any{"q": "houses near 12 rue des lilas, lyon"}, DecisionDeny, RiskHigh, "egress_sensitive_value:memory:profile/address@q"},
+		{"short value ignored", "web_search", map[string]any{"q": "Ann"}, DecisionAllow, RiskLow, ""},
+		{"secret pattern", "send_webhook", map[string]any{"payload": map[string]any{"text": "Authorization: Bearer abcdefghijklmnop"}}, DecisionDeny, RiskHigh, "egress_secret_pattern:bearer_line@payload.text"},
+		{"tool not scanned", "write_file", map[string]any{"content": "CANARY-7f3a9e"}, DecisionAllow, RiskLow, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, _ := g.Evaluate(context.Background(), Meta{RunID: "r"}, Action{Type: ActionToolCallPre, ToolName: tc.tool, ToolParams: tc.params})
+			if res.Decision != tc.decision || res.RiskLevel != tc.risk {
+				t.Fatalf("got %s/%s (%v), want %s/%s", res.Decision, res.RiskLevel, res.Reasons, tc.decision, tc.risk)
+			}
+			if tc.reason != "" && !containsReason(res.Reasons, tc.reason) {
+				t.Fatalf("reasons %v missing %q", res.Reasons, tc.reason)
+			}
+			for _, r := range res.Reasons {
+				if strings.Contains(r, "ghp_") || strings.Contains(r, "CANARY") {
+					t.Fatalf("reason leaks the value: %q", r)
+				}
+			}
+		})
+	}
+}
+
+func TestEgress_RequireApprovalAction(t *testing.T) {
+	g := newDLPGuard("require_approval")
+
+	res, _ := g.Evaluate(context.Background(), Meta{}, Action{Type: ActionToolCallPre, ToolName: "web_search", ToolParams: map[string]any{"q": "ghp_exampleSecretValue123"}})
+	if res.Decision != DecisionRequireApproval || res.RiskLevel != RiskHigh {
+		t.Fatalf("got %s/%s (%v)", res.Decision, res.RiskLevel, res.Reasons)
+	}
+
+	// Canary tokens are denied regardless of the configured action.
+	res, _ = g.Evaluate(context.Background(), Meta{}, Action{Type: ActionToolCallPre, ToolName: "web_search", ToolParams: map[string]any{"q": "CANARY-7f3a9e"}})
+	if res.Decision != DecisionDeny || res.RiskLevel != RiskCritical {
+		t.Fatalf("got %s/%s (%v)", res.Decision, res.RiskLevel, res.Reasons)
+	}
+}
+
+func TestEgress_RulesCannotSkip(t *testing.T) {
+	rs, err := ParseRules([]byte("rules: [{name: allow-search, tools: [web_search], decision: allow}]"))
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	g := newDLPGuard("")
+	g.cfg.Rules = rs
+
+	res, _ := g.Evaluate(context.Background(), Meta{}, Action{Type: ActionToolCallPre, ToolName: "web_search", ToolParams: map[string]any{"q": "CANARY-7f3a9e"}})
+	if res.Decision != DecisionDeny {
+		t.Fatalf("expected deny, got %s (%v)", res.Decision, res.Reasons)
+	}
+}
+
+func containsReason(reasons []string, want string) bool {
+	for _, r := range reasons {
+		if r == want {
+			return true
+		}
+	}
+	return false
+}
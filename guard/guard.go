@@ -14,6 +14,8 @@ type Guard struct {
 	audit      AuditSink
 	approvals  ApprovalStore
 	lookupHost func(string) ([]string, error) // nil => net.LookupHost
+	taint      injectionTaint
+	sensitive  sensitiveValues
 }
 
 func New(cfg Config, audit AuditSink, approvals ApprovalStore) *Guard {
@@ -62,15 +64,31 @@ func (g *Guard) Evaluate(ctx context.Context, meta Meta, a Action) (Result, erro
 
 	res := Result{RiskLevel: RiskLow, Decision: DecisionAllow}
 
+	if r, ok := g.cfg.Rules.Match(meta, a, g.redactor); ok {
+		res = r
+		if a.Type == ActionToolCallPost || a.Type == ActionOutputPublish {
+			res = g.redactAfterRule(a, res)
+		}
+	} else {
+		switch a.Type {
+		case ActionToolCallPre:
+			res = g.evalToolCallPre(ctx, a)
+		case ActionToolCallPost:
+			res = g.evalToolCallPost(a)
+		case ActionOutputPublish:
+			res = g.evalOutputPublish(a)
+		default:
+			res = Result{RiskLevel: RiskLow, Decision: DecisionAllow}
+		}
+	}
+
+	// Egress and injection checks run after rules so a rule cannot skip them.
 	switch a.Type {
 	case ActionToolCallPre:
-		res = g.evalToolCallPre(ctx, a)
+		res = g.checkEgress(a, res)
+		res = g.escalateAfterInjection(meta, a, res)
 	case ActionToolCallPost:
-		res = g.evalToolCallPost(a)
-	case ActionOutputPublish:
-		res = g.evalOutputPublish(a)
-	default:
-		res = Result{RiskLevel: RiskLow, Decision: DecisionAllow}
+		res = g.checkInjection(meta, a, res)
 	}
 
 	g.emitAudit(ctx, meta, a, res, "", "", "")
@@ -136,6 +154,10 @@ func (g *Guard) ResolveApproval(ctx context.Context, id string, status ApprovalS
 	// Emit a follow-up audit event for the resolution (safe/redacted by construction).
 	if rec, ok, err := g.approvals.Get(ctx, id); err == nil && ok {
 		g.emitApprovalResolutionAudit(ctx, rec)
+		// A human has reviewed the run since the flagged output.
+		if rec.Status == ApprovalApproved {
+			g.taint.clear(rec.RunID)
+		}
 	}
 	return nil
 }
@@ -154,15 +176,63 @@ func (g *Guard) evalToolCallPre(_ context.Context, a Action) Result {
 	name := strings.TrimSpace(strings.ToLower(a.ToolName))
 	switch name {
 	case "bash":
+		cmd, _ := a.ToolParams["cmd"].(string)
+		risk, reasons := ClassifyBash(cmd, g.cfg.Bash)
+		if risk == RiskCritical {
+			return Result{RiskLevel: risk, Decision: DecisionDeny, Reasons: reasons}
+		}
+		if risk == RiskLow && g.cfg.Bash.AllowReadOnly {
+			return Result{RiskLevel: risk, Decision: DecisionAllow, Reasons: reasons}
+		}
 		if g.cfg.Bash.RequireApproval {
+			return Result{
+				RiskLevel: risk,
+				Decision:  DecisionRequireApproval,
+				Reasons:   append([]string{"bash_requires_approval"}, reasons...),
+			}
+		}
+		return Result{RiskLevel: risk, Decision: DecisionAllow, Reasons: reasons}
+	case "write_file", "edit_file":
+		if g.cfg.FileWrite.RequireApproval {
+			// Only edit_file implements dry_run; write_file would ignore it.
+			if dry, _ := a.ToolParams["dry_run"].(bool); dry && name == "edit_file" {
+				return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
+			}
 			return Result{
 				RiskLevel: RiskHigh,
 				Decision:  DecisionRequireApproval,
-				Reasons:   []string{"bash_requires_approval"},
+				Reasons:   []string{"file_write_requires_approval"},
 			}
 		}
 		return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
-	case "url_fetch":
+	case "git":
+		op, _ := a.ToolParams["op"].(string)
+		switch strings.ToLower(strings.TrimSpace(op)) {
+		case "commit", "checkout":
+			if g.cfg.Git.RequireApproval {
+				return Result{
+					RiskLevel: RiskHigh,
+					Decision:  DecisionRequireApproval,
+					Reasons:   []string{"git_write_requires_approval"},
+				}
+			}
+			return Result{RiskLevel: RiskMedium, Decision: DecisionAllow}
+		}
+		return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
+	case "run_code":
+		if g.cfg.RunCode.RequireApproval {
+			return Result{
+				RiskLevel: RiskHigh,
+				Decision:  DecisionRequireApproval,
+				Reasons:   []string{"run_code_requires_approval"},
+			}
+		}
+		return Result{RiskLevel: RiskMedium, Decision: DecisionAllow}
+	case "send_email", "send_webhook":
+		// Destinations are allowlisted by the tools themselves.
+		return Result{RiskLevel: RiskMedium, Decision: DecisionAllow}
+	case "url_fetch", "crawl":
+		// crawl shares url_fetch's network policy but has no auth_profile exemption.
 		rawURL := ""
 		if a.ToolParams != nil {
 			if v, ok := a.ToolParams["url"].(string); ok {
@@ -174,7 +244,7 @@ func (g *Guard) evalToolCallPre(_ context.Context, a Action) Result {
 		}
 		// If the call uses auth_profile, the auth_profile's allow policy is the primary destination boundary.
 		// Guard still audits, but does not add an extra allowlist layer by default.
-		if a.ToolParams != nil {
+		if a.ToolParams != nil && name == "url_fetch" {
 			if v, ok := a.ToolParams["auth_profile"].(string); ok && strings.TrimSpace(v) != "" {
 				return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
 			}
@@ -216,8 +286,8 @@ func (g *Guard) evalToolCallPost(a Action) Result {
 	if g.redactor == nil {
 		return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
 	}
-	red, changed := g.redactor.RedactString(obs)
-	if !changed {
+	red, counts := g.redactor.Redact(obs)
+	if len(counts) == 0 {
 		return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
 	}
 	return Result{
@@ -225,6 +295,7 @@ func (g *Guard) evalToolCallPost(a Action) Result {
 		Decision:        DecisionAllowWithRedact,
 		Reasons:         []string{"sensitive_content_redacted"},
 		RedactedContent: red,
+		Redactions:      counts,
 	}
 }
 
@@ -233,8 +304,8 @@ func (g *Guard) evalOutputPublish(a Action) Result {
 	if strings.TrimSpace(out) == "" || g.redactor == nil {
 		return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
 	}
-	red, changed := g.redactor.RedactString(out)
-	if !changed {
+	red, counts := g.redactor.Redact(out)
+	if len(counts) == 0 {
 		return Result{RiskLevel: RiskLow, Decision: DecisionAllow}
 	}
 	return Result{
@@ -242,9 +313,40 @@ func (g *Guard) evalOutputPublish(a Action) Result {
 		Decision:        DecisionAllowWithRedact,
 		Reasons:         []string{"sensitive_content_redacted"},
 		RedactedContent: red,
+		Redactions:      counts,
 	}
 }
 
+// redactAfterRule applies built-in redaction on top of a post-call or publish
+// rule, so an allow rule (or a redact rule with its own patterns) cannot turn
+// off secret redaction.
+func (g *Guard) redactAfterRule(a Action, res Result) Result {
+	if res.Decision == DecisionDeny || g.redactor == nil {
+		return res
+	}
+	content := a.Content
+	if res.Decision == DecisionAllowWithRedact && res.RedactedContent != "" {
+		content = res.RedactedContent
+	}
+	red, counts := g.redactor.Redact(content)
+	if len(counts) == 0 {
+		return res
+	}
+	res.Decision = DecisionAllowWithRedact
+	res.RedactedContent = red
+	res.Reasons = append(res.Reasons, "sensitive_content_redacted")
+	if res.Redactions == nil {
+		res.Redactions = make(map[string]int, len(counts))
+	}
+	for k, n := range counts {
+		res.Redactions[k] += n
+	}
+	if riskRank[res.RiskLevel] < riskRank[RiskHigh] {
+		res.RiskLevel = RiskHigh
+	}
+	return res
+}
+
 func (g *Guard) emitAudit(ctx context.Context, meta Meta, a Action, res Result, approvalID string, approvalStatus string, actor string) {
 	if g == nil || g.audit == nil || !g.cfg.Enabled {
 		return
@@ -267,6 +369,7 @@ func (g *Guard) emitAudit(ctx context.Context, meta Meta, a Action, res Result,
 		RiskLevel:             res.RiskLevel,
 		Decision:              res.Decision,
 		Reasons:               append([]string{}, res.Reasons...),
+		Redactions:            res.Redactions,
 		ApprovalRequestID:     approvalID,
 		ApprovalStatus:        approvalStatus,
 		Actor:                 actor,
@@ -304,7 +407,7 @@ func summarizeActionRedacted(a Action) string {
 		if strings.TrimSpace(a.ToolName) == "" {
 			return string(a.Type)
 		}
-		if strings.EqualFold(a.ToolName, "url_fetch") {
+		if strings.EqualFold(a.ToolName, "url_fetch") || strings.EqualFold(a.ToolName, "crawl") {
 			raw := ""
 			if a.ToolParams != nil {
 				if v, ok := a.ToolParams["url"].(string); ok {
@@ -314,7 +417,22 @@ func summarizeActionRedacted(a Action) string {
 			if raw == "" {
 				raw = strings.TrimSpace(a.URL)
 			}
-			return string(a.Type) + " tool=url_fetch url=" + redactURLQuery(raw)
+			return string(a.Type) + " tool=" + strings.ToLower(a.ToolName) + " url=" + redactURLQuery(raw)
+		}
+		if strings.EqualFold(a.ToolName, "write_file") || strings.EqualFold(a.ToolName, "edit_file") {
+			if p, _ := a.ToolParams["path"].(string); strings.TrimSpace(p) != "" {
+				return string(a.Type) + " tool=" + strings.TrimSpace(a.ToolName) + " path=" + strings.TrimSpace(p)
+			}
+		}
+		if strings.EqualFold(a.ToolName, "git") {
+			if op, _ := a.ToolParams["op"].(string); strings.TrimSpace(op) != "" {
+				return string(a.Type) + " tool=git op=" + strings.TrimSpace(op)
+			}
+		}
+		if strings.EqualFold(a.ToolName, "run_code") {
+			if lang, _ := a.ToolParams["language"].(string); strings.TrimSpace(lang) != "" {
+				return string(a.Type) + " tool=run_code language=" + strings.TrimSpace(lang)
+			}
 		}
 		return string(a.Type) + " tool=" + strings.TrimSpace(a.ToolName)
 	case ActionOutputPublish:
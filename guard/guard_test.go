@@ -257,3 +257,99 @@ func TestURLAllowedByPrefixes(t *testing.T) {
 		})
 	}
 }
+
+func TestGuard_FileWriteRequiresApproval(t *testing.T) {
+	g := New(Config{Enabled: true, FileWrite: FileWriteConfig{RequireApproval: true}}, nil, nil)
+	ctx := context.Background()
+	meta := Meta{RunID: "test"}
+
+	res, _ := g.Evaluate(ctx, meta, Action{Type: ActionToolCallPre, ToolName: "edit_file", ToolParams: map[string]any{"path": "a.txt"}})
+	if res.Decision != DecisionRequireApproval {
+		t.Fatalf("expected approval for edit_file, got %s", res.Decision)
+	}
+	res, _ = g.Evaluate(ctx, meta, Action{Type: ActionToolCallPre, ToolName: "edit_file", ToolParams: map[string]any{"path": "a.txt", "dry_run": true}})
+	if res.Decision != DecisionAllow {
+		t.Fatalf("expected dry_run to be allowed, got %s", res.Decision)
+	}
+	res, _ = g.Evaluate(ctx, meta, Action{Type: ActionToolCallPre, ToolName: "write_file", ToolParams: map[string]any{"path": "a.txt", "dry_run": true}})
+	if res.Decision != DecisionRequireApproval {
+		t.Fatalf("expected approval for write_file with dry_run, got %s", res.Decision)
+	}
+	if got := summarizeActionRedacted(Action{Type: ActionToolCallPre, ToolName: "write_file", ToolParams: map[string]any{"path": "out.md"}}); got != "ToolCallPre tool=write_file path=out.md" {
+		t.Fatalf("summary=%q", got)
+	}
+
+	g = New(Config{Enabled: true}, nil, nil)
+	res, _ = g.Evaluate(ctx, meta, Action{Type: ActionToolCallPre, ToolName: "write_file", ToolParams: map[string]any{"path": "a.txt"}})
+	if res.Decision != DecisionAllow {
+		t.Fatalf("expected allow by default, got %s", res.Decision)
+	}
+}
+
+func TestGuard_GitWriteRequiresApproval(t *testing.T) {
+	g := New(Config{Enabled: true, Git: GitConfig{RequireApproval: true}}, nil, nil)
+	ctx := context.Background()
+	meta := Meta{RunID: "test"}
+
+	for _, op := range []string{"commit", "checkout"} {
+		res, _ := g.Evaluate(ctx, meta, Action{Type: ActionToolCallPre, ToolName: "git", ToolParams: map[string]any{"op": op}})
+		if res.Decision != DecisionRequireApproval {
+			t.Fatalf("expected approval for git %s, got %s", op, res.Decision)
+		}
+	}
+	res, _ := g.Evaluate(ctx, meta, Action{Type: ActionToolCallPre, ToolName: "git", ToolParams: map[string]any{"op": "diff"}})
+	if res.Decision != DecisionAllow {
+		t.Fatalf("expected read ops to be allowed, got %s", res.Decision)
+	}
+	if got := summarizeActionRedacted(Action{Type: ActionToolCallPre, ToolName: "git", ToolParams: map[string]any{"op": "commit"}}); got != "ToolCallPre tool=git op=commit" {
+		t.Fatalf("summary=%q", got)
+	}
+}
+
+func TestGuard_RunCodeApproval(t *testing.T) {
+	ctx := context.Background()
+	meta := Meta{RunID: "test"}
+	a := Action{Type: ActionToolCallPre, ToolName: "run_code", ToolParams: map[string]any{"language": "python"}}
+
+	res, _ := New(Config{Enabled: true}, nil, nil).Evaluate(ctx, meta, a)
+	if res.Decision != DecisionAllow || res.RiskLevel != RiskMedium {
+		t.Fatalf("expected medium-risk allow, got %s/%s", res.RiskLevel, res.Decision)
+	}
+	res, _ = New(Config{Enabled: true, RunCode: RunCodeConfig{RequireApproval: true}}, nil, nil).Evaluate(ctx, meta, a)
+	if res.Decision != DecisionRequireApproval {
+		t.Fatalf("expected approval, got %s", res.Decision)
+	}
+	if got := summarizeActionRedacted(a); got != "ToolCallPre tool=run_code language=python" {
+		t.Fatalf("summary=%q", got)
+	}
+}
+
+func TestGuard_CrawlUsesURLFetchAllowlist(t *testing.T) {
+	g := New(Config{
+		Enabled: true,
+		Network: NetworkConfig{
+			URLFetch: URLFetchNetworkPolicy{AllowedURLPrefixes: []string{"https://docs.example.com/"}},
+		},
+	}, nil, nil)
+	ctx := context.Background()
+	meta := Meta{RunID: "test"}
+
+	cases := []struct {
+		params map[string]any
+		want   Decision
+	}{
+		{map[string]any{"url": "https://docs.example.com/guide/"}, DecisionAllow},
+		{map[string]any{"url": "https://evil.example.net/"}, DecisionDeny},
+		// auth_profile does not exempt crawl from the allowlist.
+		{map[string]any{"url": "https://evil.example.net/", "auth_profile": "x"}, DecisionDeny},
+	}
+	for _, c := range cases {
+		res, err := g.Evaluate(ctx, meta, Action{Type: ActionToolCallPre, ToolName: "crawl", ToolParams: c.params})
+		if err != nil {
+			t.Fatalf("Evaluate error: %v", err)
+		}
+		if res.Decision != c.want {
+			t.Fatalf("%v: got %s (reasons=%v), want %s", c.params, res.Decision, res.Reasons, c.want)
+		}
+	}
+}
@@ -0,0 +1,557 @@
+package guard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleDecisionRedact is the rules-file spelling of DecisionAllowWithRedact.
+const RuleDecisionRedact = "redact"
+
+// RulesFile is the on-disk policy format (guard.rules_file).
+type RulesFile struct {
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule is one declarative policy entry. Every populated matcher must match;
+// the first matching rule decides and built-in policy is skipped.
+type Rule struct {
+	Name string `yaml:"name" json:"name"`
+
+	// Actions limits the rule to action types: tool_call_pre (default),
+	// tool_call_post, output_publish.
+	Actions []string `yaml:"actions,omitempty" json:"actions,omitempty"`
+	// Tools are tool name globs (path.Match syntax, case-insensitive).
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	// Triggers match Meta.Trigger (e.g. telegram, cron, mcp).
+	Triggers []string    `yaml:"triggers,omitempty" json:"triggers,omitempty"`
+	When     *TimeWindow `yaml:"when,omitempty" json:"when,omitempty"`
+	// Params match tool params by key; nested maps use dotted keys (headers.Authorization).
+	Params  map[string]ParamMatcher `yaml:"params,omitempty" json:"params,omitempty"`
+	Content *ParamMatcher           `yaml:"content,omitempty" json:"content,omitempty"`
+
+	Decision string    `yaml:"decision" json:"decision"`
+	Risk     RiskLevel `yaml:"risk,omitempty" json:"risk,omitempty"`
+	Reason   string    `yaml:"reason,omitempty" json:"reason,omitempty"`
+	// Redact lists regexes replaced with [redacted] for decision=redact.
+	// When empty, the guard's redactor is used.
+	Redact []string `yaml:"redact,omitempty" json:"redact,omitempty"`
+}
+
+// TimeWindow matches Meta.Time. Hours may wrap midnight ("22:00-06:00").
+type TimeWindow struct {
+	Days     []string `yaml:"days,omitempty" json:"days,omitempty"` // mon..sun
+	Hours    string   `yaml:"hours,omitempty" json:"hours,omitempty"`
+	Timezone string   `yaml:"timezone,omitempty" json:"timezone,omitempty"` // IANA name; default UTC
+}
+
+// ParamMatcher matches one value. Lists match when any element matches.
+type ParamMatcher struct {
+	Equals    any      `yaml:"equals,omitempty" json:"equals,omitempty"`
+	OneOf     []any    `yaml:"one_of,omitempty" json:"one_of,omitempty"`
+	Regex     string   `yaml:"regex,omitempty" json:"regex,omitempty"`
+	Glob      string   `yaml:"glob,omitempty" json:"glob,omitempty"`
+	Contains  string   `yaml:"contains,omitempty" json:"contains,omitempty"`
+	URLPrefix []string `yaml:"url_prefix,omitempty" json:"url_prefix,omitempty"`
+	URLHost   []string `yaml:"url_host,omitempty" json:"url_host,omitempty"` // host globs: *.example.com
+	Exists    *bool    `yaml:"exists,omitempty" json:"exists,omitempty"`
+	Not       bool     `yaml:"not,omitempty" json:"not,omitempty"`
+}
+
+// RuleSet is a validated, compiled list of rules.
+type RuleSet struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	Rule
+	actions  map[ActionType]bool
+	decision Decision
+	risk     RiskLevel
+	params   map[string]compiledMatcher
+	content  *compiledMatcher
+	window   *compiledWindow
+	redact   []*regexp.Regexp
+}
+
+type compiledMatcher struct {
+	ParamMatcher
+	re *regexp.Regexp
+}
+
+type compiledWindow struct {
+	days     map[time.Weekday]bool
+	from, to int // minutes since midnight; from == to means all day
+	loc      *time.Location
+}
+
+var ruleActionTypes = map[string]ActionType{
+	"tool_call_pre":  ActionToolCallPre,
+	"tool_call_post": ActionToolCallPost,
+	"output_publish": ActionOutputPublish,
+}
+
+var ruleWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// LoadRulesFile reads and compiles a YAML (or JSON) rules file.
+func LoadRulesFile(p string) (*RuleSet, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(b)
+}
+
+// ParseRules compiles a rules document.
+func ParseRules(data []byte) (*RuleSet, error) {
+	var f RulesFile
+	// Unknown keys are errors: a typo like "tool:" would otherwise widen a rule to every tool.
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+	return CompileRules(f.Rules)
+}
+
+// CompileRules validates rules and compiles their regexes and time windows.
+func CompileRules(rules []Rule) (*RuleSet, error) {
+	rs := &RuleSet{}
+	for i, r := range rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			name := strings.TrimSpace(r.Name)
+			if name == "" {
+				name = "#" + strconv.Itoa(i+1)
+			}
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		rs.rules = append(rs.rules, cr)
+	}
+	return rs, nil
+}
+
+// Len returns the number of rules.
+func (rs *RuleSet) Len() int {
+	if rs == nil {
+		return 0
+	}
+	return len(rs.rules)
+}
+
+func compileRule(r Rule) (compiledRule, error) {
+	cr := compiledRule{Rule: r, actions: make(map[ActionType]bool), params: make(map[string]compiledMatcher)}
+	if strings.TrimSpace(r.Name) == "" {
+		return cr, fmt.Errorf("missing name")
+	}
+	if len(r.Actions) == 0 {
+		cr.actions[ActionToolCallPre] = true
+	}
+	for _, a := range r.Actions {
+		t, ok := ruleActionTypes[strings.ToLower(strings.TrimSpace(a))]
+		if !ok {
+			return cr, fmt.Errorf("unknown action %q (expected tool_call_pre, tool_call_post or output_publish)", a)
+		}
+		cr.actions[t] = true
+	}
+	for _, g := range r.Tools {
+		if _, err := path.Match(strings.ToLower(g), ""); err != nil {
+			return cr, fmt.Errorf("invalid tools glob %q", g)
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(r.Decision)) {
+	case string(DecisionAllow):
+		cr.decision = DecisionAllow
+	case string(DecisionDeny):
+		cr.decision = DecisionDeny
+	case string(DecisionRequireApproval):
+		cr.decision = DecisionRequireApproval
+	case RuleDecisionRedact, string(DecisionAllowWithRedact):
+		cr.decision = DecisionAllowWithRedact
+	case "":
+		return cr, fmt.Errorf("missing decision")
+	default:
+		return cr, fmt.Errorf("unknown decision %q (expected allow, deny, require_approval or redact)", r.Decision)
+	}
+	// Post-call and publish happen after the fact: nobody can approve them, and
+	// publish can only be rewritten, never blocked.
+	if cr.decision == DecisionRequireApproval && (cr.actions[ActionToolCallPost] || cr.actions[ActionOutputPublish]) {
+		return cr, fmt.Errorf("require_approval only applies to tool_call_pre")
+	}
+	if cr.decision == DecisionAllowWithRedact && cr.actions[ActionToolCallPre] {
+		return cr, fmt.Errorf("redact only applies to tool_call_post and output_publish")
+	}
+	if cr.decision == DecisionDeny && cr.actions[ActionOutputPublish] {
+		return cr, fmt.Errorf("deny does not apply to output_publish (use redact)")
+	}
+
+	switch RiskLevel(strings.ToLower(strings.TrimSpace(string(r.Risk)))) {
+	case "":
+		cr.risk = defaultRuleRisk(cr.decision)
+	case RiskLow, RiskMedium, RiskHigh, RiskCritical:
+		cr.risk = RiskLevel(strings.ToLower(strings.TrimSpace(string(r.Risk))))
+	default:
+		return cr, fmt.Errorf("unknown risk %q (expected low, medium, high or critical)", r.Risk)
+	}
+
+	if r.When != nil {
+		w, err := compileWindow(*r.When)
+		if err != nil {
+			return cr, err
+		}
+		cr.window = w
+	}
+	for k, m := range r.Params {
+		if strings.TrimSpace(k) == "" {
+			return cr, fmt.Errorf("empty param key")
+		}
+		cm, err := compileMatcher(m)
+		if err != nil {
+			return cr, fmt.Errorf("params.%s: %w", k, err)
+		}
+		cr.params[k] = cm
+	}
+	if r.Content != nil {
+		cm, err := compileMatcher(*r.Content)
+		if err != nil {
+			return cr, fmt.Errorf("content: %w", err)
+		}
+		cr.content = &cm
+	}
+	for _, expr := range r.Redact {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return cr, fmt.Errorf("invalid redact regex %q: %w", expr, err)
+		}
+		cr.redact = append(cr.redact, re)
+	}
+	return cr, nil
+}
+
+func defaultRuleRisk(d Decision) RiskLevel {
+	switch d {
+	case DecisionDeny, DecisionRequireApproval, DecisionAllowWithRedact:
+		return RiskHigh
+	default:
+		return RiskLow
+	}
+}
+
+func compileMatcher(m ParamMatcher) (compiledMatcher, error) {
+	cm := compiledMatcher{ParamMatcher: m}
+	if m.Regex != "" {
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return cm, fmt.Errorf("invalid regex %q: %w", m.Regex, err)
+		}
+		cm.re = re
+	}
+	if m.Glob != "" {
+		if _, err := path.Match(m.Glob, ""); err != nil {
+			return cm, fmt.Errorf("invalid glob %q", m.Glob)
+		}
+	}
+	for _, h := range m.URLHost {
+		if _, err := path.Match(strings.ToLower(h), ""); err != nil {
+			return cm, fmt.Errorf("invalid url_host glob %q", h)
+		}
+	}
+	return cm, nil
+}
+
+func compileWindow(w TimeWindow) (*compiledWindow, error) {
+	cw := &compiledWindow{loc: time.UTC}
+	if tz := strings.TrimSpace(w.Timezone); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %q", tz)
+		}
+		cw.loc = loc
+	}
+	if len(w.Days) > 0 {
+		cw.days = make(map[time.Weekday]bool)
+		for _, d := range w.Days {
+			key := strings.ToLower(strings.TrimSpace(d))
+			if len(key) > 3 {
+				key = key[:3]
+			}
+			wd, ok := ruleWeekdays[key]
+			if !ok {
+				return nil, fmt.Errorf("invalid day %q", d)
+			}
+			cw.days[wd] = true
+		}
+	}
+	if h := strings.TrimSpace(w.Hours); h != "" {
+		fromS, toS, ok := strings.Cut(h, "-")
+		from, err1 := parseClock(fromS)
+		to, err2 := parseClock(toS)
+		if !ok || err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("invalid hours %q (expected HH:MM-HH:MM)", h)
+		}
+		cw.from, cw.to = from, to
+	}
+	return cw, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w *compiledWindow) match(t time.Time) bool {
+	t = t.In(w.loc)
+	if w.days != nil && !w.days[t.Weekday()] {
+		return false
+	}
+	if w.from == w.to {
+		return true
+	}
+	m := t.Hour()*60 + t.Minute()
+	if w.from < w.to {
+		return m >= w.from && m < w.to
+	}
+	return m >= w.from || m < w.to // wraps midnight
+}
+
+// Match returns the result of the first rule matching the action, or false
+// when no rule matches.
+func (rs *RuleSet) Match(meta Meta, a Action, redactor *Redactor) (Result, bool) {
+	if rs == nil {
+		return Result{}, false
+	}
+	for i := range rs.rules {
+		r := &rs.rules[i]
+		if !r.matches(meta, a) {
+			continue
+		}
+		return r.result(a, redactor), true
+	}
+	return Result{}, false
+}
+
+func (r *compiledRule) matches(meta Meta, a Action) bool {
+	if !r.actions[a.Type] {
+		return false
+	}
+	if len(r.Tools) > 0 {
+		name := strings.ToLower(strings.TrimSpace(a.ToolName))
+		ok := false
+		for _, g := range r.Tools {
+			if m, _ := path.Match(strings.ToLower(strings.TrimSpace(g)), name); m {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(r.Triggers) > 0 {
+		ok := false
+		for _, t := range r.Triggers {
+			if strings.EqualFold(strings.TrimSpace(t), strings.TrimSpace(meta.Trigger)) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if r.window != nil {
+		t := meta.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+		if !r.window.match(t) {
+			return false
+		}
+	}
+	for k, m := range r.params {
+		v, found := lookupParam(a.ToolParams, k)
+		if !m.match(v, found) {
+			return false
+		}
+	}
+	if r.content != nil && !r.content.match(a.Content, a.Content != "") {
+		return false
+	}
+	return true
+}
+
+func (r *compiledRule) result(a Action, redactor *Redactor) Result {
+	reason := strings.TrimSpace(r.Reason)
+	if reason == "" {
+		reason = "rule:" + strings.TrimSpace(r.Name)
+	}
+	res := Result{RiskLevel: r.risk, Decision: r.decision, Reasons: []string{reason}}
+	if r.decision != DecisionAllowWithRedact {
+		return res
+	}
+	red, counts := a.Content, map[string]int(nil)
+	if len(r.redact) > 0 {
+		n := 0
+		for _, re := range r.redact {
+			n += len(re.FindAllStringIndex(red, -1))
+			red = re.ReplaceAllString(red, "[redacted]")
+		}
+		if n > 0 {
+			counts = map[string]int{"rule:" + strings.TrimSpace(r.Name): n}
+		}
+	} else if redactor != nil {
+		red, counts = redactor.Redact(a.Content)
+	}
+	if len(counts) == 0 {
+		// Nothing to rewrite; keep the rule's verdict visible in the audit trail.
+		res.Decision = DecisionAllow
+		return res
+	}
+	res.RedactedContent = red
+	res.Redactions = counts
+	return res
+}
+
+// lookupParam resolves a dotted key against nested maps. An exact key match
+// wins over descending, so keys that contain dots still work.
+func lookupParam(params map[string]any, key string) (any, bool) {
+	if params == nil {
+		return nil, false
+	}
+	if v, ok := params[key]; ok {
+		return v, true
+	}
+	head, rest, ok := strings.Cut(key, ".")
+	if !ok {
+		return nil, false
+	}
+	v, found := params[head]
+	if !found {
+		return nil, false
+	}
+	switch m := v.(type) {
+	case map[string]any:
+		return lookupParam(m, rest)
+	case map[string]string:
+		s, ok := m[rest]
+		return s, ok
+	}
+	return nil, false
+}
+
+func (m *compiledMatcher) match(v any, found bool) bool {
+	return m.matchRaw(v, found) != m.Not
+}
+
+func (m *compiledMatcher) matchRaw(v any, found bool) bool {
+	if m.Exists != nil && *m.Exists != found {
+		return false
+	}
+	hasValueTest := m.Equals != nil || len(m.OneOf) > 0 || m.re != nil || m.Glob != "" || m.Contains != "" || len(m.URLPrefix) > 0 || len(m.URLHost) > 0
+	if !hasValueTest {
+		return m.Exists != nil || found
+	}
+	if !found {
+		return false
+	}
+	for _, s := range paramStrings(v) {
+		if m.matchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *compiledMatcher) matchString(s string) bool {
+	if m.Equals != nil && s != fmt.Sprint(m.Equals) {
+		return false
+	}
+	if len(m.OneOf) > 0 {
+		ok := false
+		for _, o := range m.OneOf {
+			if s == fmt.Sprint(o) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if m.re != nil && !m.re.MatchString(s) {
+		return false
+	}
+	if m.Glob != "" {
+		if ok, _ := path.Match(m.Glob, s); !ok {
+			return false
+		}
+	}
+	if m.Contains != "" && !strings.Contains(s, m.Contains) {
+		return false
+	}
+	if len(m.URLPrefix) > 0 && !URLAllowedByPrefixes(s, m.URLPrefix) {
+		return false
+	}
+	if len(m.URLHost) > 0 {
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil || u.Hostname() == "" {
+			return false
+		}
+		host := strings.ToLower(u.Hostname())
+		ok := false
+		for _, g := range m.URLHost {
+			g = strings.ToLower(strings.TrimSpace(g))
+			if hit, _ := path.Match(g, host); hit {
+				ok = true
+				break
+			}
+			// "*.example.com" also covers the apex.
+			if strings.HasPrefix(g, "*.") && host == g[2:] {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func paramStrings(v any) []string {
+	switch x := v.(type) {
+	case nil:
+		return []string{""}
+	case string:
+		return []string{x}
+	case []any:
+		out := make([]string, 0, len(x))
+		for _, e := range x {
+			out = append(out, paramStrings(e)...)
+		}
+		return out
+	case []string:
+		return x
+	case float64:
+		return []string{strconv.FormatFloat(x, 'f', -1, 64)}
+	default:
+		return []string{fmt.Sprint(x)}
+	}
+}
@@ -41,6 +41,9 @@ type Meta struct {
 	RunID string
 	Step  int
 	Time  time.Time
+
+	// Trigger is the run's origin (telegram, cron, mcp); empty for CLI runs.
+	Trigger string
 }
 
 type Action struct {
@@ -61,6 +64,8 @@ type Result struct {
 	Reasons   []string
 
 	RedactedContent string
+	// Redactions counts replacements per detector when content was redacted.
+	Redactions map[string]int
 }
 
 type AuditEvent struct {
@@ -77,6 +82,8 @@ type AuditEvent struct {
 	RiskLevel RiskLevel `json:"risk_level"`
 	Decision  Decision  `json:"decision"`
 	Reasons   []string  `json:"reasons,omitempty"`
+	// Redactions counts redacted matches per detector (e.g. {"email": 2}).
+	Redactions map[string]int `json:"redactions,omitempty"`
 
 	ApprovalRequestID string `json:"approval_request_id,omitempty"`
 	ApprovalStatus    string `json:"approval_status,omitempty"`
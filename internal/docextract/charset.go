@@ -0,0 +1,102 @@
+package docextract
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// DetectEncoding picks an encoding for sample: a BOM wins, valid UTF-8 is kept
+// as-is, otherwise HTML meta tags and byte-frequency heuristics are used
+// (falling back to windows-1252). name is "" for UTF-8/ASCII.
+func DetectEncoding(sample []byte) (encoding.Encoding, string) {
+	return detectEncoding(sample, false)
+}
+
+// detectEncoding is DetectEncoding; complete reports that sample is the whole
+// input, so a trailing partial rune is an encoding error rather than a cut.
+func detectEncoding(sample []byte, complete bool) (encoding.Encoding, string) {
+	switch {
+	case bytes.HasPrefix(sample, []byte("\xef\xbb\xbf")):
+		return unicode.UTF8BOM, ""
+	case bytes.HasPrefix(sample, []byte("\xff\xfe")):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), "utf-16le"
+	case bytes.HasPrefix(sample, []byte("\xfe\xff")):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), "utf-16be"
+	}
+	if utf8.Valid(sample) || (!complete && validUTF8Prefix(sample)) {
+		return nil, ""
+	}
+	enc, name, _ := charset.DetermineEncoding(sample, "text/plain")
+	return enc, name
+}
+
+// validUTF8Prefix is utf8.Valid, tolerating a rune cut off at the end of the sample.
+func validUTF8Prefix(b []byte) bool {
+	for i := 0; i < 3 && len(b) > 0 && !utf8.Valid(b); i++ {
+		b = b[:len(b)-1]
+	}
+	return utf8.Valid(b)
+}
+
+// LookupEncoding resolves a user-supplied encoding label (e.g. "gbk", "shift_jis", "latin1").
+func LookupEncoding(label string) (encoding.Encoding, error) {
+	label = strings.TrimSpace(label)
+	switch strings.ToLower(label) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	}
+	enc, _ := charset.Lookup(label)
+	if enc == nil {
+		return nil, fmt.Errorf("unknown encoding: %s", label)
+	}
+	return enc, nil
+}
+
+// NewDecodingReader wraps r so it yields UTF-8. When label is empty the
+// encoding is detected from sample (the first bytes of r, which must not have
+// been consumed). It returns the encoding name ("" for UTF-8).
+func NewDecodingReader(r io.Reader, sample []byte, label string) (io.Reader, string, error) {
+	return newDecodingReader(r, sample, label, false)
+}
+
+func newDecodingReader(r io.Reader, sample []byte, label string, complete bool) (io.Reader, string, error) {
+	var enc encoding.Encoding
+	name := strings.ToLower(strings.TrimSpace(label))
+	if name != "" {
+		var err error
+		if enc, err = LookupEncoding(label); err != nil {
+			return nil, "", err
+		}
+		if enc == nil {
+			name = ""
+		}
+	} else {
+		enc, name = detectEncoding(sample, complete)
+	}
+	if enc == nil {
+		return r, name, nil
+	}
+	return transform.NewReader(r, enc.NewDecoder()), name, nil
+}
+
+// DecodeText converts data to UTF-8 (see NewDecodingReader).
+func DecodeText(data []byte, label string) (string, string, error) {
+	sample := data[:min(len(data), 64*1024)]
+	r, name, err := newDecodingReader(bytes.NewReader(data), sample, label, len(sample) == len(data))
+	if err != nil {
+		return "", "", err
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return "", "", err
+	}
+	return string(out), name, nil
+}
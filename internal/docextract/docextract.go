@@ -0,0 +1,100 @@
+// Package docextract turns common document formats into plain text using
+// pure-Go parsers, and decodes legacy text encodings to UTF-8.
+//
+// Supported formats: PDF, DOCX, XLSX, CSV/TSV and HTML. Detection uses the
+// file extension first and falls back to content sniffing, so files saved
+// without an extension (e.g. Telegram downloads) are still recognized.
+package docextract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Format identifies a supported document format.
+type Format string
+
+const (
+	FormatNone Format = ""
+	FormatPDF  Format = "pdf"
+	FormatDOCX Format = "docx"
+	FormatXLSX Format = "xlsx"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+	FormatHTML Format = "html"
+)
+
+// ErrUnsupported is returned by Extract for inputs that are not a supported document.
+var ErrUnsupported = errors.New("unsupported document format")
+
+// Detect returns the document format of name/data, or FormatNone for plain text
+// and unknown binary files. head may be just the first few KB of the file.
+func Detect(name string, head []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		return FormatPDF
+	case ".docx":
+		return FormatDOCX
+	case ".xlsx", ".xlsm":
+		return FormatXLSX
+	case ".csv":
+		return FormatCSV
+	case ".tsv", ".tab":
+		return FormatTSV
+	case ".html", ".htm", ".xhtml":
+		return FormatHTML
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return FormatPDF
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		// OOXML containers list their main part early in the archive.
+		switch {
+		case bytes.Contains(head, []byte("word/")):
+			return FormatDOCX
+		case bytes.Contains(head, []byte("xl/")):
+			return FormatXLSX
+		}
+	}
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '<' {
+		lower := bytes.ToLower(trimmed[:min(len(trimmed), 512)])
+		if bytes.HasPrefix(lower, []byte("<!doctype html")) || bytes.HasPrefix(lower, []byte("<html")) {
+			return FormatHTML
+		}
+	}
+	return FormatNone
+}
+
+// Extract converts data (the full file) to plain text.
+func Extract(format Format, data []byte) (text string, err error) {
+	defer func() {
+		// Third-party parsers may panic on malformed input.
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("extract %s: malformed document (%v)", format, r)
+		}
+	}()
+	switch format {
+	case FormatPDF:
+		return extractPDF(data)
+	case FormatDOCX:
+		return extractDOCX(data)
+	case FormatXLSX:
+		return extractXLSX(data)
+	case FormatCSV:
+		return extractDelimited(data, ',')
+	case FormatTSV:
+		return extractDelimited(data, '\t')
+	case FormatHTML:
+		s, _, err := DecodeText(data, "")
+		if err != nil {
+			return "", err
+		}
+		return HTMLToText(s), nil
+	default:
+		return "", ErrUnsupported
+	}
+}
@@ -0,0 +1,153 @@
+package docextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		name string
+		head string
+		want Format
+	}{
+		{"report.PDF", "", FormatPDF},
+		{"a.csv", "", FormatCSV},
+		{"download", "%PDF-1.7\n", FormatPDF},
+		{"download", "PK\x03\x04....word/document.xml", FormatDOCX},
+		{"download", "PK\x03\x04....xl/workbook.xml", FormatXLSX},
+		{"page", "\n  <!DOCTYPE html><html>", FormatHTML},
+		{"notes.txt", "hello <b>", FormatNone},
+		{"blob", "PK\x03\x04....other", FormatNone},
+	}
+	for _, tc := range cases {
+		if got := Detect(tc.name, []byte(tc.head)); got != tc.want {
+			t.Errorf("Detect(%q, %q) = %q, want %q", tc.name, tc.head, got, tc.want)
+		}
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	in := `<html><head><title>T</title><style>p{}</style></head><body>
+<script>alert(1)</script>
+<h1>Title</h1><p>Hello   <b>world</b></p>
+<ul><li>one</li><li>two</li></ul>
+<table><tr><td>a</td><td>b</td></tr></table>
+</body></html>`
+	got := HTMLToText(in)
+	for _, want := range []string{"Title\n", "Hello world", "- one\n- two", "a | b"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+	if strings.Contains(got, "alert") || strings.Contains(got, "p{}") {
+		t.Errorf("script/style leaked: %q", got)
+	}
+}
+
+func TestExtractCSV(t *testing.T) {
+	got, err := Extract(FormatCSV, []byte("name,note\nbob,\"multi\nline\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "name | note\nbob | multi line\n" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestExtractDOCX(t *testing.T) {
+	doc := `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:t xml:space="preserve"> docx</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>A1</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>B1</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`
+	data := buildZip(t, map[string]string{"word/document.xml": doc})
+	got, err := Extract(FormatDOCX, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "Hello docx\n") || !strings.Contains(got, "A1 | B1") {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestExtractXLSX(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>name</t></si><si><t>qty</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>apple</t></is></c><c r="C2"><v>3</v></c></row>
+</sheetData></worksheet>`,
+	})
+	got, err := Extract(FormatXLSX, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Sheet: Data\nname | qty\napple |  | 3\n"
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("got %q, want prefix %q", got, want)
+	}
+}
+
+func TestExtractMalformed(t *testing.T) {
+	if _, err := Extract(FormatPDF, []byte("%PDF-1.4 garbage")); err == nil {
+		t.Fatal("expected error for malformed pdf")
+	}
+	if _, err := Extract(FormatDOCX, []byte("not a zip")); err == nil {
+		t.Fatal("expected error for malformed docx")
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("你好，世界")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := DecodeText([]byte(gbk), "gbk")
+	if err != nil || got != "你好，世界" {
+		t.Fatalf("gbk: got %q, %v", got, err)
+	}
+
+	got, name, err := DecodeText([]byte("caf\xe9"), "")
+	if err != nil || got != "café" || name == "" {
+		t.Fatalf("latin1 detect: got %q (%s), %v", got, name, err)
+	}
+
+	got, name, err = DecodeText([]byte("\xff\xfeh\x00i\x00"), "")
+	if err != nil || got != "hi" || name != "utf-16le" {
+		t.Fatalf("utf-16: got %q (%s), %v", got, name, err)
+	}
+
+	got, name, _ = DecodeText([]byte("plain ✓"), "")
+	if got != "plain ✓" || name != "" {
+		t.Fatalf("utf-8: got %q (%s)", got, name)
+	}
+
+	if _, _, err := DecodeText([]byte("x"), "no-such-charset"); err == nil {
+		t.Fatal("expected unknown encoding error")
+	}
+}
@@ -0,0 +1,92 @@
+package docextract
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	htmlSkipTags = map[string]bool{
+		"script": true, "style": true, "noscript": true, "template": true,
+		"svg": true, "head": true, "iframe": true, "object": true,
+	}
+	htmlBlockTags = map[string]bool{
+		"p": true, "div": true, "section": true, "article": true, "main": true,
+		"header": true, "footer": true, "nav": true, "aside": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"ul": true, "ol": true, "li": true, "table": true, "tr": true,
+		"pre": true, "blockquote": true, "br": true, "hr": true,
+		"dl": true, "dt": true, "dd": true, "figure": true, "figcaption": true,
+		"form": true, "title": true,
+	}
+	// htmlLineTags start a new line without the blank line used between blocks.
+	htmlLineTags = map[string]bool{"li": true, "tr": true, "br": true, "dt": true, "dd": true}
+	blankLinesRe = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+	spacesRe     = regexp.MustCompile(`[ \t\f\v\r]+`)
+)
+
+// HTMLToText returns the visible text of an HTML document with one line per
+// block element. Scripts, styles and other non-content elements are dropped.
+func HTMLToText(s string) string {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return s
+	}
+	return nodeText(doc)
+}
+
+// nodeText renders the visible text below n (see HTMLToText).
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.CommentNode, html.DoctypeNode:
+			return
+		case html.TextNode:
+			if pre {
+				b.WriteString(n.Data)
+			} else {
+				b.WriteString(spacesRe.ReplaceAllString(strings.ReplaceAll(n.Data, "\n", " "), " "))
+			}
+			return
+		case html.ElementNode:
+			if htmlSkipTags[n.Data] {
+				return
+			}
+		}
+		block := n.Type == html.ElementNode && htmlBlockTags[n.Data]
+		line := block && htmlLineTags[n.Data]
+		if block && !line {
+			b.WriteString("\n")
+		}
+		if n.Type == html.ElementNode && n.Data == "li" {
+			b.WriteString("- ")
+		}
+		if n.Type == html.ElementNode && (n.Data == "td" || n.Data == "th") && n.PrevSibling != nil {
+			b.WriteString(" | ")
+		}
+		inPre := pre || (n.Type == html.ElementNode && n.Data == "pre")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, inPre)
+		}
+		if block {
+			b.WriteString("\n")
+		}
+	}
+	walk(n, false)
+	return tidyText(b.String())
+}
+
+// tidyText trims line edges and collapses runs of blank lines.
+func tidyText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
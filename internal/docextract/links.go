@@ -0,0 +1,62 @@
+package docextract
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Links returns the absolute http(s) targets of the <a href> links in an HTML
+// page, in document order and without duplicates or fragments. Links are
+// resolved against the page's <base href> (itself resolved against base), or
+// base. rel="nofollow" links are skipped.
+func Links(page string, base *url.URL) []*url.URL {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return nil
+	}
+	if b := findElement(doc, "base"); b != nil {
+		if href, ok := attrOK(b, "href"); ok {
+			if u, err := url.Parse(strings.TrimSpace(href)); err == nil {
+				if base != nil {
+					u = base.ResolveReference(u)
+				}
+				base = u
+			}
+		}
+	}
+	seen := map[string]bool{}
+	var out []*url.URL
+	walkElements(doc, func(n *html.Node) {
+		if n.Data != "a" {
+			return
+		}
+		href, ok := attrOK(n, "href")
+		if !ok || strings.TrimSpace(href) == "" {
+			return
+		}
+		for _, rel := range strings.Fields(strings.ToLower(attr(n, "rel"))) {
+			if rel == "nofollow" {
+				return
+			}
+		}
+		u, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			return
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if s := strings.ToLower(u.Scheme); (s != "http" && s != "https") || u.Host == "" {
+			return
+		}
+		u.Fragment = ""
+		u.RawFragment = ""
+		if k := u.String(); !seen[k] {
+			seen[k] = true
+			out = append(out, u)
+		}
+	})
+	return out
+}
@@ -0,0 +1,281 @@
+package docextract
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ReadableMarkdown extracts the main content of an HTML page (see mainContent)
+// and renders it as Markdown. Relative links and images are resolved against
+// base when it is non-nil.
+func ReadableMarkdown(page string, base *url.URL) (title, markdown string) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return "", page
+	}
+	title = pageTitle(doc)
+	r := mdRenderer{base: base}
+	return title, tidyMarkdown(r.blocks(mainContent(doc)))
+}
+
+// ReadableText is ReadableMarkdown without the markup: the main content as plain text.
+func ReadableText(page string) (title, text string) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		return "", page
+	}
+	title = pageTitle(doc)
+	return title, nodeText(mainContent(doc))
+}
+
+var (
+	mdBlockTags = map[string]bool{
+		"p": true, "div": true, "section": true, "article": true, "main": true,
+		"header": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"ul": true, "ol": true, "li": true, "table": true, "pre": true, "blockquote": true,
+		"hr": true, "dl": true, "dt": true, "dd": true, "figure": true, "figcaption": true,
+		"details": true, "summary": true, "address": true, "center": true,
+	}
+	mdBlankRunRe = regexp.MustCompile(`\n{3,}`)
+)
+
+type mdRenderer struct {
+	base *url.URL
+}
+
+// blocks renders the children of n as Markdown blocks separated by blank lines.
+func (r *mdRenderer) blocks(n *html.Node) string {
+	var out []string
+	var para strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(trimLineSpace(para.String())); s != "" {
+			out = append(out, s)
+		}
+		para.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && mdBlockTags[c.Data] {
+			flush()
+			if s := strings.TrimSpace(r.block(c)); s != "" {
+				out = append(out, s)
+			}
+			continue
+		}
+		para.WriteString(r.inline(c))
+	}
+	flush()
+	return strings.Join(out, "\n\n")
+}
+
+func (r *mdRenderer) block(n *html.Node) string {
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := collapseSpace(r.inlineChildren(n))
+		if text == "" {
+			return ""
+		}
+		return strings.Repeat("#", int(n.Data[1]-'0')) + " " + text
+	case "ul", "ol":
+		return r.list(n, n.Data == "ol")
+	case "pre":
+		code := strings.Trim(textContent(n), "\n")
+		lang := ""
+		if c := findElement(n, "code"); c != nil {
+			for _, cls := range strings.Fields(attr(c, "class")) {
+				if l, ok := strings.CutPrefix(cls, "language-"); ok {
+					lang = l
+				}
+			}
+		}
+		return "```" + lang + "\n" + code + "\n```"
+	case "blockquote":
+		return prefixLines(r.blocks(n), "> ", "> ")
+	case "table":
+		return r.table(n)
+	case "hr":
+		return "---"
+	case "dt":
+		return "**" + collapseSpace(r.inlineChildren(n)) + "**"
+	default:
+		return r.blocks(n)
+	}
+}
+
+func (r *mdRenderer) list(n *html.Node, ordered bool) string {
+	var items []string
+	i := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			continue
+		}
+		i++
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", i)
+		}
+		body := strings.TrimSpace(r.blocks(c))
+		// Tight list: nested blocks on following lines, indented under the marker.
+		body = strings.ReplaceAll(body, "\n\n", "\n")
+		items = append(items, prefixLines(body, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (r *mdRenderer) table(n *html.Node) string {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(p *html.Node) {
+		for c := p.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			case "tr":
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						text := collapseSpace(r.inlineChildren(cell))
+						row = append(row, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func (r *mdRenderer) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(r.inline(c))
+	}
+	return b.String()
+}
+
+func (r *mdRenderer) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return spacesRe.ReplaceAllString(strings.ReplaceAll(n.Data, "\n", " "), " ")
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if mdBlockTags[n.Data] {
+		// Block inside inline content (e.g. <div> in <a>): keep it on its own line.
+		return "\n" + r.blocks(n) + "\n"
+	}
+	switch n.Data {
+	case "br":
+		return "\n"
+	case "a":
+		text := strings.TrimSpace(collapseSpace(r.inlineChildren(n)))
+		href := r.resolve(attr(n, "href"))
+		if text == "" || href == "" {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case "img":
+		src := r.resolve(attr(n, "src"))
+		if src == "" {
+			return ""
+		}
+		return "![" + collapseSpace(attr(n, "alt")) + "](" + src + ")"
+	case "strong", "b":
+		return wrapInline(r.inlineChildren(n), "**")
+	case "em", "i":
+		return wrapInline(r.inlineChildren(n), "_")
+	case "code", "kbd", "samp":
+		return wrapInline(textContent(n), "`")
+	case "del", "s", "strike":
+		return wrapInline(r.inlineChildren(n), "~~")
+	default:
+		return r.inlineChildren(n)
+	}
+}
+
+// resolve makes href absolute; in-page anchors and javascript: links are dropped.
+func (r *mdRenderer) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "javascript", "data", "vbscript":
+		return ""
+	}
+	if r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(u.String(), "(", "%28"), ")", "%29")
+}
+
+// wrapInline wraps s in marker, keeping surrounding spaces outside the markers.
+func wrapInline(s, marker string) string {
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return s
+	}
+	lead := s[:strings.Index(s, t)]
+	trail := s[len(lead)+len(t):]
+	return lead + marker + t + marker + trail
+}
+
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		p := rest
+		if i == 0 {
+			p = first
+		}
+		if strings.TrimSpace(l) == "" {
+			lines[i] = strings.TrimRight(p, " ")
+		} else {
+			lines[i] = p + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func trimLineSpace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func tidyMarkdown(s string) string {
+	s = mdBlankRunRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s) + "\n"
+}
@@ -0,0 +1,91 @@
+package docextract
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const articlePage = `<!doctype html>
+<html><head><title>Release notes</title><script>track()</script></head>
+<body>
+<nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter, get updates, and more, every week.</p></div>
+<div id="content">
+  <h1>Version 2.0</h1>
+  <p>This release adds <strong>streaming</strong>, a new <a href="/docs/api">API reference</a>, and many fixes.</p>
+  <p>Upgrading is simple, backwards compatible, and takes about five minutes for most users.</p>
+  <ul><li>Faster startup</li><li>Smaller binary<ul><li>by 30%</li></ul></li></ul>
+  <pre><code class="language-go">fmt.Println("hi")</code></pre>
+  <table><tr><th>Plan</th><th>Price</th></tr><tr><td>Pro</td><td>$5</td></tr></table>
+  <blockquote><p>Best release yet.</p></blockquote>
+</div>
+<footer><p>Copyright 2024, Example Inc, all rights reserved, contact us.</p></footer>
+</body></html>`
+
+func TestReadableMarkdown(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/v2")
+	title, md := ReadableMarkdown(articlePage, base)
+	if title != "Release notes" {
+		t.Fatalf("title = %q", title)
+	}
+	for _, want := range []string{
+		"# Version 2.0\n\nThis release adds **streaming**, a new [API reference](https://example.com/docs/api), and many fixes.",
+		"- Faster startup\n- Smaller binary\n  - by 30%",
+		"```go\nfmt.Println(\"hi\")\n```",
+		"| Plan | Price |\n| --- | --- |\n| Pro | $5 |",
+		"> Best release yet.",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("missing %q in:\n%s", want, md)
+		}
+	}
+	for _, unwanted := range []string{"track()", "Home", "newsletter", "Copyright"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("boilerplate %q leaked into:\n%s", unwanted, md)
+		}
+	}
+}
+
+func TestReadableText(t *testing.T) {
+	title, text := ReadableText(articlePage)
+	if title != "Release notes" {
+		t.Fatalf("title = %q", title)
+	}
+	if !strings.Contains(text, "This release adds streaming, a new API reference, and many fixes.") {
+		t.Fatalf("got:\n%s", text)
+	}
+	if strings.Contains(text, "Copyright") || strings.Contains(text, "](") {
+		t.Fatalf("unexpected content:\n%s", text)
+	}
+}
+
+func TestReadableMarkdown_PrefersArticle(t *testing.T) {
+	page := `<html><body><div><p>` + strings.Repeat("Teaser text, with commas, ", 10) + `</p></div>
+<article><h2>Story</h2><p>` + strings.Repeat("The actual story body. ", 20) + `</p>
+<a href="javascript:void(0)">share</a></article></body></html>`
+	_, md := ReadableMarkdown(page, nil)
+	if !strings.HasPrefix(md, "## Story\n\nThe actual story body.") || strings.Contains(md, "Teaser") {
+		t.Fatalf("got:\n%s", md)
+	}
+	if strings.Contains(md, "javascript") {
+		t.Fatalf("javascript link kept:\n%s", md)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/blog/v2")
+	var got []string
+	for _, u := range Links(articlePage+`<a href="/docs/api#intro">again</a><a href="mailto:x@example.com">m</a><a rel="nofollow" href="/login">in</a>`, base) {
+		got = append(got, u.String())
+	}
+	want := "https://example.com/ https://example.com/blog https://example.com/docs/api"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("Links = %v", got)
+	}
+
+	page := `<html><head><base href="/docs/v1/"></head><body><a href="guide">g</a></body></html>`
+	if links := Links(page, base); len(links) != 1 || links[0].String() != "https://example.com/docs/v1/guide" {
+		t.Fatalf("Links with <base> = %v", links)
+	}
+}
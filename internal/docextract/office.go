@@ -0,0 +1,336 @@
+package docextract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxZipPartBytes bounds decompressed OOXML parts (zip bomb protection).
+const maxZipPartBytes = 64 << 20
+
+func openZip(data []byte) (*zip.Reader, error) {
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
+
+func readZipPart(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(io.LimitReader(rc, maxZipPartBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > maxZipPartBytes {
+			return nil, fmt.Errorf("%s: part too large", name)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("%s: not found", name)
+}
+
+func extractDOCX(data []byte) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", fmt.Errorf("docx: %w", err)
+	}
+	doc, err := readZipPart(zr, "word/document.xml")
+	if err != nil {
+		return "", fmt.Errorf("docx: %w", err)
+	}
+	var b strings.Builder
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	inText := false
+	cellDepth, cellIdx := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("docx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			case "tr":
+				cellIdx = 0
+			case "tc":
+				if cellIdx > 0 {
+					// The previous cell's paragraph already ended with a space.
+					b.WriteString("| ")
+				}
+				cellIdx++
+				cellDepth++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				// Paragraphs inside table cells stay on the row's line.
+				if cellDepth > 0 {
+					b.WriteByte(' ')
+				} else {
+					b.WriteByte('\n')
+				}
+			case "tc":
+				cellDepth--
+			case "tr":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+	return tidyText(b.String()), nil
+}
+
+type xlsxSheet struct {
+	name   string
+	target string
+}
+
+func extractXLSX(data []byte) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", fmt.Errorf("xlsx: %w", err)
+	}
+	shared, err := xlsxSharedStrings(zr)
+	if err != nil {
+		return "", err
+	}
+	sheets, err := xlsxSheets(zr)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, sh := range sheets {
+		raw, err := readZipPart(zr, sh.target)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, "## Sheet: %s\n", sh.name)
+		if err := xlsxWriteSheet(&b, raw, shared); err != nil {
+			return "", fmt.Errorf("xlsx: sheet %s: %w", sh.name, err)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+func xlsxSharedStrings(zr *zip.Reader) ([]string, error) {
+	raw, err := readZipPart(zr, "xl/sharedStrings.xml")
+	if err != nil {
+		return nil, nil // optional part
+	}
+	var out []string
+	var cur strings.Builder
+	inSI, inT, inRPh := false, false, false
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("xlsx: shared strings: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inSI = true
+				cur.Reset()
+			case "t":
+				inT = true
+			case "rPh":
+				// Phonetic hints duplicate text; skip them.
+				inRPh = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				inSI = false
+				out = append(out, cur.String())
+			case "t":
+				inT = false
+			case "rPh":
+				inRPh = false
+			}
+		case xml.CharData:
+			if inSI && inT && !inRPh {
+				cur.Write(t)
+			}
+		}
+	}
+	return out, nil
+}
+
+func xlsxSheets(zr *zip.Reader) ([]xlsxSheet, error) {
+	wb, err := readZipPart(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, fmt.Errorf("xlsx: %w", err)
+	}
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(wb, &workbook); err != nil {
+		return nil, fmt.Errorf("xlsx: workbook: %w", err)
+	}
+	targets := map[string]string{}
+	if rels, err := readZipPart(zr, "xl/_rels/workbook.xml.rels"); err == nil {
+		var r struct {
+			Rels []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		if xml.Unmarshal(rels, &r) == nil {
+			for _, rel := range r.Rels {
+				t := rel.Target
+				if strings.HasPrefix(t, "/") {
+					t = strings.TrimPrefix(t, "/")
+				} else {
+					t = path.Join("xl", t)
+				}
+				targets[rel.ID] = t
+			}
+		}
+	}
+	var out []xlsxSheet
+	for i, s := range workbook.Sheets {
+		t := targets[s.RID]
+		if t == "" {
+			t = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		out = append(out, xlsxSheet{name: s.Name, target: t})
+	}
+	return out, nil
+}
+
+func xlsxWriteSheet(b *strings.Builder, raw []byte, shared []string) error {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(raw, &sheet); err != nil {
+		return err
+	}
+	for _, row := range sheet.Rows {
+		cells := map[int]string{}
+		maxCol := -1
+		next := 0
+		for _, c := range row.Cells {
+			col := next
+			if c.Ref != "" {
+				col = xlsxColumn(c.Ref)
+			}
+			next = col + 1
+			v := c.Value
+			switch c.Type {
+			case "s":
+				if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && i >= 0 && i < len(shared) {
+					v = shared[i]
+				}
+			case "inlineStr":
+				v = c.Inline
+			case "b":
+				if v == "1" {
+					v = "TRUE"
+				} else {
+					v = "FALSE"
+				}
+			}
+			cells[col] = v
+			if col > maxCol {
+				maxCol = col
+			}
+		}
+		if maxCol < 0 {
+			continue
+		}
+		cols := make([]int, 0, len(cells))
+		for c := range cells {
+			cols = append(cols, c)
+		}
+		sort.Ints(cols)
+		vals := make([]string, maxCol+1)
+		for _, c := range cols {
+			vals[c] = strings.ReplaceAll(cells[c], "\n", " ")
+		}
+		b.WriteString(strings.Join(vals, " | "))
+		b.WriteByte('\n')
+	}
+	return nil
+}
+
+// xlsxColumn converts the column letters of a cell ref ("C12") to a 0-based index.
+func xlsxColumn(ref string) int {
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1
+}
+
+func extractDelimited(data []byte, sep rune) (string, error) {
+	s, _, err := DecodeText(data, "")
+	if err != nil {
+		return "", err
+	}
+	r := csv.NewReader(strings.NewReader(s))
+	r.Comma = sep
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	var b strings.Builder
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Not really delimited; return the decoded text unchanged.
+			return s, nil
+		}
+		for i := range rec {
+			rec[i] = strings.ReplaceAll(rec[i], "\n", " ")
+		}
+		b.WriteString(strings.Join(rec, " | "))
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,39 @@
+package docextract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+func extractPDF(data []byte) (string, error) {
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("pdf: %w", err)
+	}
+	var b strings.Builder
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		for _, name := range p.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				f := p.Font(name)
+				fonts[name] = &f
+			}
+		}
+		text, err := p.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("pdf: page %d: %w", i, err)
+		}
+		fmt.Fprintf(&b, "[page %d]\n%s\n\n", i, strings.TrimSpace(text))
+	}
+	if strings.TrimSpace(b.String()) == "" {
+		return "", fmt.Errorf("pdf: no extractable text (scanned or image-only document?)")
+	}
+	return b.String(), nil
+}
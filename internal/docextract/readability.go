@@ -0,0 +1,201 @@
+package docextract
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// Elements that never hold main content.
+	boilerplateTags = map[string]bool{
+		"nav": true, "footer": true, "aside": true, "form": true,
+		"button": true, "input": true, "select": true, "textarea": true, "dialog": true,
+	}
+	unlikelyRe = regexp.MustCompile(`(?i)comment|sidebar|footer|\bnav|menu|share|social|sponsor|advert|\bads?\b|promo|related|cookie|banner|popup|modal|subscribe|newsletter|breadcrumb|pagination|skip-link`)
+	maybeRe    = regexp.MustCompile(`(?i)article|body|content|main|post|entry|story|text`)
+)
+
+// mainContent returns the node holding the main content of doc, readability
+// style: explicit <article>/<main> landmarks win, otherwise paragraphs vote
+// for their ancestors and the best-scoring container with few links is kept.
+// doc is pruned of boilerplate in place.
+func mainContent(doc *html.Node) *html.Node {
+	prune(doc)
+	body := findElement(doc, "body")
+	if body == nil {
+		body = doc
+	}
+
+	var landmark *html.Node
+	landmarkLen := 0
+	walkElements(body, func(n *html.Node) {
+		if n.Data == "article" || n.Data == "main" || attr(n, "role") == "main" {
+			if l := len(textContent(n)); l > landmarkLen {
+				landmark, landmarkLen = n, l
+			}
+		}
+	})
+	if landmark != nil && landmarkLen >= 250 {
+		return landmark
+	}
+
+	scores := map[*html.Node]float64{}
+	walkElements(body, func(n *html.Node) {
+		switch n.Data {
+		case "p", "pre", "td", "blockquote":
+		default:
+			return
+		}
+		text := strings.TrimSpace(textContent(n))
+		if len(text) < 25 {
+			return
+		}
+		s := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		if p := n.Parent; p != nil {
+			scores[p] += s
+			if gp := p.Parent; gp != nil {
+				scores[gp] += s / 2
+			}
+		}
+	})
+	var best *html.Node
+	bestScore := 0.0
+	for n, s := range scores {
+		s *= 1 - linkDensity(n)
+		if s > bestScore || (s == bestScore && best != nil && isAncestor(n, best)) {
+			best, bestScore = n, s
+		}
+	}
+	if best == nil {
+		return body
+	}
+	return best
+}
+
+// prune removes scripts, hidden elements and navigation/ads boilerplate.
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isBoilerplate(c)) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if htmlSkipTags[n.Data] || boilerplateTags[n.Data] {
+		return true
+	}
+	if n.Data == "body" || n.Data == "html" || n.Data == "article" || n.Data == "main" {
+		return false
+	}
+	if _, ok := attrOK(n, "hidden"); ok || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	if style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", ""); strings.Contains(style, "display:none") {
+		return true
+	}
+	switch attr(n, "role") {
+	case "navigation", "banner", "contentinfo", "complementary", "dialog":
+		return true
+	}
+	id := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyRe.MatchString(id) && !maybeRe.MatchString(id)
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len(textContent(n))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walkElements(n, func(c *html.Node) {
+		if c.Data == "a" {
+			links += len(textContent(c))
+		}
+	})
+	return float64(links) / float64(total)
+}
+
+func pageTitle(doc *html.Node) string {
+	if t := findElement(doc, "title"); t != nil {
+		if s := strings.TrimSpace(collapseSpace(textContent(t))); s != "" {
+			return s
+		}
+	}
+	var og string
+	walkElements(doc, func(n *html.Node) {
+		if og == "" && n.Data == "meta" && attr(n, "property") == "og:title" {
+			og = strings.TrimSpace(attr(n, "content"))
+		}
+	})
+	if og != "" {
+		return og
+	}
+	if h := findElement(doc, "h1"); h != nil {
+		return strings.TrimSpace(collapseSpace(textContent(h)))
+	}
+	return ""
+}
+
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+		}
+		walkElements(c, fn)
+	}
+}
+
+func findElement(n *html.Node, tag string) *html.Node {
+	var found *html.Node
+	walkElements(n, func(c *html.Node) {
+		if found == nil && c.Data == tag {
+			found = c
+		}
+	})
+	return found
+}
+
+func isAncestor(a, n *html.Node) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p == a {
+			return true
+		}
+	}
+	return false
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrOK(n, key)
+	return v
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
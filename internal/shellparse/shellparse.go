@@ -0,0 +1,451 @@
+// Package shellparse breaks a bash command string into the programs it runs,
+// the words it passes and the redirections it makes, using a real shell parser
+// instead of string matching. It never executes or expands anything.
+package shellparse
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// maxDepth bounds recursion into `bash -c "..."` / `eval` strings.
+const maxDepth = 4
+
+// Command is one program invocation. Wrapped invocations are reported twice:
+// `sudo rm -rf x` yields sudo (Args: rm -rf x) and rm (Via: sudo).
+type Command struct {
+	Name    string   // program as written after quote removal (e.g. /usr/bin/curl)
+	Args    []string // arguments after quote removal; dynamic parts keep their source text
+	Dynamic bool     // program name is not a literal ($CMD, $(...))
+	Via     string   // wrapper that runs this command (sudo, env, xargs, bash -c, find -exec ...)
+	// Assigns names the environment variables set for this command only
+	// (GIT_PAGER=x git log, env PAGER=x man ls).
+	Assigns []string
+}
+
+// Program returns the lower-cased base name of the program (curl for /usr/bin/curl).
+func (c Command) Program() string {
+	return strings.ToLower(path.Base(c.Name))
+}
+
+// Redirect is one I/O redirection.
+type Redirect struct {
+	Op     string // >, >>, <, &>, >| ...
+	Target string // file name, fd ("&1") or dynamic source text
+	FD     string // explicit fd (2>), empty when implicit
+}
+
+// Writes reports whether the redirect writes to a file (not a duplicated fd or /dev/null).
+func (r Redirect) Writes() bool {
+	switch r.Op {
+	case ">", ">>", ">|", "&>", "&>>", "<>":
+	default:
+		return false
+	}
+	t := strings.TrimSpace(r.Target)
+	return t != "/dev/null" && t != "/dev/stdout" && t != "/dev/stderr"
+}
+
+// Script is the parsed form of a command string.
+type Script struct {
+	Commands  []Command
+	Redirects []Redirect
+	// Words holds every argument, redirect target and assignment value, so path
+	// policies can be checked against what the shell actually sees.
+	Words []string
+	// Params names the variables expanded anywhere in the script ($HOME,
+	// ${TOKEN:-x}); Vars names the variables the script sets itself
+	// (assignments, declarations, for loops, read). Special parameters ($1, $?)
+	// are not included.
+	Params []string
+	Vars   []string
+	// Opaque is set when part of the script could not be inspected: a bash -c
+	// or eval string that does not parse, or nesting deeper than maxDepth.
+	Opaque bool
+}
+
+// Parse parses src as bash.
+func Parse(src string) (*Script, error) {
+	s := &Script{}
+	if err := s.parse(src, "", 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Script) parse(src, via string, depth int) error {
+	if depth > maxDepth {
+		s.Opaque = true
+		return nil
+	}
+	f, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(src), "")
+	if err != nil {
+		return err
+	}
+	syntax.Walk(f, func(n syntax.Node) bool {
+		switch x := n.(type) {
+		case *syntax.CallExpr:
+			var assigns []string
+			for _, a := range x.Assigns {
+				if a.Value != nil {
+					s.Words = append(s.Words, wordString(a.Value))
+				}
+				if a.Name != nil {
+					assigns = append(assigns, a.Name.Value)
+				}
+			}
+			if len(x.Args) == 0 {
+				return true
+			}
+			c := Command{Name: wordString(x.Args[0]), Dynamic: !isLiteral(x.Args[0]), Via: via, Assigns: assigns}
+			for _, w := range x.Args[1:] {
+				c.Args = append(c.Args, wordString(w))
+			}
+			switch c.Program() {
+			case "read", "mapfile", "readarray":
+				for _, a := range c.Args {
+					if isVarName(a) {
+						s.Vars = append(s.Vars, a)
+					}
+				}
+			}
+			s.Words = append(s.Words, c.Args...)
+			s.addCommand(c, depth)
+		case *syntax.DeclClause:
+			// export/declare/local/readonly/typeset: reported as a command whose
+			// arguments are the flags, names and NAME=value pairs.
+			c := Command{Name: x.Variant.Value, Via: via}
+			for _, a := range x.Args {
+				arg := ""
+				switch {
+				case a.Name != nil && a.Value != nil:
+					arg = a.Name.Value + "=" + wordString(a.Value)
+					s.Words = append(s.Words, wordString(a.Value))
+				case a.Name != nil:
+					arg = a.Name.Value
+				case a.Value != nil:
+					arg = wordString(a.Value)
+				}
+				if arg != "" {
+					c.Args = append(c.Args, arg)
+				}
+			}
+			s.Commands = append(s.Commands, c)
+		case *syntax.Assign:
+			if x.Name != nil {
+				s.Vars = append(s.Vars, x.Name.Value)
+			}
+		case *syntax.WordIter:
+			if x.Name != nil {
+				s.Vars = append(s.Vars, x.Name.Value)
+			}
+		case *syntax.ParamExp:
+			if x.Param != nil && isVarName(x.Param.Value) {
+				s.Params = append(s.Params, x.Param.Value)
+			}
+		case *syntax.Redirect:
+			r := Redirect{Op: x.Op.String()}
+			if x.N != nil {
+				r.FD = x.N.Value
+			}
+			if x.Word != nil {
+				r.Target = wordString(x.Word)
+				s.Words = append(s.Words, r.Target)
+			}
+			s.Redirects = append(s.Redirects, r)
+		}
+		return true
+	})
+	return nil
+}
+
+// addCommand records c and any command it wraps.
+func (s *Script) addCommand(c Command, depth int) {
+	s.Commands = append(s.Commands, c)
+	if c.Dynamic {
+		return
+	}
+	prog := c.Program()
+	switch prog {
+	case "bash", "sh", "zsh", "dash", "ksh":
+		for i := 0; i < len(c.Args); i++ {
+			a := c.Args[i]
+			if a == "-o" || a == "+o" || a == "-O" || a == "+O" {
+				i++ // option name
+				continue
+			}
+			if strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--") && strings.HasSuffix(a, "c") {
+				if i+1 < len(c.Args) {
+					s.parseNested(c.Args[i+1], prog+" -c", depth)
+				}
+				return
+			}
+			if !strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "+") {
+				return // script file
+			}
+		}
+		return
+	case "eval":
+		s.parseNested(strings.Join(c.Args, " "), "eval", depth)
+		return
+	case "find":
+		for i := 0; i < len(c.Args); i++ {
+			switch c.Args[i] {
+			case "-exec", "-execdir", "-ok", "-okdir":
+				j := i + 1
+				for j < len(c.Args) && c.Args[j] != ";" && c.Args[j] != "+" {
+					j++
+				}
+				if i+1 < j {
+					s.addCommand(Command{Name: c.Args[i+1], Args: c.Args[i+2 : j], Via: "find " + c.Args[i]}, depth)
+				}
+				i = j
+			}
+		}
+		return
+	}
+	if skip, ok := wrappers[prog]; ok {
+		if prog == "command" && hasAnyFlag(c.Args, "-v", "-V") {
+			return // lookup only
+		}
+		if prog == "env" {
+			// env -S "cmd args" splits its argument into a command line.
+			if split, rest, ok := envSplitString(c.Args); ok {
+				s.parseNested(strings.TrimSpace(split+" "+strings.Join(rest, " ")), "env -S", depth)
+				return
+			}
+		}
+		rest := skipWrapperArgs(prog, c.Args, skip)
+		if len(rest) > 0 {
+			wrapped := Command{Name: rest[0], Args: rest[1:], Via: prog}
+			if prog == "env" {
+				wrapped.Assigns = envAssigns(c.Args)
+			}
+			s.addCommand(wrapped, depth)
+		}
+	}
+}
+
+func (s *Script) parseNested(src, via string, depth int) {
+	if err := s.parse(src, via, depth+1); err != nil {
+		// Not valid shell: nothing inside can be inspected.
+		s.Opaque = true
+	}
+}
+
+// wrappers run their trailing arguments as a command. The value lists the
+// options that take a separate argument.
+var wrappers = map[string][]string{
+	"sudo":     {"-u", "-g", "-h", "-p", "-C", "-D", "-r", "-t", "-U", "-T"},
+	"doas":     {"-u", "-C"},
+	"env":      {"-u", "-C", "-S", "--unset", "--chdir"},
+	"nice":     {"-n", "--adjustment"},
+	"ionice":   {"-c", "-n", "-p", "-t"},
+	"nohup":    nil,
+	"time":     {"-f", "-o"},
+	"timeout":  {"-s", "-k", "--signal", "--kill-after"},
+	"xargs":    {"-I", "-n", "-P", "-d", "-L", "-s", "-a", "-E", "--max-args", "--max-procs", "--delimiter", "--arg-file"},
+	"command":  nil,
+	"builtin":  nil,
+	"exec":     {"-a"},
+	"stdbuf":   {"-i", "-o", "-e"},
+	"chroot":   nil,
+	"watch":    {"-n", "-d", "--interval"},
+	"strace":   {"-e", "-o", "-p", "-s"},
+	"unbuffer": nil,
+	"setsid":   nil,
+	"flock":    {"-w", "-E"},
+}
+
+func skipWrapperArgs(prog string, args []string, withValue []string) []string {
+	i := 0
+	for i < len(args) {
+		a := args[i]
+		if a == "--" {
+			i++
+			break
+		}
+		if prog == "env" && strings.Contains(a, "=") && !strings.HasPrefix(a, "-") {
+			i++
+			continue
+		}
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			break
+		}
+		i++
+		if !strings.Contains(a, "=") && containsString(withValue, a) {
+			i++
+		}
+	}
+	if i > len(args) {
+		return nil
+	}
+	rest := args[i:]
+	switch prog {
+	case "timeout":
+		if len(rest) > 0 {
+			rest = rest[1:] // duration
+		}
+	case "chroot":
+		if len(rest) > 0 {
+			rest = rest[1:] // new root
+		}
+	case "flock":
+		if len(rest) > 0 {
+			rest = rest[1:] // lock file
+		}
+	}
+	return rest
+}
+
+// envSplitString returns the value of env's -S/--split-string option and the
+// arguments after it.
+func envSplitString(args []string) (string, []string, bool) {
+	for i, a := range args {
+		switch {
+		case a == "--":
+			return "", nil, false
+		case a == "-S" || a == "--split-string":
+			if i+1 < len(args) {
+				return args[i+1], args[i+2:], true
+			}
+			return "", nil, false
+		case strings.HasPrefix(a, "--split-string="):
+			return strings.TrimPrefix(a, "--split-string="), args[i+1:], true
+		case strings.HasPrefix(a, "-S"):
+			return strings.TrimPrefix(a, "-S"), args[i+1:], true
+		case !strings.HasPrefix(a, "-") && !strings.Contains(a, "="):
+			return "", nil, false // command reached
+		}
+	}
+	return "", nil, false
+}
+
+// envAssigns returns the variable names env sets before running its command.
+func envAssigns(args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" || (!strings.HasPrefix(a, "-") && !strings.Contains(a, "=")) {
+			break
+		}
+		if strings.HasPrefix(a, "-") {
+			if containsString(wrappers["env"], a) {
+				i++ // option value
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(a, "=")
+		out = append(out, name)
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyFlag(args []string, flags ...string) bool {
+	for _, a := range args {
+		if containsString(flags, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLiteral(w *syntax.Word) bool {
+	for _, p := range w.Parts {
+		switch x := p.(type) {
+		case *syntax.Lit, *syntax.SglQuoted:
+		case *syntax.DblQuoted:
+			for _, q := range x.Parts {
+				if _, ok := q.(*syntax.Lit); !ok {
+					return false
+				}
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isVarName reports whether s is a shell variable name (not $1, $?, ...).
+func isVarName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// wordString returns w after quote removal. Expansions ($X, $(...)) are kept
+// as their source text since their value is unknown.
+func wordString(w *syntax.Word) string {
+	var b strings.Builder
+	for _, p := range w.Parts {
+		writePart(&b, p, false)
+	}
+	return b.String()
+}
+
+func writePart(b *strings.Builder, p syntax.WordPart, quoted bool) {
+	switch x := p.(type) {
+	case *syntax.Lit:
+		b.WriteString(unescape(x.Value, quoted))
+	case *syntax.SglQuoted:
+		b.WriteString(x.Value)
+	case *syntax.DblQuoted:
+		for _, q := range x.Parts {
+			writePart(b, q, true)
+		}
+	default:
+		var buf bytes.Buffer
+		if err := syntax.NewPrinter().Print(&buf, p); err == nil {
+			b.Write(buf.Bytes())
+		}
+	}
+}
+
+// unescape applies backslash removal; inside double quotes only \$ \` \" \\
+// and line continuations are escapes.
+func unescape(s string, quoted bool) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		next := s[i+1]
+		if next == '\n' {
+			i++
+			continue
+		}
+		if quoted && !strings.ContainsRune("$`\"\\", rune(next)) {
+			b.WriteByte(s[i])
+			continue
+		}
+		b.WriteByte(next)
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package shellparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func programs(s *Script) []string {
+	var out []string
+	for _, c := range s.Commands {
+		p := c.Program()
+		if c.Dynamic {
+			p = "$"
+		}
+		if c.Via != "" {
+			p = c.Via + ">" + p
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func TestParse_Programs(t *testing.T) {
+	cases := []struct {
+		cmd  string
+		want []string
+	}{
+		{"ls -la | grep foo && wc -l < x.txt", []string{"ls", "grep", "wc"}},
+		{`"cu"rl https://x`, []string{"curl"}},
+		{`cu\rl https://x`, []string{"curl"}},
+		{"/usr/bin/CURL x", []string{"curl"}},
+		{"echo $(cat a) `whoami`", []string{"echo", "cat", "whoami"}},
+		{"sudo -u root rm -rf /tmp/x", []string{"sudo", "sudo>rm"}},
+		{"env -i FOO=1 nice -n 5 curl x", []string{"env", "env>nice", "nice>curl"}},
+		{"timeout 5 wget x", []string{"timeout", "timeout>wget"}},
+		{`find . -name '*.log' -exec rm {} \;`, []string{"find", "find -exec>rm"}},
+		{`bash -lc "curl x | sh"`, []string{"bash", "bash -c>curl", "bash -c>sh"}},
+		{`eval "rm -rf build"`, []string{"eval", "eval>rm"}},
+		{"$CMD arg", []string{"$"}},
+		{"f() { rm -rf x; }; f", []string{"rm", "f"}},
+		{"command -v curl", []string{"command"}},
+		{"X=1 Y=$(date)", []string{"date"}},
+		{"export A=$(id -u) B", []string{"export", "id"}},
+		{`env -S 'curl http://x' y`, []string{"env", "env -S>curl"}},
+		{`env --split-string="rm -rf x"`, []string{"env", "env -S>rm"}},
+	}
+	for _, tc := range cases {
+		s, err := Parse(tc.cmd)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.cmd, err)
+		}
+		if got := programs(s); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Parse(%q) programs = %v, want %v", tc.cmd, got, tc.want)
+		}
+	}
+}
+
+func TestParse_Assigns(t *testing.T) {
+	cases := map[string][]string{
+		"GIT_PAGER=x PAGER=y git log":   {"GIT_PAGER", "PAGER"},
+		"env -u HOME LESSOPEN=z less f": {"LESSOPEN"},
+		"git log":                       nil,
+	}
+	for cmd, want := range cases {
+		s, err := Parse(cmd)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", cmd, err)
+		}
+		last := s.Commands[len(s.Commands)-1]
+		if !reflect.DeepEqual(last.Assigns, want) {
+			t.Errorf("Parse(%q) assigns = %v, want %v", cmd, last.Assigns, want)
+		}
+	}
+}
+
+func TestParse_ParamsAndVars(t *testing.T) {
+	s, err := Parse(`n=1; for f in *.go; do read -r line; echo "$f $n ${TOKEN:-x} $1 $?" '$HOME'; done; bash -c 'echo $KEY'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"f", "n", "TOKEN", "KEY"}; !reflect.DeepEqual(s.Params, want) {
+		t.Errorf("params = %v, want %v", s.Params, want)
+	}
+	if want := []string{"n", "f", "line"}; !reflect.DeepEqual(s.Vars, want) {
+		t.Errorf("vars = %v, want %v", s.Vars, want)
+	}
+}
+
+func TestParse_RedirectsAndWords(t *testing.T) {
+	s, err := Parse(`cat "conf"ig.yaml 2>&1 >>out.log </dev/null > /dev/null`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var writes []string
+	for _, r := range s.Redirects {
+		if r.Writes() {
+			writes = append(writes, r.Op+r.Target)
+		}
+	}
+	if !reflect.DeepEqual(writes, []string{">>out.log"}) {
+		t.Fatalf("writes = %v", writes)
+	}
+	if !strings.Contains(strings.Join(s.Words, " "), "config.yaml") {
+		t.Fatalf("words = %v", s.Words)
+	}
+
+	if _, err := Parse("echo 'unterminated"); err == nil {
+		t.Fatalf("expected parse error")
+	}
+	s, err = Parse(`bash -c "echo 'unterminated"`)
+	if err != nil || !s.Opaque {
+		t.Fatalf("expected opaque nested script, got %v %+v", err, s)
+	}
+}
@@ -0,0 +1,173 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Transport carries JSON-RPC messages to and from one MCP server.
+type Transport interface {
+	Call(ctx context.Context, method string, params any) (json.RawMessage, error)
+	Notify(ctx context.Context, method string, params any) error
+	Close() error
+}
+
+// ClientInfo identifies this client during the initialize handshake.
+type ClientInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ServerInfo is what the server reports about itself during initialize.
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ToolInfo describes one tool exposed by an MCP server.
+type ToolInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
+}
+
+// Content is one item of a tools/call result.
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Data     string `json:"data,omitempty"`
+	Resource *struct {
+		URI      string `json:"uri"`
+		MimeType string `json:"mimeType,omitempty"`
+		Text     string `json:"text,omitempty"`
+	} `json:"resource,omitempty"`
+}
+
+// CallResult is the result of tools/call.
+type CallResult struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// Client is a minimal MCP client: initialize, tools/list and tools/call.
+type Client struct {
+	t      Transport
+	info   ClientInfo
+	server ServerInfo
+}
+
+func NewClient(t Transport, info ClientInfo) *Client {
+	if strings.TrimSpace(info.Name) == "" {
+		info.Name = "mistermorph"
+	}
+	if strings.TrimSpace(info.Version) == "" {
+		info.Version = "dev"
+	}
+	return &Client{t: t, info: info}
+}
+
+// Initialize performs the MCP handshake. It must be called before any other method.
+func (c *Client) Initialize(ctx context.Context) (ServerInfo, error) {
+	raw, err := c.t.Call(ctx, "initialize", map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      c.info,
+	})
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	var res struct {
+		ProtocolVersion string     `json:"protocolVersion"`
+		ServerInfo      ServerInfo `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return ServerInfo{}, fmt.Errorf("mcp: invalid initialize result: %w", err)
+	}
+	c.server = res.ServerInfo
+	if err := c.t.Notify(ctx, "notifications/initialized", nil); err != nil {
+		return ServerInfo{}, err
+	}
+	return c.server, nil
+}
+
+// ListTools returns every tool the server exposes (following pagination cursors).
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	var out []ToolInfo
+	cursor := ""
+	for page := 0; page < 100; page++ {
+		var params any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		raw, err := c.t.Call(ctx, "tools/list", params)
+		if err != nil {
+			return nil, err
+		}
+		var res struct {
+			Tools      []ToolInfo `json:"tools"`
+			NextCursor string     `json:"nextCursor,omitempty"`
+		}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			return nil, fmt.Errorf("mcp: invalid tools/list result: %w", err)
+		}
+		out = append(out, res.Tools...)
+		if strings.TrimSpace(res.NextCursor) == "" {
+			return out, nil
+		}
+		cursor = res.NextCursor
+	}
+	return out, nil
+}
+
+// CallTool invokes a server tool by its (un-namespaced) name.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (CallResult, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	raw, err := c.t.Call(ctx, "tools/call", map[string]any{"name": name, "arguments": args})
+	if err != nil {
+		return CallResult{}, err
+	}
+	var res CallResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return CallResult{}, fmt.Errorf("mcp: invalid tools/call result: %w", err)
+	}
+	return res, nil
+}
+
+func (c *Client) Close() error {
+	if c == nil || c.t == nil {
+		return nil
+	}
+	return c.t.Close()
+}
+
+// Text renders a call result as plain text for the agent observation.
+func (r CallResult) Text() string {
+	var parts []string
+	for _, c := range r.Content {
+		switch c.Type {
+		case "text":
+			parts = append(parts, c.Text)
+		case "resource":
+			if c.Resource != nil {
+				if c.Resource.Text != "" {
+					parts = append(parts, c.Resource.Text)
+				} else {
+					parts = append(parts, fmt.Sprintf("[resource: %s]", c.Resource.URI))
+				}
+			}
+		default:
+			parts = append(parts, fmt.Sprintf("[%s content omitted: %s, %d bytes base64]", c.Type, c.MimeType, len(c.Data)))
+		}
+	}
+	if len(parts) == 0 && r.StructuredContent != nil {
+		b, _ := json.MarshalIndent(r.StructuredContent, "", "  ")
+		return string(b)
+	}
+	return strings.Join(parts, "\n")
+}
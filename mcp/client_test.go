@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// fakeServerReply answers MCP requests for an "echo" and a "fail" tool.
+func fakeServerReply(m rpcMessage) (any, bool) {
+	switch m.Method {
+	case "initialize":
+		return map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "fake", "version": "1.0"},
+		}, true
+	case "tools/list":
+		return map[string]any{"tools": []any{
+			map[string]any{
+				"name":        "echo",
+				"description": "Echo text back.",
+				"inputSchema": map[string]any{"type": "object", "properties": map[string]any{"text": map[string]any{"type": "string"}}},
+			},
+			map[string]any{"name": "fail"},
+		}}, true
+	case "tools/call":
+		var p struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		_ = json.Unmarshal(m.Params, &p)
+		if p.Name == "fail" {
+			return map[string]any{"isError": true, "content": []any{map[string]any{"type": "text", "text": "boom"}}}, true
+		}
+		return map[string]any{"content": []any{map[string]any{"type": "text", "text": fmt.Sprint(p.Arguments["text"])}}}, true
+	}
+	return nil, false
+}
+
+func runFakeStdioServer(t *testing.T, in io.Reader, out io.Writer) {
+	t.Helper()
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		var m rpcMessage
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil || len(m.ID) == 0 {
+			continue
+		}
+		res, ok := fakeServerReply(m)
+		resp := map[string]any{"jsonrpc": "2.0", "id": m.ID}
+		if ok {
+			resp["result"] = res
+		} else {
+			resp["error"] = map[string]any{"code": -32601, "message": "not found"}
+		}
+		b, _ := json.Marshal(resp)
+		_, _ = out.Write(append(b, '\n'))
+	}
+}
+
+func TestClient_Stdio(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	go runFakeStdioServer(t, serverR, serverW)
+
+	tr := newStdioTransport(clientR, clientW, func() error {
+		_ = clientW.Close()
+		return serverW.Close()
+	})
+	c := NewClient(tr, ClientInfo{})
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	si, err := c.Initialize(ctx)
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if si.Name != "fake" {
+		t.Fatalf("server name=%q", si.Name)
+	}
+	infos, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	ts := remoteTools(c, "demo", infos, ServerConfig{})
+	if len(ts) != 2 {
+		t.Fatalf("got %d tools", len(ts))
+	}
+	r := tools.NewRegistry()
+	for _, tool := range ts {
+		r.Register(tool)
+	}
+	echo, ok := r.Get("demo__echo")
+	if !ok {
+		t.Fatalf("demo__echo not registered; have %s", r.ToolNames())
+	}
+	if !strings.Contains(echo.ParameterSchema(), `"text"`) {
+		t.Fatalf("schema not passed through: %s", echo.ParameterSchema())
+	}
+	out, err := echo.Execute(ctx, map[string]any{"text": "hello"})
+	if err != nil || out != "hello" {
+		t.Fatalf("Execute=%q,%v", out, err)
+	}
+	fail, _ := r.Get("demo__fail")
+	out, err = fail.Execute(ctx, nil)
+	if err == nil || out != "boom" {
+		t.Fatalf("expected isError to surface as error, got %q,%v", out, err)
+	}
+}
+
+func TestClient_StreamableHTTP(t *testing.T) {
+	var sawSession, sawAuth bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer t0k" {
+			sawAuth = true
+		}
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		var m rpcMessage
+		_ = json.NewDecoder(r.Body).Decode(&m)
+		if len(m.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if m.Method != "initialize" && r.Header.Get("Mcp-Session-Id") == "s1" {
+			sawSession = true
+		}
+		res, _ := fakeServerReply(m)
+		b, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": m.ID, "result": res})
+		w.Header().Set("Mcp-Session-Id", "s1")
+		if m.Method == "tools/call" {
+			// Respond via SSE with an interleaved notification first.
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Setenv("MCP_TEST_TOKEN", "Bearer t0k")
+	conn, err := Connect(ctx, ServerConfig{
+		Name:           "remote",
+		URL:            srv.URL,
+		HeadersFromEnv: map[string]string{"Authorization": "MCP_TEST_TOKEN"},
+		Tools:          []string{"echo"},
+	}, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer conn.Close()
+
+	if len(conn.Tools) != 1 || conn.Tools[0].Name() != "remote__echo" {
+		t.Fatalf("unexpected tools: %d", len(conn.Tools))
+	}
+	out, err := conn.Tools[0].Execute(ctx, map[string]any{"text": "via sse"})
+	if err != nil || out != "via sse" {
+		t.Fatalf("Execute=%q,%v", out, err)
+	}
+	if !sawSession || !sawAuth {
+		t.Fatalf("session=%v auth=%v", sawSession, sawAuth)
+	}
+}
+
+func TestServerConfig_Validate(t *testing.T) {
+	if err := (ServerConfig{Name: "x"}).Validate(); err == nil {
+		t.Fatalf("expected error without command/url")
+	}
+	if err := (ServerConfig{Name: "x", Command: "a", URL: "http://b"}).Validate(); err == nil {
+		t.Fatalf("expected error with both command and url")
+	}
+	if err := (ServerConfig{Name: "x", Command: "a"}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
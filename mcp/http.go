@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// httpTransport implements the MCP "streamable HTTP" transport: every
+// client message is POSTed to a single endpoint, and the server answers
+// with either a JSON body or an SSE stream carrying the response.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	nextID atomic.Int64
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+// NewHTTPTransport returns a streamable HTTP transport for endpoint.
+// headers are sent on every request (e.g. Authorization).
+func NewHTTPTransport(endpoint string, headers map[string]string, client *http.Client) Transport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	h := make(map[string]string, len(headers))
+	for k, v := range headers {
+		h[k] = v
+	}
+	return &httpTransport{url: strings.TrimSpace(endpoint), headers: h, client: client}
+}
+
+func (t *httpTransport) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := t.nextID.Add(1)
+	resp, err := t.post(ctx, newRequest(id, method, params))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("mcp http: %s returned %d: %s", method, resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if sid := strings.TrimSpace(resp.Header.Get("Mcp-Session-Id")); sid != "" {
+		t.mu.Lock()
+		t.sessionID = sid
+		t.mu.Unlock()
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/event-stream":
+		return readSSEResponse(resp.Body, id)
+	default:
+		var m rpcMessage
+		if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+			return nil, fmt.Errorf("mcp http: invalid response: %w", err)
+		}
+		return resultOf(m)
+	}
+}
+
+func (t *httpTransport) Notify(ctx context.Context, method string, params any) error {
+	resp, err := t.post(ctx, newNotification(method, params))
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mcp http: notification %s returned %d", method, resp.StatusCode)
+	}
+	return nil
+}
+
+func (t *httpTransport) post(ctx context.Context, msg rpcRequest) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("MCP-Protocol-Version", ProtocolVersion)
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	t.mu.Unlock()
+	return t.client.Do(req)
+}
+
+// Close ends the server-side session (best-effort).
+func (t *httpTransport) Close() error {
+	t.mu.Lock()
+	sid := t.sessionID
+	t.mu.Unlock()
+	if sid == "" {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Mcp-Session-Id", sid)
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if resp, err := t.client.Do(req); err == nil {
+		_ = resp.Body.Close()
+	}
+	return nil
+}
+
+// readSSEResponse scans an SSE stream until it sees the JSON-RPC response for id.
+// Interleaved server notifications/requests are ignored.
+func readSSEResponse(r io.Reader, id int64) (json.RawMessage, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	flush := func() (json.RawMessage, bool, error) {
+		if data.Len() == 0 {
+			return nil, false, nil
+		}
+		payload := data.String()
+		data.Reset()
+		var m rpcMessage
+		if err := json.Unmarshal([]byte(payload), &m); err != nil {
+			return nil, false, nil
+		}
+		if !m.isResponse() {
+			return nil, false, nil
+		}
+		if got, ok := m.intID(); !ok || got != id {
+			return nil, false, nil
+		}
+		res, err := resultOf(m)
+		return res, true, err
+	}
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if res, ok, err := flush(); ok {
+				return res, err
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "data:") {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if res, ok, err := flush(); ok {
+		return res, err
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("mcp http: reading event stream: %w", err)
+	}
+	return nil, fmt.Errorf("mcp http: event stream ended without a response")
+}
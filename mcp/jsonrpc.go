@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the MCP revision this package speaks (streamable HTTP).
+const ProtocolVersion = "2025-03-26"
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      *int64 `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// rpcMessage is the union of request/response/notification as seen on the wire.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func (m rpcMessage) isResponse() bool {
+	return len(m.ID) > 0 && m.Method == "" && (m.Result != nil || m.Error != nil)
+}
+
+func (m rpcMessage) isRequest() bool {
+	return len(m.ID) > 0 && m.Method != ""
+}
+
+func (m rpcMessage) intID() (int64, bool) {
+	var id int64
+	if err := json.Unmarshal(m.ID, &id); err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// RPCError is a JSON-RPC error object returned by a server.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp rpc error %d: %s", e.Code, e.Message)
+}
+
+func newRequest(id int64, method string, params any) rpcRequest {
+	return rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params}
+}
+
+func newNotification(method string, params any) rpcRequest {
+	return rpcRequest{JSONRPC: "2.0", Method: method, Params: params}
+}
+
+func resultOf(m rpcMessage) (json.RawMessage, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Result, nil
+}
@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// ServerConfig configures one MCP server under `mcp.servers`.
+// Exactly one of Command (stdio) or URL (streamable HTTP) must be set.
+type ServerConfig struct {
+	Name    string `mapstructure:"name"`
+	Enabled *bool  `mapstructure:"enabled"`
+
+	// stdio
+	Command string            `mapstructure:"command"`
+	Args    []string          `mapstructure:"args"`
+	Env     map[string]string `mapstructure:"env"`
+	Dir     string            `mapstructure:"dir"`
+
+	// streamable HTTP
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"`
+	// HeadersFromEnv maps header names to env var names, so tokens stay out of config files.
+	HeadersFromEnv map[string]string `mapstructure:"headers_from_env"`
+
+	// Tools optionally restricts which remote tools get registered (remote names).
+	Tools []string `mapstructure:"tools"`
+
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout"`
+	Timeout        time.Duration `mapstructure:"timeout"`
+	MaxOutputBytes int           `mapstructure:"max_output_bytes"`
+}
+
+func (c ServerConfig) IsEnabled() bool { return c.Enabled == nil || *c.Enabled }
+
+func (c ServerConfig) Validate() error {
+	if sanitizeName(c.Name) == "" {
+		return fmt.Errorf("mcp server: missing name")
+	}
+	hasCmd := strings.TrimSpace(c.Command) != ""
+	hasURL := strings.TrimSpace(c.URL) != ""
+	if hasCmd == hasURL {
+		return fmt.Errorf("mcp server %q: set exactly one of command or url", c.Name)
+	}
+	return nil
+}
+
+// Connection is a live, initialized MCP server connection.
+type Connection struct {
+	Name   string
+	Server ServerInfo
+	Client *Client
+	Tools  []tools.Tool
+}
+
+func (c *Connection) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.Client.Close()
+}
+
+// Connect starts/dials the server, performs the handshake and lists its tools.
+func Connect(ctx context.Context, cfg ServerConfig, info ClientInfo) (*Connection, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	name := sanitizeName(cfg.Name)
+	if cfg.ConnectTimeout <= 0 {
+		cfg.ConnectTimeout = 30 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 60 * time.Second
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = 256 * 1024
+	}
+
+	var t Transport
+	if strings.TrimSpace(cfg.Command) != "" {
+		st, err := StartStdio(strings.TrimSpace(cfg.Command), cfg.Args, cfg.Env, cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		t = st
+	} else {
+		headers := make(map[string]string, len(cfg.Headers)+len(cfg.HeadersFromEnv))
+		for k, v := range cfg.Headers {
+			headers[k] = v
+		}
+		for k, env := range cfg.HeadersFromEnv {
+			v := strings.TrimSpace(os.Getenv(strings.TrimSpace(env)))
+			if v == "" {
+				return nil, fmt.Errorf("mcp server %q: env var %s for header %s is empty", cfg.Name, env, k)
+			}
+			headers[k] = v
+		}
+		t = NewHTTPTransport(cfg.URL, headers, &http.Client{})
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+
+	c := NewClient(t, info)
+	si, err := c.Initialize(cctx)
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("mcp server %q: initialize: %w", cfg.Name, err)
+	}
+	infos, err := c.ListTools(cctx)
+	if err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("mcp server %q: tools/list: %w", cfg.Name, err)
+	}
+	return &Connection{
+		Name:   name,
+		Server: si,
+		Client: c,
+		Tools:  remoteTools(c, name, infos, cfg),
+	}, nil
+}
+
+// Mount connects to every enabled server and registers its tools into r.
+// Servers that fail to connect are logged and skipped; a remote tool never
+// replaces an already-registered tool of the same name.
+func Mount(ctx context.Context, r *tools.Registry, servers []ServerConfig, info ClientInfo, log *slog.Logger) []*Connection {
+	if log == nil {
+		log = slog.Default()
+	}
+	var conns []*Connection
+	for _, cfg := range servers {
+		if !cfg.IsEnabled() {
+			continue
+		}
+		conn, err := Connect(ctx, cfg, info)
+		if err != nil {
+			log.Warn("mcp_server_error", "server", cfg.Name, "error", err.Error())
+			continue
+		}
+		registered := 0
+		for _, t := range conn.Tools {
+			if _, exists := r.Get(t.Name()); exists {
+				log.Warn("mcp_tool_name_conflict", "server", conn.Name, "tool", t.Name())
+				continue
+			}
+			r.Register(t)
+			registered++
+		}
+		log.Info("mcp_server_mounted",
+			"server", conn.Name,
+			"server_name", conn.Server.Name,
+			"server_version", conn.Server.Version,
+			"tools", registered,
+		)
+		conns = append(conns, conn)
+	}
+	return conns
+}
@@ -0,0 +1,213 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// CallFunc executes a tool on behalf of an MCP client. It lets the caller wrap
+// execution (guard checks, auditing, redaction) without the server knowing.
+type CallFunc func(ctx context.Context, t tools.Tool, params map[string]any) (string, error)
+
+// Server exposes a set of tools over MCP (newline-delimited JSON-RPC on stdio).
+type Server struct {
+	info  ServerInfo
+	tools []tools.Tool
+	byKey map[string]tools.Tool
+	call  CallFunc
+
+	writeMu sync.Mutex
+	w       io.Writer
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewServer returns a server exposing ts. If call is nil, tools are executed directly.
+func NewServer(info ServerInfo, ts []tools.Tool, call CallFunc) *Server {
+	if call == nil {
+		call = func(ctx context.Context, t tools.Tool, params map[string]any) (string, error) {
+			return t.Execute(ctx, params)
+		}
+	}
+	byKey := make(map[string]tools.Tool, len(ts))
+	for _, t := range ts {
+		byKey[t.Name()] = t
+	}
+	return &Server{info: info, tools: ts, byKey: byKey, call: call, inflight: make(map[string]context.CancelFunc)}
+}
+
+// Serve reads requests from r and writes responses to w until r is closed or ctx is done.
+// tools/call requests run concurrently; everything else is answered inline.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.w = w
+	defer s.wg.Wait()
+
+	lines := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for sc.Scan() {
+			line := append([]byte(nil), sc.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		errc <- sc.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.cancelAll()
+			return ctx.Err()
+		case err := <-errc:
+			s.cancelAll()
+			return err
+		case line := <-lines:
+			s.handleLine(ctx, line)
+		}
+	}
+}
+
+func (s *Server) handleLine(ctx context.Context, line []byte) {
+	if len(strings.TrimSpace(string(line))) == 0 {
+		return
+	}
+	var m rpcMessage
+	if err := json.Unmarshal(line, &m); err != nil {
+		s.reply(json.RawMessage("null"), nil, &RPCError{Code: -32700, Message: "parse error"})
+		return
+	}
+	if len(m.ID) == 0 {
+		s.handleNotification(m)
+		return
+	}
+	switch m.Method {
+	case "initialize":
+		s.reply(m.ID, map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+			"serverInfo":      s.info,
+		}, nil)
+	case "ping":
+		s.reply(m.ID, map[string]any{}, nil)
+	case "tools/list":
+		s.reply(m.ID, map[string]any{"tools": s.listTools()}, nil)
+	case "tools/call":
+		callCtx, cancel := context.WithCancel(ctx)
+		key := string(m.ID)
+		s.mu.Lock()
+		s.inflight[key] = cancel
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.inflight, key)
+				s.mu.Unlock()
+				cancel()
+			}()
+			res, rpcErr := s.callTool(callCtx, m.Params)
+			s.reply(m.ID, res, rpcErr)
+		}()
+	default:
+		s.reply(m.ID, nil, &RPCError{Code: -32601, Message: "method not found: " + m.Method})
+	}
+}
+
+func (s *Server) handleNotification(m rpcMessage) {
+	if m.Method != "notifications/cancelled" {
+		return
+	}
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if err := json.Unmarshal(m.Params, &p); err != nil {
+		return
+	}
+	s.mu.Lock()
+	cancel := s.inflight[string(p.RequestID)]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (s *Server) listTools() []map[string]any {
+	out := make([]map[string]any, 0, len(s.tools))
+	for _, t := range s.tools {
+		var schema any
+		if err := json.Unmarshal([]byte(t.ParameterSchema()), &schema); err != nil || schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		out = append(out, map[string]any{
+			"name":        t.Name(),
+			"description": t.Description(),
+			"inputSchema": schema,
+		})
+	}
+	return out
+}
+
+func (s *Server) callTool(ctx context.Context, raw json.RawMessage) (any, *RPCError) {
+	var p struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, &RPCError{Code: -32602, Message: "invalid params"}
+	}
+	t, ok := s.byKey[p.Name]
+	if !ok {
+		return nil, &RPCError{Code: -32602, Message: "unknown tool: " + p.Name}
+	}
+	if p.Arguments == nil {
+		p.Arguments = map[string]any{}
+	}
+	out, err := s.call(ctx, t, p.Arguments)
+	if err != nil {
+		// Tool failures are reported in-band so the calling model can see them.
+		text := err.Error()
+		if strings.TrimSpace(out) != "" {
+			text = out + "\n\nerror: " + err.Error()
+		}
+		return CallResult{IsError: true, Content: []Content{{Type: "text", Text: text}}}, nil
+	}
+	return CallResult{Content: []Content{{Type: "text", Text: out}}}, nil
+}
+
+func (s *Server) reply(id json.RawMessage, result any, rpcErr *RPCError) {
+	msg := map[string]any{"jsonrpc": "2.0", "id": id}
+	if rpcErr != nil {
+		msg["error"] = rpcErr
+	} else {
+		msg["result"] = result
+	}
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, _ = s.w.Write(append(b, '\n'))
+}
+
+func (s *Server) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cancel := range s.inflight {
+		cancel()
+	}
+}
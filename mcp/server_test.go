@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+type upperParams struct {
+	Text string `json:"text"`
+}
+
+func TestServer_RoundTripWithClient(t *testing.T) {
+	upper := tools.NewTyped("upper", "Uppercases text.", func(_ context.Context, p upperParams) (string, error) {
+		return strings.ToUpper(p.Text), nil
+	})
+	var calls []string
+	srv := NewServer(ServerInfo{Name: "test", Version: "0"}, []tools.Tool{upper}, func(ctx context.Context, tool tools.Tool, params map[string]any) (string, error) {
+		calls = append(calls, tool.Name())
+		if params["text"] == "deny" {
+			return "", fmt.Errorf("blocked by guard")
+		}
+		return tool.Execute(ctx, params)
+	})
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, serverR, serverW) }()
+
+	c := NewClient(newStdioTransport(clientR, clientW, clientW.Close), ClientInfo{})
+	si, err := c.Initialize(ctx)
+	if err != nil || si.Name != "test" {
+		t.Fatalf("Initialize=%+v,%v", si, err)
+	}
+	infos, err := c.ListTools(ctx)
+	if err != nil || len(infos) != 1 || infos[0].Name != "upper" {
+		t.Fatalf("ListTools=%+v,%v", infos, err)
+	}
+	res, err := c.CallTool(ctx, "upper", map[string]any{"text": "abc"})
+	if err != nil || res.IsError || res.Text() != "ABC" {
+		t.Fatalf("CallTool=%+v,%v", res, err)
+	}
+	res, err = c.CallTool(ctx, "upper", map[string]any{"text": "deny"})
+	if err != nil || !res.IsError || !strings.Contains(res.Text(), "blocked by guard") {
+		t.Fatalf("expected in-band error, got %+v,%v", res, err)
+	}
+	if _, err := c.CallTool(ctx, "missing", nil); err == nil {
+		t.Fatalf("expected rpc error for unknown tool")
+	}
+	if len(calls) != 2 {
+		t.Fatalf("call hook invoked %d times, want 2", len(calls))
+	}
+
+	_ = clientW.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+}
+
+func TestServer_MethodNotFound(t *testing.T) {
+	srv := NewServer(ServerInfo{Name: "test"}, nil, nil)
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"resources/list"}` + "\n")
+	var out strings.Builder
+	if err := srv.Serve(context.Background(), in, &out); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	var m rpcMessage
+	if err := json.NewDecoder(bufio.NewReader(strings.NewReader(out.String()))).Decode(&m); err != nil {
+		t.Fatalf("decode: %v (%q)", err, out.String())
+	}
+	if m.Error == nil || m.Error.Code != -32601 {
+		t.Fatalf("expected method not found, got %+v", m)
+	}
+}
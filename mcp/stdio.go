@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+// stdioTransport speaks newline-delimited JSON-RPC over a pair of streams,
+// typically the stdin/stdout of a child process.
+type stdioTransport struct {
+	w      io.Writer
+	closer func() error
+
+	writeMu sync.Mutex
+	nextID  atomic.Int64
+
+	mu      sync.Mutex
+	pending map[int64]chan rpcMessage
+	err     error
+	done    chan struct{}
+}
+
+// StartStdio launches an MCP server process and returns a transport bound to its stdio.
+// The process inherits the current environment plus env.
+func StartStdio(command string, args []string, env map[string]string, dir string) (Transport, error) {
+	if command == "" {
+		return nil, fmt.Errorf("mcp stdio: missing command")
+	}
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	// Server logs go to our stderr; stdout is reserved for protocol messages.
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp stdio: start %s: %w", command, err)
+	}
+	closer := func() error {
+		_ = stdin.Close()
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+		_ = cmd.Wait()
+		return nil
+	}
+	return newStdioTransport(stdout, stdin, closer), nil
+}
+
+func newStdioTransport(r io.Reader, w io.Writer, closer func() error) *stdioTransport {
+	t := &stdioTransport{
+		w:       w,
+		closer:  closer,
+		pending: make(map[int64]chan rpcMessage),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(r)
+	return t
+}
+
+func (t *stdioTransport) readLoop(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var m rpcMessage
+		if err := json.Unmarshal(line, &m); err != nil {
+			continue
+		}
+		switch {
+		case m.isResponse():
+			id, ok := m.intID()
+			if !ok {
+				continue
+			}
+			t.mu.Lock()
+			ch := t.pending[id]
+			delete(t.pending, id)
+			t.mu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		case m.isRequest():
+			t.replyToServerRequest(m)
+		}
+	}
+	err := sc.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	t.mu.Lock()
+	t.err = fmt.Errorf("mcp stdio: connection closed: %w", err)
+	t.mu.Unlock()
+	close(t.done)
+}
+
+// replyToServerRequest answers server-initiated requests. Only ping is supported;
+// everything else (sampling, roots, elicitation) is declined.
+func (t *stdioTransport) replyToServerRequest(m rpcMessage) {
+	resp := map[string]any{"jsonrpc": "2.0", "id": m.ID}
+	if m.Method == "ping" {
+		resp["result"] = map[string]any{}
+	} else {
+		resp["error"] = RPCError{Code: -32601, Message: "method not supported by client"}
+	}
+	_ = t.write(resp)
+}
+
+func (t *stdioTransport) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.w.Write(b)
+	return err
+}
+
+func (t *stdioTransport) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id := t.nextID.Add(1)
+	ch := make(chan rpcMessage, 1)
+	t.mu.Lock()
+	if t.err != nil {
+		err := t.err
+		t.mu.Unlock()
+		return nil, err
+	}
+	t.pending[id] = ch
+	t.mu.Unlock()
+
+	if err := t.write(newRequest(id, method, params)); err != nil {
+		t.forget(id)
+		return nil, err
+	}
+	select {
+	case m := <-ch:
+		return resultOf(m)
+	case <-t.done:
+		t.forget(id)
+		t.mu.Lock()
+		err := t.err
+		t.mu.Unlock()
+		return nil, err
+	case <-ctx.Done():
+		t.forget(id)
+		_ = t.write(newNotification("notifications/cancelled", map[string]any{"requestId": id, "reason": ctx.Err().Error()}))
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) Notify(_ context.Context, method string, params any) error {
+	return t.write(newNotification(method, params))
+}
+
+func (t *stdioTransport) forget(id int64) {
+	t.mu.Lock()
+	delete(t.pending, id)
+	t.mu.Unlock()
+}
+
+func (t *stdioTransport) Close() error {
+	if t.closer != nil {
+		return t.closer()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/internal/strutil"
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// RemoteTool adapts one MCP server tool to tools.Tool. It is registered under
+// a namespaced name ("<server>__<tool>") so remote tools cannot shadow builtins
+// and the guard/audit pipeline can tell them apart.
+type RemoteTool struct {
+	client   *Client
+	server   string
+	remote   string
+	name     string
+	desc     string
+	schema   string
+	timeout  time.Duration
+	maxBytes int
+}
+
+func (t *RemoteTool) Name() string            { return t.name }
+func (t *RemoteTool) Description() string     { return t.desc }
+func (t *RemoteTool) ParameterSchema() string { return t.schema }
+
+// Server returns the configured server name this tool belongs to.
+func (t *RemoteTool) Server() string { return t.server }
+
+// RemoteName returns the tool name as known to the MCP server.
+func (t *RemoteTool) RemoteName() string { return t.remote }
+
+func (t *RemoteTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	res, err := t.client.CallTool(ctx, t.remote, params)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("mcp tool %s timed out after %s", t.name, t.timeout)
+		}
+		return "", err
+	}
+	out := res.Text()
+	if t.maxBytes > 0 && len(out) > t.maxBytes {
+		out = strutil.TruncateUTF8(out, t.maxBytes) + "\n...(truncated)"
+	}
+	if res.IsError {
+		return out, fmt.Errorf("mcp tool %s returned an error", t.name)
+	}
+	return out, nil
+}
+
+// NamespacedName builds the registry name for a remote tool.
+func NamespacedName(server, tool string) string {
+	return sanitizeName(server) + "__" + sanitizeName(tool)
+}
+
+func sanitizeName(s string) string {
+	s = strings.TrimSpace(s)
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func remoteTools(c *Client, server string, infos []ToolInfo, cfg ServerConfig) []tools.Tool {
+	out := make([]tools.Tool, 0, len(infos))
+	allowed := make(map[string]bool, len(cfg.Tools))
+	for _, name := range cfg.Tools {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+	for _, info := range infos {
+		if strings.TrimSpace(info.Name) == "" {
+			continue
+		}
+		if len(allowed) > 0 && !allowed[info.Name] {
+			continue
+		}
+		schema := `{"type":"object","properties":{}}`
+		if len(info.InputSchema) > 0 {
+			var v any
+			if err := json.Unmarshal(info.InputSchema, &v); err == nil {
+				b, _ := json.MarshalIndent(v, "", "  ")
+				schema = string(b)
+			}
+		}
+		desc := strings.TrimSpace(info.Description)
+		if desc == "" {
+			desc = "MCP tool " + info.Name
+		}
+		out = append(out, &RemoteTool{
+			client:   c,
+			server:   server,
+			remote:   info.Name,
+			name:     NamespacedName(server, info.Name),
+			desc:     fmt.Sprintf("[mcp:%s] %s", server, desc),
+			schema:   schema,
+			timeout:  cfg.Timeout,
+			maxBytes: cfg.MaxOutputBytes,
+		})
+	}
+	return out
+}
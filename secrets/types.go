@@ -19,6 +19,8 @@ type Config struct {
 type Credential struct {
 	Kind      string `mapstructure:"kind"`
 	SecretRef string `mapstructure:"secret_ref"`
+	// Username is sent alongside the secret by credentials that need one (SMTP AUTH).
+	Username string `mapstructure:"username"`
 }
 
 type Allow struct {
@@ -33,6 +35,7 @@ type Allow struct {
 	URLPrefixes []string `mapstructure:"url_prefixes"`
 
 	// Methods is the allowed HTTP method set (GET/POST/PUT/DELETE).
+	// It is not used (and may be empty) for smtp:// and smtps:// prefixes.
 	Methods []string `mapstructure:"methods"`
 
 	FollowRedirects bool  `mapstructure:"follow_redirects"`
@@ -85,16 +88,20 @@ func (p *AuthProfile) Validate() error {
 	if len(p.Allow.URLPrefixes) == 0 {
 		return fmt.Errorf("auth_profiles.%s.allow.url_prefixes is required (fail-closed)", p.ID)
 	}
-	if len(p.Allow.Methods) == 0 {
-		return fmt.Errorf("auth_profiles.%s.allow.methods is required (fail-closed)", p.ID)
-	}
-
 	rules, err := parseURLPrefixRules(p.Allow.URLPrefixes, p.ID)
 	if err != nil {
 		return err
 	}
 	p.Allow.ParsedURLPrefixes = rules
 
+	if len(p.Allow.Methods) == 0 {
+		for _, r := range rules {
+			if isHTTPScheme(r.Scheme) {
+				return fmt.Errorf("auth_profiles.%s.allow.methods is required (fail-closed)", p.ID)
+			}
+		}
+	}
+
 	for _, m := range p.Allow.Methods {
 		m = strings.ToUpper(strings.TrimSpace(m))
 		if m == "" {
@@ -107,15 +114,11 @@ func (p *AuthProfile) Validate() error {
 		}
 	}
 
-	b, ok := p.Bindings["url_fetch"]
-	if !ok {
-		return fmt.Errorf("auth_profiles.%s.bindings.url_fetch is required", p.ID)
-	}
-	if err := b.Validate("url_fetch"); err != nil {
-		return fmt.Errorf("auth_profiles.%s.bindings.url_fetch: %w", p.ID, err)
+	// At least one tool binding (url_fetch, web_search, ...) is required; each
+	// tool looks up its own binding by name.
+	if len(p.Bindings) == 0 {
+		return fmt.Errorf("auth_profiles.%s.bindings is required (e.g. bindings.url_fetch)", p.ID)
 	}
-
-	// Optional: validate other bindings if provided.
 	for toolName, binding := range p.Bindings {
 		if strings.TrimSpace(toolName) == "" {
 			continue
@@ -138,6 +141,14 @@ func (b ToolBinding) Validate(toolName string) error {
 	switch loc {
 	case "header":
 		// MVP supported.
+	case "smtp":
+		// SMTP AUTH with credential.username and the secret as password.
+		switch strings.ToLower(strings.TrimSpace(b.Inject.Format)) {
+		case "", "plain", "cram-md5":
+			return nil
+		default:
+			return fmt.Errorf("unsupported inject.format for smtp: %q (use plain or cram-md5)", b.Inject.Format)
+		}
 	default:
 		return fmt.Errorf("unsupported inject.location for %s: %q", toolName, b.Inject.Location)
 	}
@@ -185,7 +196,7 @@ func (p AuthProfile) IsURLAllowed(u *url.URL, method string) error {
 	}
 
 	m := strings.ToUpper(strings.TrimSpace(method))
-	if !stringInSliceFold(m, p.Allow.Methods) {
+	if isHTTPScheme(scheme) && !stringInSliceFold(m, p.Allow.Methods) {
 		return fmt.Errorf("method %q not allowed by auth_profile %q", m, p.ID)
 	}
 
@@ -260,11 +271,19 @@ func effectivePort(u *url.URL) int {
 		return 80
 	case "https":
 		return 443
+	case "smtp":
+		return 587
+	case "smtps":
+		return 465
 	default:
 		return 0
 	}
 }
 
+func isHTTPScheme(scheme string) bool {
+	return scheme == "http" || scheme == "https"
+}
+
 func isPrivateIP(ip net.IP) bool {
 	if ip == nil {
 		return true
@@ -321,7 +340,7 @@ func parseURLPrefixRules(prefixes []string, profileID string) ([]URLPrefixRule,
 		}
 		scheme := strings.ToLower(strings.TrimSpace(u.Scheme))
 		switch scheme {
-		case "http", "https":
+		case "http", "https", "smtp", "smtps":
 		default:
 			return nil, fmt.Errorf("auth_profiles.%s.allow.url_prefixes contains unsupported scheme: %q", profileID, raw)
 		}
@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"path"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/quailyquaily/mistermorph/internal/shellparse"
+	"github.com/quailyquaily/mistermorph/tools"
 )
 
 type BashTool struct {
@@ -18,6 +22,9 @@ type BashTool struct {
 	MaxOutputBytes int
 	DenyPaths      []string
 	DenyTokens     []string
+	Sandbox        *BashSandbox
+	// Sessions, when set, runs commands from the same agent run in one persistent shell.
+	Sessions *BashSessions
 }
 
 func NewBashTool(enabled bool, confirmEachRun bool, defaultTimeout time.Duration, maxOutputBytes int) *BashTool {
@@ -38,6 +45,9 @@ func NewBashTool(enabled bool, confirmEachRun bool, defaultTimeout time.Duration
 func (t *BashTool) Name() string { return "bash" }
 
 func (t *BashTool) Description() string {
+	if t.Sessions != nil {
+		return "Runs a bash command in the local environment and returns stdout/stderr. Commands in the same run share one persistent shell, so cd, exported variables and activated virtualenvs carry over between calls. Disabled by default for safety."
+	}
 	return "Runs a bash command in the local environment and returns stdout/stderr. Disabled by default for safety."
 }
 
@@ -60,6 +70,17 @@ func (t *BashTool) ParameterSchema() string {
 		},
 		"required": []string{"cmd"},
 	}
+	if t.Sessions != nil {
+		props := s["properties"].(map[string]any)
+		props["cwd"] = map[string]any{
+			"type":        "string",
+			"description": "Optional working directory. In session mode this changes the session's directory for later commands.",
+		}
+		props["reset_session"] = map[string]any{
+			"type":        "boolean",
+			"description": "If true, restarts the persistent shell before running cmd (clears cwd and env changes).",
+		}
+	}
 	b, _ := json.MarshalIndent(s, "", "  ")
 	return string(b)
 }
@@ -105,9 +126,25 @@ func (t *BashTool) Execute(ctx context.Context, params map[string]any) (string,
 	runCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	cmd := exec.CommandContext(runCtx, "bash", "-lc", cmdStr)
-	if cwd != "" {
-		cmd.Dir = cwd
+	if t.Sessions != nil {
+		if runID, ok := tools.RunIDFromContext(ctx); ok {
+			reset, _ := params["reset_session"].(bool)
+			return t.executeInSession(runCtx, runID, cmdStr, cwd, reset, timeout)
+		}
+	}
+
+	var cmd *exec.Cmd
+	if t.Sandbox != nil && t.Sandbox.Enabled {
+		sc, err := t.Sandbox.command(runCtx, cmdStr, cwd)
+		if err != nil {
+			return "", err
+		}
+		cmd = sc
+	} else {
+		cmd = exec.CommandContext(runCtx, "bash", "-lc", cmdStr)
+		if cwd != "" {
+			cmd.Dir = cwd
+		}
 	}
 
 	var stdout limitedBuffer
@@ -130,6 +167,68 @@ func (t *BashTool) Execute(ctx context.Context, params map[string]any) (string,
 		}
 	}
 
+	out := formatBashOutput(exitCode, &stdout, &stderr)
+	if exitCode != 0 {
+		return out, fmt.Errorf("bash exited with code %d", exitCode)
+	}
+	return out, nil
+}
+
+func (t *BashTool) executeInSession(ctx context.Context, runID, cmdStr, cwd string, reset bool, timeout time.Duration) (string, error) {
+	if reset {
+		t.Sessions.Close(runID)
+	}
+	sess, err := t.Sessions.get(runID, t.sessionShell, timeout)
+	if err != nil {
+		return "", err
+	}
+	defer t.Sessions.touch(sess)
+
+	script := cmdStr
+	if cwd != "" {
+		script = "cd -- " + shellQuote(cwd) + " && eval " + shellQuote(cmdStr)
+	}
+
+	var stdout limitedBuffer
+	var stderr limitedBuffer
+	stdout.Limit = t.MaxOutputBytes
+	stderr.Limit = t.MaxOutputBytes
+	exitCode, exited, err := sess.run(ctx, script, &stdout, &stderr)
+	if err != nil {
+		// The command is still running inside the shell; the only safe recovery is a fresh session.
+		t.Sessions.Close(runID)
+		if ctx.Err() == context.DeadlineExceeded {
+			return formatBashOutput(-1, &stdout, &stderr), fmt.Errorf("bash timed out after %s (session was reset)", timeout)
+		}
+		return "", err
+	}
+	out := formatBashOutput(exitCode, &stdout, &stderr)
+	if exited {
+		t.Sessions.Close(runID)
+		return out, fmt.Errorf("bash session exited with code %d (a new session starts on the next call)", exitCode)
+	}
+	if exitCode != 0 {
+		return out, fmt.Errorf("bash exited with code %d", exitCode)
+	}
+	return out, nil
+}
+
+// sessionShell builds the long-lived shell process for a session.
+func (t *BashTool) sessionShell() (*exec.Cmd, error) {
+	if t.Sandbox != nil && t.Sandbox.Enabled {
+		return t.Sandbox.command(context.Background(), "exec bash -s", "")
+	}
+	return exec.Command("bash", "--login", "-s"), nil
+}
+
+// FinishRun closes the persistent shell of a completed run.
+func (t *BashTool) FinishRun(runID string) {
+	if t.Sessions != nil {
+		t.Sessions.Close(runID)
+	}
+}
+
+func formatBashOutput(exitCode int, stdout, stderr *limitedBuffer) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "exit_code: %d\n", exitCode)
 	fmt.Fprintf(&b, "stdout_truncated: %t\n", stdout.Truncated)
@@ -138,43 +237,58 @@ func (t *BashTool) Execute(ctx context.Context, params map[string]any) (string,
 	b.WriteString(string(bytes.ToValidUTF8(stdout.Bytes(), []byte("\n[non-utf8 output]\n"))))
 	b.WriteString("\n\nstderr:\n")
 	b.WriteString(string(bytes.ToValidUTF8(stderr.Bytes(), []byte("\n[non-utf8 output]\n"))))
-
-	if exitCode != 0 {
-		return b.String(), fmt.Errorf("bash exited with code %d", exitCode)
-	}
-	return b.String(), nil
+	return b.String()
 }
 
+// bashCommandDenied reports the first deny path referenced by cmdStr. The raw
+// command is matched first; it is also parsed so quoting, escapes and
+// substitutions cannot hide a path: every argument, redirect target and
+// program name is checked, and globs are matched against the denied base name.
 func bashCommandDenied(cmdStr string, denyPaths []string) (string, bool) {
 	cmdStr = strings.TrimSpace(cmdStr)
 	if cmdStr == "" || len(denyPaths) == 0 {
 		return "", false
 	}
+	words := []string{cmdStr}
+	if script, err := shellparse.Parse(cmdStr); err == nil {
+		words = append(words, script.Words...)
+		for _, c := range script.Commands {
+			words = append(words, c.Name)
+		}
+	}
 	for _, p := range denyPaths {
 		p = strings.TrimSpace(p)
 		if p == "" {
 			continue
 		}
-		if containsTokenBoundary(cmdStr, p) {
-			return p, true
-		}
 		// Most configs will specify basenames (e.g. config.yaml). For safety,
 		// also deny the basename even if a path is provided.
+		candidates := []string{p}
 		if i := strings.LastIndex(p, "/"); i != -1 && i+1 < len(p) {
-			base := p[i+1:]
-			if base != "" && containsTokenBoundary(cmdStr, base) {
-				return base, true
+			candidates = append(candidates, p[i+1:])
+		}
+		for _, cand := range candidates {
+			for _, w := range words {
+				if containsTokenBoundary(w, cand) || globMatchesBase(w, cand) {
+					return cand, true
+				}
 			}
 		}
 	}
 	return "", false
 }
 
+// bashCommandDeniedTokens reports the first deny token in cmdStr. Any
+// occurrence of the token in the raw command is denied (so it also catches
+// python -c "...curl...", awk system() and git -c core.pager=curl); the
+// command is parsed as well, so programs spelled with quotes or escapes
+// (c"ur"l, cu\rl) or run through wrappers and bash -c are denied too.
 func bashCommandDeniedTokens(cmdStr string, denyTokens []string) (string, bool) {
 	cmdStr = strings.TrimSpace(cmdStr)
 	if cmdStr == "" || len(denyTokens) == 0 {
 		return "", false
 	}
+	script, err := shellparse.Parse(cmdStr)
 	for _, tok := range denyTokens {
 		tok = strings.TrimSpace(tok)
 		if tok == "" {
@@ -183,10 +297,33 @@ func bashCommandDeniedTokens(cmdStr string, denyTokens []string) (string, bool)
 		if containsTokenBoundaryFold(cmdStr, tok) {
 			return tok, true
 		}
+		if err != nil {
+			continue
+		}
+		for _, c := range script.Commands {
+			if !c.Dynamic && c.Program() == strings.ToLower(tok) {
+				return tok, true
+			}
+			for _, a := range c.Args {
+				if containsTokenBoundaryFold(a, tok) {
+					return tok, true
+				}
+			}
+		}
 	}
 	return "", false
 }
 
+// globMatchesBase reports whether a glob word (e.g. ./conf*.yaml) would
+// match the file name name.
+func globMatchesBase(word, name string) bool {
+	if !strings.ContainsAny(word, "*?[") {
+		return false
+	}
+	ok, _ := path.Match(path.Base(word), path.Base(name))
+	return ok
+}
+
 func containsTokenBoundary(haystack, needle string) bool {
 	if needle == "" {
 		return false
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package builtin
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup puts cmd in its own process group so background jobs can be killed with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	_ = cmd.Process.Kill()
+}
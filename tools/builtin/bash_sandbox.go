@@ -0,0 +1,136 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// BashSandbox configures isolated execution for BashTool (Linux only).
+//
+// Commands run inside fresh user + mount + PID + IPC + UTS (+ network, unless
+// AllowNetwork) namespaces with a reduced environment. Every mount is
+// remounted read-only except Workspace; /proc is a fresh procfs for the new
+// PID namespace and /dev/shm and /dev/mqueue are private tmpfs mounts.
+// CPU/memory/process rlimits are applied via ulimit before the user command
+// starts. Setup fails closed: if any step fails the command does not run. No
+// root privileges are needed on the host, but the kernel must allow
+// unprivileged user namespaces.
+type BashSandbox struct {
+	Enabled      bool
+	Workspace    string
+	AllowNetwork bool
+	CPUSeconds   int
+	MemoryMB     int
+	MaxProcesses int
+}
+
+// sandboxPrelude runs as root-in-userns before exec'ing the user command.
+// The user command arrives as $1 so it is never re-quoted.
+//
+// Remounts keep each mount's existing flags (nosuid, nodev, noexec, atime):
+// the kernel refuses to clear flags that are locked in a user namespace.
+// Mount points that don't exist or can't be reached are skipped; any other
+// failure aborts.
+const sandboxPrelude = `set -e
+fail() { echo "mistermorph-sandbox: $*" >&2; exit 125; }
+mount --make-rprivate / || fail "cannot make mounts private"
+mount --bind "$MM_SANDBOX_WS" "$MM_SANDBOX_WS" || fail "cannot bind workspace"
+while read -r _ _ _ _ mp opts _; do
+  mp=$(printf '%b' "$mp")
+  case "$mp" in
+    "$MM_SANDBOX_WS"|/proc|/proc/*) continue ;;
+  esac
+  [ -e "$mp" ] || continue
+  opts=${opts#rw}; opts=${opts#ro}
+  mount -o "remount,bind,ro$opts" "$mp" 2>/dev/null || fail "cannot make $mp read-only"
+done < <(tac /proc/self/mountinfo)
+mount -t proc -o nosuid,nodev,noexec proc /proc || fail "cannot mount /proc"
+for d in /dev/shm /dev/mqueue; do
+  [ -d "$d" ] || continue
+  mount -t tmpfs -o nosuid,nodev,noexec,mode=1777,size=64m tmpfs "$d" || fail "cannot mount $d"
+done
+[ -n "$MM_SANDBOX_CPU" ] && ulimit -t "$MM_SANDBOX_CPU"
+[ -n "$MM_SANDBOX_MEM_KB" ] && ulimit -v "$MM_SANDBOX_MEM_KB"
+[ -n "$MM_SANDBOX_NPROC" ] && ulimit -u "$MM_SANDBOX_NPROC"
+set +e
+cd "$MM_SANDBOX_CWD"
+unset MM_SANDBOX_WS MM_SANDBOX_CPU MM_SANDBOX_MEM_KB MM_SANDBOX_NPROC MM_SANDBOX_CWD
+exec bash -lc "$1"
+`
+
+func (s *BashSandbox) workspaceAbs() (string, error) {
+	ws := strings.TrimSpace(expandHomePath(s.Workspace))
+	if ws == "" {
+		return "", fmt.Errorf("bash sandbox: workspace is not configured")
+	}
+	abs, err := filepath.Abs(ws)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(abs, 0o700); err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// sandboxEnvKeys are passed through to sandboxed commands; everything else
+// (LLM API keys, tokens, proxy settings) is dropped.
+var sandboxEnvKeys = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "LC_ALL", "LC_CTYPE", "TZ"}
+
+func sandboxEnv() []string {
+	var env []string
+	for _, k := range sandboxEnvKeys {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
+
+// command builds the sandboxed exec.Cmd for cmdStr. cwd defaults to the workspace.
+func (s *BashSandbox) command(ctx context.Context, cmdStr string, cwd string) (*exec.Cmd, error) {
+	return s.commandEnv(ctx, cmdStr, cwd, sandboxEnv())
+}
+
+// commandEnv is command with an explicit base environment for the user command.
+func (s *BashSandbox) commandEnv(ctx context.Context, cmdStr string, cwd string, baseEnv []string) (*exec.Cmd, error) {
+	ws, err := s.workspaceAbs()
+	if err != nil {
+		return nil, err
+	}
+	if cwd == "" {
+		cwd = ws
+	}
+	cwd, err = filepath.Abs(expandHomePath(cwd))
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "bash", "-c", sandboxPrelude, "mistermorph-sandbox", cmdStr)
+	env := append([]string(nil), baseEnv...)
+	env = append(env,
+		"MM_SANDBOX_WS="+ws,
+		"MM_SANDBOX_CWD="+cwd,
+		"TMPDIR="+filepath.Join(ws, ".tmp"),
+	)
+	if s.CPUSeconds > 0 {
+		env = append(env, fmt.Sprintf("MM_SANDBOX_CPU=%d", s.CPUSeconds))
+	}
+	if s.MemoryMB > 0 {
+		env = append(env, fmt.Sprintf("MM_SANDBOX_MEM_KB=%d", s.MemoryMB*1024))
+	}
+	if s.MaxProcesses > 0 {
+		env = append(env, fmt.Sprintf("MM_SANDBOX_NPROC=%d", s.MaxProcesses))
+	}
+	cmd.Env = env
+	_ = os.MkdirAll(filepath.Join(ws, ".tmp"), 0o700)
+
+	if err := applySandboxAttrs(cmd, s.AllowNetwork); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
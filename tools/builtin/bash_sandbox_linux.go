@@ -0,0 +1,26 @@
+//go:build linux
+
+package builtin
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func applySandboxAttrs(cmd *exec.Cmd, allowNetwork bool) error {
+	flags := uintptr(syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS)
+	if !allowNetwork {
+		flags |= syscall.CLONE_NEWNET
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: flags,
+		// Map the invoking user to root inside the namespace so the prelude can
+		// set up mounts; it has no privileges outside the namespace.
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+	return nil
+}
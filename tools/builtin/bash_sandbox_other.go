@@ -0,0 +1,12 @@
+//go:build !linux
+
+package builtin
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func applySandboxAttrs(_ *exec.Cmd, _ bool) error {
+	return fmt.Errorf("bash sandbox requires Linux namespaces")
+}
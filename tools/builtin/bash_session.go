@@ -0,0 +1,383 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BashSessions keeps one long-lived shell per agent run so `cd`, exported
+// variables and activated virtualenvs survive between bash calls.
+//
+// Commands in a session run sequentially; each is followed by a sentinel line
+// on stdout and stderr that delimits its output and carries the exit code.
+// Sessions are closed when the run finishes (tools.RunFinisher), after
+// IdleTimeout without use, or when a command times out.
+type BashSessions struct {
+	IdleTimeout time.Duration
+	MaxSessions int
+
+	mu       sync.Mutex
+	sessions map[string]*bashSession
+}
+
+func NewBashSessions(idleTimeout time.Duration, maxSessions int) *BashSessions {
+	if idleTimeout <= 0 {
+		idleTimeout = 10 * time.Minute
+	}
+	if maxSessions <= 0 {
+		maxSessions = 16
+	}
+	return &BashSessions{
+		IdleTimeout: idleTimeout,
+		MaxSessions: maxSessions,
+		sessions:    make(map[string]*bashSession),
+	}
+}
+
+type bashSession struct {
+	runID  string
+	token  string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout chan []byte
+	stderr chan []byte
+	done   chan struct{}
+
+	// Bytes read past the previous sentinel (e.g. from background jobs).
+	outRest []byte
+	errRest []byte
+
+	mu       sync.Mutex
+	lastUsed time.Time
+	idle     *time.Timer
+	closed   bool
+}
+
+// get returns the session for runID, starting one if needed.
+func (m *BashSessions) get(runID string, start func() (*exec.Cmd, error), startTimeout time.Duration) (*bashSession, error) {
+	m.mu.Lock()
+	if s, ok := m.sessions[runID]; ok {
+		m.mu.Unlock()
+		return s, nil
+	}
+	m.mu.Unlock()
+
+	cmd, err := start()
+	if err != nil {
+		return nil, err
+	}
+	s, err := startBashSession(runID, cmd, startTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.sessions[runID]; ok {
+		// Lost a race with a concurrent call for the same run.
+		s.close()
+		return existing, nil
+	}
+	if m.sessions == nil {
+		m.sessions = make(map[string]*bashSession)
+	}
+	if len(m.sessions) >= m.MaxSessions {
+		m.evictOldestLocked()
+	}
+	m.sessions[runID] = s
+	s.idle = time.AfterFunc(m.IdleTimeout, func() { m.Close(runID) })
+	return s, nil
+}
+
+func (m *BashSessions) evictOldestLocked() {
+	var (
+		oldest     *bashSession
+		oldestUsed time.Time
+	)
+	for _, s := range m.sessions {
+		s.mu.Lock()
+		used := s.lastUsed
+		s.mu.Unlock()
+		if oldest == nil || used.Before(oldestUsed) {
+			oldest, oldestUsed = s, used
+		}
+	}
+	if oldest != nil {
+		delete(m.sessions, oldest.runID)
+		go oldest.close()
+	}
+}
+
+// touch pushes back the idle deadline for runID.
+func (m *BashSessions) touch(s *bashSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.idle != nil {
+		s.idle.Reset(m.IdleTimeout)
+	}
+}
+
+// Close tears down the session for runID, if any.
+func (m *BashSessions) Close(runID string) {
+	m.mu.Lock()
+	s, ok := m.sessions[runID]
+	if ok {
+		delete(m.sessions, runID)
+	}
+	m.mu.Unlock()
+	if ok {
+		s.close()
+	}
+}
+
+// CloseAll tears down every session.
+func (m *BashSessions) CloseAll() {
+	m.mu.Lock()
+	all := m.sessions
+	m.sessions = make(map[string]*bashSession)
+	m.mu.Unlock()
+	for _, s := range all {
+		s.close()
+	}
+}
+
+// Len reports the number of live sessions.
+func (m *BashSessions) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+func startBashSession(runID string, cmd *exec.Cmd, startTimeout time.Duration) (*bashSession, error) {
+	var tok [8]byte
+	if _, err := rand.Read(tok[:]); err != nil {
+		return nil, err
+	}
+	setProcessGroup(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("bash session: start: %w", err)
+	}
+	s := &bashSession{
+		runID:    runID,
+		token:    "__MM_BASH_" + hex.EncodeToString(tok[:]) + "__",
+		cmd:      cmd,
+		stdin:    stdin,
+		stdout:   make(chan []byte, 16),
+		stderr:   make(chan []byte, 16),
+		done:     make(chan struct{}),
+		lastUsed: time.Now(),
+	}
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go pumpPipe(stdout, s.stdout, &readers)
+	go pumpPipe(stderr, s.stderr, &readers)
+	go func() {
+		readers.Wait()
+		_ = cmd.Wait()
+		close(s.done)
+	}()
+
+	// Wait for the first sentinel so login-profile noise is not attributed to the first command.
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+	var discard limitedBuffer
+	discard.Limit = 1
+	if _, exited, err := s.run(ctx, "", &discard, &discard); err != nil || exited {
+		s.close()
+		if err == nil {
+			err = fmt.Errorf("shell exited")
+		}
+		return nil, fmt.Errorf("bash session: start: %w", err)
+	}
+	return s, nil
+}
+
+func pumpPipe(r io.Reader, ch chan<- []byte, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(ch)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			ch <- append([]byte(nil), buf[:n]...)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// run executes script in the session and captures its output up to the sentinels.
+// An empty script only emits the sentinels. exited reports whether the shell died.
+func (s *bashSession) run(ctx context.Context, script string, stdout, stderr *limitedBuffer) (exitCode int, exited bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, true, fmt.Errorf("bash session closed")
+	}
+	s.lastUsed = time.Now()
+
+	var in strings.Builder
+	if script != "" {
+		// Commands are eval'd from a quoted string so heredocs and unbalanced
+		// input cannot swallow the sentinel; stdin is detached from the control pipe.
+		fmt.Fprintf(&in, "eval %s </dev/null\n", shellQuote(script))
+	}
+	fmt.Fprintf(&in, "__mm_rc=$?\nprintf '\\n%%s:%%d\\n' %s \"$__mm_rc\"\nprintf '\\n%%s:%%d\\n' %s \"$__mm_rc\" >&2\n", s.token, s.token)
+	if _, err := io.WriteString(s.stdin, in.String()); err != nil {
+		return 0, true, fmt.Errorf("bash session: write failed: %w", err)
+	}
+
+	marker := []byte("\n" + s.token + ":")
+	outScan := &sentinelScanner{marker: marker, out: stdout}
+	errScan := &sentinelScanner{marker: marker, out: stderr}
+	outCh, errCh := s.stdout, s.stderr
+	outDone, errDone := false, false
+	if len(s.outRest) > 0 {
+		p := s.outRest
+		s.outRest = nil
+		outDone, s.outRest = outScan.feed(p)
+	}
+	if len(s.errRest) > 0 {
+		p := s.errRest
+		s.errRest = nil
+		errDone, s.errRest = errScan.feed(p)
+	}
+	for !outDone || !errDone {
+		if outDone {
+			outCh = nil
+		}
+		if errDone {
+			errCh = nil
+		}
+		select {
+		case p, ok := <-outCh:
+			if !ok {
+				outScan.flush()
+				errScan.drain(s.stderr)
+				return s.exitCode(), true, nil
+			}
+			outDone, s.outRest = outScan.feed(p)
+		case p, ok := <-errCh:
+			if !ok {
+				errScan.flush()
+				outScan.drain(s.stdout)
+				return s.exitCode(), true, nil
+			}
+			errDone, s.errRest = errScan.feed(p)
+		case <-ctx.Done():
+			outScan.flush()
+			errScan.flush()
+			return 0, false, ctx.Err()
+		}
+	}
+	return outScan.code, false, nil
+}
+
+func (s *bashSession) exitCode() int {
+	<-s.done
+	if s.cmd.ProcessState == nil {
+		return -1
+	}
+	return s.cmd.ProcessState.ExitCode()
+}
+
+func (s *bashSession) close() {
+	s.mu.Lock()
+	already := s.closed
+	s.closed = true
+	s.mu.Unlock()
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	if already {
+		return
+	}
+	_ = s.stdin.Close()
+	killProcessGroup(s.cmd)
+	go func() {
+		// Drain so the pipe readers can exit.
+		for range s.stdout {
+		}
+	}()
+	go func() {
+		for range s.stderr {
+		}
+	}()
+}
+
+// sentinelScanner copies stream bytes into out until it sees marker followed
+// by an exit code and newline.
+type sentinelScanner struct {
+	marker []byte
+	out    *limitedBuffer
+	buf    []byte
+	code   int
+}
+
+// feed appends p and reports whether the sentinel line has been seen; bytes after it are returned as rest.
+func (s *sentinelScanner) feed(p []byte) (done bool, rest []byte) {
+	s.buf = append(s.buf, p...)
+	if i := bytes.Index(s.buf, s.marker); i >= 0 {
+		tail := s.buf[i+len(s.marker):]
+		j := bytes.IndexByte(tail, '\n')
+		if j < 0 {
+			_, _ = s.out.Write(s.buf[:i])
+			s.buf = append([]byte(nil), s.buf[i:]...)
+			return false, nil
+		}
+		_, _ = s.out.Write(s.buf[:i])
+		s.code, _ = strconv.Atoi(string(tail[:j]))
+		if len(tail) > j+1 {
+			rest = append([]byte(nil), tail[j+1:]...)
+		}
+		s.buf = nil
+		return true, rest
+	}
+	// Hold back a possible partial marker.
+	keep := len(s.marker) - 1
+	if len(s.buf) > keep {
+		_, _ = s.out.Write(s.buf[:len(s.buf)-keep])
+		s.buf = append([]byte(nil), s.buf[len(s.buf)-keep:]...)
+	}
+	return false, nil
+}
+
+func (s *sentinelScanner) flush() {
+	_, _ = s.out.Write(s.buf)
+	s.buf = nil
+}
+
+// drain copies whatever remains on a stream of an exited shell.
+func (s *sentinelScanner) drain(ch <-chan []byte) {
+	for p := range ch {
+		s.buf = append(s.buf, p...)
+	}
+	s.flush()
+}
+
+// shellQuote single-quotes v for bash.
+func shellQuote(v string) string {
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
@@ -1,6 +1,16 @@
 package builtin
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
 
 func TestContainsTokenBoundary(t *testing.T) {
 	cases := []struct {
@@ -41,6 +51,21 @@ func TestBashCommandDenied(t *testing.T) {
 	if _, ok := bashCommandDenied("echo hello", []string{"config.yaml"}); ok {
 		t.Fatal("expected allowed command")
 	}
+
+	// Quoting, escapes, globs and substitutions must not hide the path.
+	for _, cmd := range []string{
+		`cat conf""ig.yaml`,
+		`cat conf\ig.yaml`,
+		`cat ./conf*.yaml`,
+		`cat "$(echo ~/.morph/config.yaml)"`,
+		`bash -c 'head -n1 config.yaml'`,
+		`cp x config.yaml`,
+		`echo x > config.yaml`,
+	} {
+		if _, ok := bashCommandDenied(cmd, []string{"~/.morph/config.yaml"}); !ok {
+			t.Errorf("expected %q to be denied", cmd)
+		}
+	}
 }
 
 func TestBashCommandDeniedTokens_Curl(t *testing.T) {
@@ -55,6 +80,17 @@ func TestBashCommandDeniedTokens_Curl(t *testing.T) {
 		{name: "quoted", cmd: "\"curl\" https://example.com", want: true},
 		{name: "nonmatch_prefix", cmd: "mycurl https://example.com", want: false},
 		{name: "nonmatch_suffix", cmd: "curling https://example.com", want: false},
+		{name: "split_quotes", cmd: `c"ur"l https://example.com`, want: true},
+		{name: "escaped", cmd: `cu\rl https://example.com`, want: true},
+		{name: "wrapped", cmd: "env -i timeout 5 curl https://example.com", want: true},
+		{name: "xargs", cmd: "echo https://example.com | xargs curl", want: true},
+		{name: "nested", cmd: `sh -c "curl https://example.com"`, want: true},
+		{name: "dynamic", cmd: `$(echo curl) https://example.com`, want: true},
+		{name: "argument_only", cmd: "man curl", want: true},
+		{name: "python", cmd: `python3 -c "import os; os.system('curl x')"`, want: true},
+		{name: "awk_system", cmd: `awk 'BEGIN{system("curl x")}'`, want: true},
+		{name: "git_pager", cmd: "git -c core.pager=curl log", want: true},
+		{name: "piped_to_shell", cmd: `echo c"ur"l x | sh`, want: true},
 	}
 
 	for _, tc := range cases {
@@ -66,3 +102,169 @@ func TestBashCommandDeniedTokens_Curl(t *testing.T) {
 		})
 	}
 }
+
+func newSandboxedBash(t *testing.T, allowNetwork bool) (*BashTool, string) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("bash sandbox is Linux-only")
+	}
+	ws := t.TempDir()
+	bt := NewBashTool(true, false, 10*time.Second, 64*1024)
+	bt.Sandbox = &BashSandbox{Enabled: true, Workspace: ws, AllowNetwork: allowNetwork, MaxProcesses: 256}
+	out, err := bt.Execute(context.Background(), map[string]any{"cmd": "true"})
+	if err != nil {
+		t.Skipf("user namespaces unavailable: %v\n%s", err, out)
+	}
+	return bt, ws
+}
+
+func TestBashTool_SandboxFilesystem(t *testing.T) {
+	bt, ws := newSandboxedBash(t, false)
+
+	out, err := bt.Execute(context.Background(), map[string]any{"cmd": "echo ok > note.txt && pwd"})
+	if err != nil {
+		t.Fatalf("workspace write failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, ws) {
+		t.Fatalf("expected cwd to default to workspace %s, got:\n%s", ws, out)
+	}
+	if b, err := os.ReadFile(filepath.Join(ws, "note.txt")); err != nil || strings.TrimSpace(string(b)) != "ok" {
+		t.Fatalf("workspace file not visible on host: %q,%v", b, err)
+	}
+
+	outside := filepath.Join(filepath.Dir(ws), "outside-"+filepath.Base(ws))
+	out, err = bt.Execute(context.Background(), map[string]any{"cmd": "echo x > " + outside})
+	if err == nil {
+		t.Fatalf("expected write outside workspace to fail:\n%s", out)
+	}
+	if _, statErr := os.Stat(outside); statErr == nil {
+		_ = os.Remove(outside)
+		t.Fatalf("write escaped the sandbox")
+	}
+
+	shm := "/dev/shm/mm-sandbox-" + filepath.Base(ws)
+	out, err = bt.Execute(context.Background(), map[string]any{"cmd": "echo x > " + shm})
+	if _, statErr := os.Stat(shm); statErr == nil {
+		_ = os.Remove(shm)
+		t.Fatalf("/dev/shm write reached the host:\n%s (%v)", out, err)
+	}
+}
+
+func TestBashTool_SandboxProcessesAndEnv(t *testing.T) {
+	t.Setenv("MISTER_MORPH_LLM_API_KEY", "sk-secret")
+	bt, _ := newSandboxedBash(t, false)
+
+	out, err := bt.Execute(context.Background(), map[string]any{"cmd": "echo pid=$$; echo key=${MISTER_MORPH_LLM_API_KEY:-none}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "pid=1\n") || !strings.Contains(out, "key=none") {
+		t.Fatalf("expected a fresh PID namespace and a scrubbed environment:\n%s", out)
+	}
+}
+
+func TestBashTool_SandboxNetworkAndLimits(t *testing.T) {
+	bt, _ := newSandboxedBash(t, false)
+
+	out, err := bt.Execute(context.Background(), map[string]any{"cmd": "cut -d: -f1 /proc/self/net/dev | tail -n +3"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "lo") || strings.Contains(out, "eth0") {
+		t.Fatalf("expected only loopback in a fresh network namespace:\n%s", out)
+	}
+
+	bt.Sandbox.MemoryMB = 64
+	out, err = bt.Execute(context.Background(), map[string]any{"cmd": "ulimit -v; ulimit -u"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "65536") || !strings.Contains(out, "256") {
+		t.Fatalf("rlimits not applied:\n%s", out)
+	}
+}
+
+func TestBashTool_SessionPersistsState(t *testing.T) {
+	bt := NewBashTool(true, false, 10*time.Second, 64*1024)
+	bt.Sessions = NewBashSessions(time.Minute, 4)
+	defer bt.Sessions.CloseAll()
+	dir := t.TempDir()
+	ctx := tools.WithRunID(context.Background(), "run1")
+
+	if _, err := bt.Execute(ctx, map[string]any{"cmd": "export MM_GREETING=hello", "cwd": dir}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	out, err := bt.Execute(ctx, map[string]any{"cmd": "echo \"$MM_GREETING\"; pwd; printf 'no-newline'"})
+	if err != nil {
+		t.Fatalf("echo: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "hello\n"+dir+"\nno-newline\n\nstderr:") {
+		t.Fatalf("session state not kept:\n%s", out)
+	}
+
+	// Heredocs and quotes must not break sentinel framing.
+	out, err = bt.Execute(ctx, map[string]any{"cmd": "cat <<'EOT'\nit's a 'quoted' line\nEOT\necho err >&2; false"})
+	if err == nil || !strings.Contains(err.Error(), "code 1") {
+		t.Fatalf("expected exit code 1, got %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "it's a 'quoted' line") || !strings.Contains(out, "stderr:\nerr\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	// Other runs get their own shell.
+	out, _ = bt.Execute(tools.WithRunID(context.Background(), "run2"), map[string]any{"cmd": "echo \"[$MM_GREETING]\""})
+	if !strings.Contains(out, "[]") {
+		t.Fatalf("env leaked across runs:\n%s", out)
+	}
+	if n := bt.Sessions.Len(); n != 2 {
+		t.Fatalf("expected 2 sessions, got %d", n)
+	}
+
+	bt.FinishRun("run1")
+	if n := bt.Sessions.Len(); n != 1 {
+		t.Fatalf("expected FinishRun to close the session, have %d", n)
+	}
+}
+
+func TestBashTool_SessionTimeoutAndExit(t *testing.T) {
+	bt := NewBashTool(true, false, 10*time.Second, 64*1024)
+	bt.Sessions = NewBashSessions(time.Minute, 4)
+	defer bt.Sessions.CloseAll()
+	ctx := tools.WithRunID(context.Background(), "run")
+
+	if _, err := bt.Execute(ctx, map[string]any{"cmd": "export X=1"}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	_, err := bt.Execute(ctx, map[string]any{"cmd": "sleep 30", "timeout_seconds": 0.5})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	out, err := bt.Execute(ctx, map[string]any{"cmd": "echo \"[$X]\""})
+	if err != nil || !strings.Contains(out, "[]") {
+		t.Fatalf("expected fresh session after timeout: %v\n%s", err, out)
+	}
+
+	_, err = bt.Execute(ctx, map[string]any{"cmd": "exit 7"})
+	if err == nil || !strings.Contains(err.Error(), "session exited with code 7") {
+		t.Fatalf("expected session exit, got %v", err)
+	}
+	if out, err := bt.Execute(ctx, map[string]any{"cmd": "echo back"}); err != nil || !strings.Contains(out, "back") {
+		t.Fatalf("expected restart after exit: %v\n%s", err, out)
+	}
+}
+
+func TestBashSessions_IdleTimeout(t *testing.T) {
+	bt := NewBashTool(true, false, 10*time.Second, 64*1024)
+	bt.Sessions = NewBashSessions(200*time.Millisecond, 4)
+	defer bt.Sessions.CloseAll()
+	if _, err := bt.Execute(tools.WithRunID(context.Background(), "run"), map[string]any{"cmd": "true"}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for bt.Sessions.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("idle session was not closed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
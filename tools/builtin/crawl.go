@@ -0,0 +1,493 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/guard"
+	"github.com/quailyquaily/mistermorph/internal/docextract"
+)
+
+// CrawlTool fetches a set of same-origin pages (following links and/or the
+// site's sitemap) and saves each page's main content as Markdown under
+// file_cache_dir. It reuses url_fetch's HTTP client, limits and guard checks;
+// every URL, including redirects, must pass the guard network policy, and
+// robots.txt is honored.
+type CrawlTool struct {
+	Fetch    *URLFetchTool
+	MaxPages int
+	MaxDepth int
+	// Delay is the minimum pause between requests; a larger robots.txt
+	// Crawl-delay (up to crawlMaxDelay) wins.
+	Delay   time.Duration
+	Timeout time.Duration // whole crawl
+}
+
+func NewCrawlTool(fetch *URLFetchTool, maxPages, maxDepth int, delay, timeout time.Duration) *CrawlTool {
+	if maxPages <= 0 {
+		maxPages = 50
+	}
+	if maxDepth < 0 {
+		maxDepth = 0
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	return &CrawlTool{
+		Fetch:    fetch,
+		MaxPages: maxPages,
+		MaxDepth: maxDepth,
+		Delay:    delay,
+		Timeout:  timeout,
+	}
+}
+
+const (
+	crawlMaxDelay        = 10 * time.Second
+	crawlMaxReportedSkip = 20
+	crawlMaxSitemapURLs  = 5000
+)
+
+func (t *CrawlTool) Name() string { return "crawl" }
+
+func (t *CrawlTool) Description() string {
+	return "Crawls a website without a browser: starting at url, follows same-origin links (and optionally the sitemap) up to max_depth/max_pages, honoring robots.txt. " +
+		"Each page's main content is saved as a Markdown file under file_cache_dir; returns an index of saved files (read them with read_file). Use for documentation sites instead of fetching pages one by one."
+}
+
+func (t *CrawlTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": "Start URL (http/https).",
+			},
+			"max_pages": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Max pages to save (default and max %d).", t.MaxPages),
+			},
+			"max_depth": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Max link depth from the start URL; 0 fetches only the start page (and sitemap URLs). Default and max %d.", t.MaxDepth),
+			},
+			"path_prefix": map[string]any{
+				"type":        "string",
+				"description": "Only crawl URLs whose path starts with this prefix (e.g. /docs/). Default: the whole origin.",
+			},
+			"sitemap": map[string]any{
+				"type":        "boolean",
+				"description": "Also seed the crawl from the site's sitemap.xml (from robots.txt, or /sitemap.xml).",
+			},
+			"output_dir": map[string]any{
+				"type":        "string",
+				"description": "Directory under file_cache_dir for the Markdown files (default: crawl/<host>).",
+			},
+		},
+		"required": []string{"url"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+type crawlItem struct {
+	u     *url.URL
+	depth int
+}
+
+type crawlPage struct {
+	url   string
+	title string
+	file  string
+	bytes int
+}
+
+type crawlScope struct {
+	origin string
+	prefix string
+}
+
+var crawlSkipExtRe = regexp.MustCompile(`(?i)\.(png|jpe?g|gif|webp|svg|ico|bmp|pdf|zip|gz|tgz|bz2|xz|7z|rar|tar|dmg|exe|msi|deb|rpm|apk|mp3|mp4|m4a|webm|mov|avi|wav|ogg|woff2?|ttf|otf|eot|css|js|mjs|map|wasm|json|xml|rss|atom)$`)
+
+func (s crawlScope) contains(u *url.URL) bool {
+	return canonicalOrigin(u) == s.origin && strings.HasPrefix(u.EscapedPath(), s.prefix) && !crawlSkipExtRe.MatchString(u.Path)
+}
+
+func (t *CrawlTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if t.Fetch == nil {
+		return "", fmt.Errorf("crawl requires url_fetch (enable tools.url_fetch)")
+	}
+	rawURL, _ := params["url"].(string)
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", fmt.Errorf("missing required param: url")
+	}
+	start, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	if !t.Fetch.AllowScheme[strings.ToLower(start.Scheme)] || start.Host == "" {
+		return "", fmt.Errorf("unsupported url: %s", rawURL)
+	}
+	start.Fragment, start.RawFragment = "", ""
+	if err := checkFetchURL(ctx, t.Name(), start); err != nil {
+		return "", err
+	}
+
+	maxPages := t.MaxPages
+	if n, ok := asInt64(params["max_pages"]); ok && n > 0 && int(n) < maxPages {
+		maxPages = int(n)
+	}
+	maxDepth := t.MaxDepth
+	if n, ok := asInt64(params["max_depth"]); ok && n >= 0 && int(n) < maxDepth {
+		maxDepth = int(n)
+	}
+	prefix, _ := params["path_prefix"].(string)
+	prefix = strings.TrimSpace(prefix)
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	scope := crawlScope{origin: canonicalOrigin(start), prefix: prefix}
+	if !scope.contains(start) {
+		return "", fmt.Errorf("url is outside path_prefix %q", prefix)
+	}
+	useSitemap, _ := params["sitemap"].(bool)
+
+	outDir, _ := params["output_dir"].(string)
+	if strings.TrimSpace(outDir) == "" {
+		outDir = path.Join("crawl", downloadNameRe.ReplaceAllString(start.Host, "_"))
+	}
+	_, outAbs, err := resolveWritePath(t.Fetch.FileCacheDir, outDir)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(outAbs, 0o700); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	client := t.client(ctx, scope.origin)
+
+	robots := t.fetchRobots(ctx, client, start)
+	delay := t.Delay
+	if robots.delay > delay {
+		delay = min(robots.delay, crawlMaxDelay)
+	}
+
+	queue := []crawlItem{{u: start}}
+	seen := map[string]bool{start.String(): true}
+	enqueue := func(u *url.URL, depth int) {
+		if k := u.String(); !seen[k] && scope.contains(u) {
+			seen[k] = true
+			queue = append(queue, crawlItem{u: u, depth: depth})
+		}
+	}
+	if useSitemap {
+		for _, u := range t.sitemapURLs(ctx, client, start, robots.sitemaps) {
+			enqueue(u, 0)
+		}
+	}
+
+	var (
+		pages   []crawlPage
+		skipped []string
+		names   = map[string]bool{}
+		last    time.Time
+		stopped string
+	)
+	skip := func(u *url.URL, reason string) {
+		skipped = append(skipped, fmt.Sprintf("%s (%s)", sanitizeOutputURL(u.String()), reason))
+	}
+	for len(queue) > 0 && len(pages) < maxPages {
+		item := queue[0]
+		queue = queue[1:]
+		if !robots.Allowed(item.u.RequestURI()) {
+			skip(item.u, "robots.txt")
+			continue
+		}
+		if err := checkFetchURL(ctx, t.Name(), item.u); err != nil {
+			skip(item.u, err.Error())
+			continue
+		}
+		if wait := delay - time.Since(last); !last.IsZero() && wait > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(wait):
+			}
+		}
+		if ctx.Err() != nil {
+			stopped = fmt.Sprintf("stopped: crawl timeout (%s) reached", t.Timeout)
+			break
+		}
+		last = time.Now()
+
+		final, title, md, links, err := t.fetchPage(ctx, client, item.u)
+		if err != nil {
+			skip(item.u, err.Error())
+			continue
+		}
+		if final.String() != item.u.String() {
+			if seen[final.String()] || !scope.contains(final) {
+				continue // a redirect to a page that is (or will be) crawled directly
+			}
+			seen[final.String()] = true
+		}
+		name := crawlFileName(final, names)
+		content := fmt.Sprintf("Source: %s\n", sanitizeOutputURL(final.String()))
+		if title != "" {
+			content += "Title: " + title + "\n"
+		}
+		content += "\n" + md
+		if err := os.WriteFile(filepath.Join(outAbs, name), []byte(content), 0o644); err != nil {
+			return "", err
+		}
+		pages = append(pages, crawlPage{url: sanitizeOutputURL(final.String()), title: title, file: name, bytes: len(content)})
+		if item.depth < maxDepth {
+			for _, l := range links {
+				enqueue(l, item.depth+1)
+			}
+		}
+	}
+	if stopped == "" && len(pages) == maxPages && len(queue) > 0 {
+		stopped = fmt.Sprintf("stopped: max_pages (%d) reached; %d more URLs were queued", maxPages, len(queue))
+	}
+
+	var idx strings.Builder
+	fmt.Fprintf(&idx, "# Crawl of %s\n\n", sanitizeOutputURL(start.String()))
+	for _, p := range pages {
+		label := p.title
+		if label == "" {
+			label = p.url
+		}
+		fmt.Fprintf(&idx, "- [%s](%s) - %s\n", label, p.file, p.url)
+	}
+	indexPath := filepath.Join(outAbs, "_index.md")
+	if err := os.WriteFile(indexPath, []byte(idx.String()), 0o644); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "url: %s\n", sanitizeOutputURL(start.String()))
+	fmt.Fprintf(&b, "dir: %s\n", outAbs)
+	fmt.Fprintf(&b, "index: %s\n", indexPath)
+	fmt.Fprintf(&b, "pages: %d\n", len(pages))
+	if stopped != "" {
+		b.WriteString(stopped + "\n")
+	}
+	for i, p := range pages {
+		fmt.Fprintf(&b, "%d. %s (%d bytes) %s", i+1, p.file, p.bytes, p.url)
+		if p.title != "" {
+			fmt.Fprintf(&b, " - %s", p.title)
+		}
+		b.WriteString("\n")
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "skipped: %d\n", len(skipped))
+		for i, s := range skipped {
+			if i == crawlMaxReportedSkip {
+				fmt.Fprintf(&b, "...(%d more)\n", len(skipped)-i)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", s)
+		}
+	}
+	if len(pages) == 0 {
+		return b.String(), fmt.Errorf("crawl saved no pages")
+	}
+	return b.String(), nil
+}
+
+// client is url_fetch's client with redirects limited to origin and checked
+// against the guard network policy on every hop.
+func (t *CrawlTool) client(ctx context.Context, origin string) *http.Client {
+	var client http.Client
+	if t.Fetch.HTTPClient != nil {
+		client = *t.Fetch.HTTPClient
+	}
+	client.Timeout = t.Fetch.Timeout
+	netPol, hasNetPol := guard.NetworkPolicyFromContext(ctx)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if hasNetPol && !netPol.FollowRedirects {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > 3 {
+			return fmt.Errorf("stopped after 3 redirects")
+		}
+		if canonicalOrigin(req.URL) != origin {
+			return fmt.Errorf("redirect to different origin is not allowed")
+		}
+		return checkFetchURL(req.Context(), t.Name(), req.URL)
+	}
+	if hasNetPol && !netPol.AllowProxy {
+		disableProxy(&client)
+	}
+	return &client
+}
+
+func (t *CrawlTool) get(ctx context.Context, client *http.Client, u *url.URL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if ua := strings.TrimSpace(t.Fetch.UserAgent); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	return client.Do(req)
+}
+
+// fetchRobots loads robots.txt for start's origin. A missing file (4xx), or
+// one outside the guard allowlist, allows everything; a server error or
+// unreachable file allows nothing.
+func (t *CrawlTool) fetchRobots(ctx context.Context, client *http.Client, start *url.URL) *robotsRules {
+	agent, _, _ := strings.Cut(strings.TrimSpace(t.Fetch.UserAgent), "/")
+	ru := &url.URL{Scheme: start.Scheme, Host: start.Host, Path: "/robots.txt"}
+	if checkFetchURL(ctx, t.Name(), ru) != nil {
+		return &robotsRules{}
+	}
+	resp, err := t.get(ctx, client, ru, "text/plain")
+	if err != nil {
+		return &robotsRules{disallowed: true}
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+		return parseRobots(data, agent)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &robotsRules{}
+	default:
+		return &robotsRules{disallowed: true}
+	}
+}
+
+type sitemapDoc struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// sitemapURLs reads the sitemaps listed in robots.txt (or /sitemap.xml),
+// following one level of sitemap index files.
+func (t *CrawlTool) sitemapURLs(ctx context.Context, client *http.Client, start *url.URL, listed []string) []*url.URL {
+	if len(listed) == 0 {
+		listed = []string{(&url.URL{Scheme: start.Scheme, Host: start.Host, Path: "/sitemap.xml"}).String()}
+	}
+	var out []*url.URL
+	fetched := 0
+	var visit func(raw string, nested bool)
+	visit = func(raw string, nested bool) {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || canonicalOrigin(u) != canonicalOrigin(start) || fetched >= 20 || len(out) >= crawlMaxSitemapURLs {
+			return
+		}
+		if checkFetchURL(ctx, t.Name(), u) != nil {
+			return
+		}
+		fetched++
+		resp, err := t.get(ctx, client, u, "application/xml, text/xml")
+		if err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return
+		}
+		var doc sitemapDoc
+		if xml.NewDecoder(io.LimitReader(resp.Body, t.Fetch.MaxBytes*8)).Decode(&doc) != nil {
+			return
+		}
+		for _, loc := range doc.URLs {
+			if lu, err := url.Parse(strings.TrimSpace(loc)); err == nil && len(out) < crawlMaxSitemapURLs {
+				lu.Fragment, lu.RawFragment = "", ""
+				out = append(out, lu)
+			}
+		}
+		if !nested {
+			for _, sm := range doc.Sitemaps {
+				visit(sm, true)
+			}
+		}
+	}
+	for _, raw := range listed {
+		visit(raw, false)
+	}
+	return out
+}
+
+// fetchPage GETs u and returns the final URL (after redirects), the page
+// title, its main content as Markdown and its links.
+func (t *CrawlTool) fetchPage(ctx context.Context, client *http.Client, u *url.URL) (*url.URL, string, string, []*url.URL, error) {
+	resp, err := t.get(ctx, client, u, "text/html,application/xhtml+xml,text/plain;q=0.8,text/markdown;q=0.8")
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, "", "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", "", nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	final := u
+	if resp.Request != nil && resp.Request.URL != nil {
+		final = resp.Request.URL
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.Fetch.MaxBytes))
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+	ct := resp.Header.Get("Content-Type")
+	page, _, err := docextract.DecodeText(body, contentTypeCharset(ct))
+	if err != nil {
+		page = string(bytes.ToValidUTF8(body, []byte("\uFFFD")))
+	}
+	if isHTMLResponse(ct, body) {
+		title, md := docextract.ReadableMarkdown(page, final)
+		return final, title, redactResponseBody(md), docextract.Links(page, final), nil
+	}
+	switch mt, _, _ := mime.ParseMediaType(ct); mt {
+	case "text/plain", "text/markdown", "text/x-markdown":
+		return final, "", redactResponseBody(page), nil, nil
+	default:
+		return nil, "", "", nil, fmt.Errorf("not a page: %s", mt)
+	}
+}
+
+// crawlFileName maps a page URL to a unique Markdown file name, e.g.
+// /docs/getting-started -> docs_getting-started.md.
+func crawlFileName(u *url.URL, used map[string]bool) string {
+	p := strings.Trim(u.Path, "/")
+	p = strings.TrimSuffix(p, path.Ext(p))
+	if u.RawQuery != "" {
+		p += "_" + u.RawQuery
+	}
+	name := strings.Trim(downloadNameRe.ReplaceAllString(strings.ReplaceAll(p, "/", "_"), "_"), "._")
+	if name == "" {
+		name = "index"
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	candidate := name + ".md"
+	for i := 1; used[candidate] || candidate == "_index.md"; i++ {
+		candidate = fmt.Sprintf("%s-%d.md", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
@@ -0,0 +1,148 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules is the robots.txt group that applies to one user agent.
+type robotsRules struct {
+	rules      []robotsRule
+	delay      time.Duration
+	sitemaps   []string
+	disallowed bool // robots.txt could not be fetched (5xx/network error): nothing is allowed
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// parseRobots parses robots.txt for agent (the product token of the
+// User-Agent, e.g. "mistermorph"). Groups naming the agent take precedence
+// over "*"; rules use RFC 9309 matching (longest pattern wins, allow wins ties,
+// "*" and "$" wildcards).
+func parseRobots(data []byte, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+	var (
+		own, star   []robotsRule
+		ownDelay    time.Duration
+		starDelay   time.Duration
+		sitemaps    []string
+		groupAgents []string
+		inRules     bool
+		ownGroup    bool
+	)
+	matches := func(kind string) bool {
+		for _, a := range groupAgents {
+			if kind == "*" && a == "*" {
+				return true
+			}
+			if kind == "own" && a != "*" && agent != "" && strings.Contains(agent, a) {
+				return true
+			}
+		}
+		return false
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 512*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+		switch key {
+		case "user-agent":
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			a := strings.ToLower(val)
+			groupAgents = append(groupAgents, a)
+			if a != "*" && agent != "" && strings.Contains(agent, a) {
+				ownGroup = true
+			}
+		case "allow", "disallow":
+			inRules = true
+			if val == "" {
+				continue // "Disallow:" with no path allows everything
+			}
+			r := robotsRule{allow: key == "allow", pattern: val, re: robotsPattern(val)}
+			if matches("own") {
+				own = append(own, r)
+			}
+			if matches("*") {
+				star = append(star, r)
+			}
+		case "crawl-delay":
+			inRules = true
+			secs, err := strconv.ParseFloat(val, 64)
+			if err != nil || secs < 0 {
+				continue
+			}
+			d := time.Duration(secs * float64(time.Second))
+			if matches("own") {
+				ownDelay = d
+			}
+			if matches("*") {
+				starDelay = d
+			}
+		case "sitemap":
+			if val != "" {
+				sitemaps = append(sitemaps, val)
+			}
+		}
+	}
+	// A group naming the agent replaces "*" entirely, even if it has no rules.
+	if ownGroup {
+		return &robotsRules{rules: own, delay: ownDelay, sitemaps: sitemaps}
+	}
+	return &robotsRules{rules: star, delay: starDelay, sitemaps: sitemaps}
+}
+
+func robotsPattern(p string) *regexp.Regexp {
+	anchored := strings.HasSuffix(p, "$")
+	p = strings.TrimSuffix(p, "$")
+	expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(p), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// Allowed reports whether path (path plus query of a URL) may be fetched.
+func (r *robotsRules) Allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	if r.disallowed {
+		return false
+	}
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
@@ -0,0 +1,133 @@
+package builtin
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/guard"
+)
+
+func TestParseRobots(t *testing.T) {
+	txt := `# comment
+User-agent: *
+Disallow: /private/
+Crawl-delay: 2
+
+User-agent: MisterMorph
+User-agent: otherbot
+Disallow: /tmp
+Disallow: /*.cgi$
+Allow: /tmp/public
+
+Sitemap: https://example.test/sitemap.xml
+`
+	own := parseRobots([]byte(txt), "mistermorph")
+	for path, want := range map[string]bool{
+		"/private/x":     true, // the named group replaces "*"
+		"/tmp/a":         false,
+		"/tmp/public/a":  true,
+		"/run.cgi":       false,
+		"/run.cgi?x=1":   true,
+		"/docs":          true,
+		"/robots.txt":    true,
+		"/tmpfoo/bar":    false,
+		"/tmp/publicity": true,
+	} {
+		if got := own.Allowed(path); got != want {
+			t.Errorf("own.Allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+	if own.delay != 0 || len(own.sitemaps) != 1 {
+		t.Fatalf("own delay=%v sitemaps=%v", own.delay, own.sitemaps)
+	}
+
+	star := parseRobots([]byte(txt), "somebot")
+	if star.Allowed("/private/x") || !star.Allowed("/tmp/a") || star.delay != 2*time.Second {
+		t.Fatalf("unexpected * group: %+v", star)
+	}
+	if (&robotsRules{disallowed: true}).Allowed("/") {
+		t.Fatalf("unreachable robots.txt must disallow")
+	}
+}
+
+func TestCrawlTool(t *testing.T) {
+	site := map[string]string{
+		"/robots.txt":          "User-agent: *\nDisallow: /docs/secret\n",
+		"/docs/":               `<html><head><title>Docs</title></head><body><main><h1>Welcome</h1><p>Start with the <a href="guide">guide</a>, then read the <a href="/docs/api">API</a>.</p><a href="/docs/secret">s</a><a href="/blog/">blog</a><a href="https://other.test/docs/x">x</a><a href="/docs/logo.png">logo</a></main></body></html>`,
+		"/docs/guide":          `<html><head><title>Guide</title></head><body><main><h1>Guide</h1><p>Install it and run it. See <a href="/docs/guide/advanced">advanced</a>.</p></main></body></html>`,
+		"/docs/api":            `<html><head><title>API</title></head><body><main><h1>API</h1><p>Call the endpoint with your token to get results back.</p></main></body></html>`,
+		"/docs/guide/advanced": `<html><body><p>Too deep.</p></body></html>`,
+	}
+	var requested []string
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requested = append(requested, r.URL.Path)
+		body, ok := site[r.URL.Path]
+		h := make(http.Header)
+		if strings.HasSuffix(r.URL.Path, ".txt") {
+			h.Set("Content-Type", "text/plain")
+		} else {
+			h.Set("Content-Type", "text/html; charset=utf-8")
+		}
+		status := 200
+		if !ok {
+			status, body = 404, "not found"
+		}
+		return &http.Response{StatusCode: status, Header: h, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+	cacheDir := t.TempDir()
+	fetch := NewURLFetchTool(true, 2*time.Second, 64*1024, "mistermorph/1.0", cacheDir)
+	fetch.HTTPClient = &http.Client{Transport: rt}
+	tool := NewCrawlTool(fetch, 10, 1, 0, 0)
+
+	out, err := tool.Execute(context.Background(), map[string]any{"url": "https://docs.example.test/docs/", "path_prefix": "/docs/"})
+	if err != nil {
+		t.Fatalf("crawl: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"pages: 3\n",
+		"1. docs.md",
+		"2. docs_guide.md",
+		"3. docs_api.md",
+		"https://docs.example.test/docs/secret (robots.txt)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, p := range requested {
+		if p == "/docs/secret" || p == "/blog/" || p == "/docs/guide/advanced" || p == "/docs/logo.png" {
+			t.Errorf("unexpected request for %s", p)
+		}
+	}
+	dir := filepath.Join(cacheDir, "crawl", "docs.example.test")
+	guide, err := os.ReadFile(filepath.Join(dir, "docs_guide.md"))
+	if err != nil || !strings.Contains(string(guide), "Source: https://docs.example.test/docs/guide\nTitle: Guide\n") || !strings.Contains(string(guide), "Install it and run it.") {
+		t.Fatalf("guide page: %v\n%s", err, guide)
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, "_index.md"))
+	if !strings.Contains(string(index), "- [API](docs_api.md) - https://docs.example.test/docs/api") {
+		t.Fatalf("index:\n%s", index)
+	}
+
+	// With a guard policy, URLs outside allowed_url_prefixes are skipped.
+	requested = nil
+	ctx := guard.WithNetworkPolicy(context.Background(), guard.NetworkPolicy{AllowedURLPrefixes: []string{"https://docs.example.test/docs/"}})
+	out, err = tool.Execute(ctx, map[string]any{"url": "https://docs.example.test/docs/", "output_dir": "guarded"})
+	if err != nil {
+		t.Fatalf("guarded crawl: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "https://docs.example.test/blog/ (url is not allowed by guard)") {
+		t.Fatalf("expected guard skip in:\n%s", out)
+	}
+	for _, p := range requested {
+		if p == "/blog/" || p == "/robots.txt" {
+			t.Errorf("unexpected request for %s under guard", p)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package builtin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Line-based unified diff and patch helpers used by edit_file.
+//
+// Lines are handled as produced by strings.SplitAfter(s, "\n"): each element
+// keeps its line terminator, and only the last one may lack it.
+
+const (
+	diffContextLines = 3
+	// Above this many LCS cells the changed middle is shown as a single
+	// delete+insert block instead of a minimal diff.
+	maxDiffCells = 4_000_000
+)
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ops = append(ops, diffMiddle(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	var ops []diffOp
+	if n == 0 || m == 0 || n*m > maxDiffCells {
+		for _, l := range a {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, diffOp{'+', l})
+		}
+		return ops
+	}
+	// lcs[i][j] = LCS length of a[i:] and b[j:].
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff renders the change from before to after. It returns "" when they are equal.
+func unifiedDiff(name string, before, after string) string {
+	if before == after {
+		return ""
+	}
+	ops := diffLines(splitLines(before), splitLines(after))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+
+	// Walk change regions, merging those whose context overlaps.
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		start := k - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContextLines {
+				end += min(diffContextLines, run-end)
+				break
+			}
+			end = run
+		}
+		aCount, bCount := aLine[end]-aLine[start], bLine[end]-bLine[start]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount))
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(strings.TrimRight(op.line, "\n"))
+			b.WriteByte('\n')
+			if !strings.HasSuffix(op.line, "\n") {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+		k = end
+	}
+	return b.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return strconv.Itoa(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+type patchHunk struct {
+	oldStart int // 1-based, as written in the header
+	oldCount int
+	newCount int
+	lines    []diffOp
+}
+
+// complete reports whether the hunk has as many lines as its header declares.
+func (h *patchHunk) complete() bool {
+	old, nw := 0, 0
+	for _, l := range h.lines {
+		if l.kind != '+' {
+			old++
+		}
+		if l.kind != '-' {
+			nw++
+		}
+	}
+	return old >= h.oldCount && nw >= h.newCount
+}
+
+// parseUnifiedPatch parses the hunks of a single-file unified diff.
+func parseUnifiedPatch(patch string) ([]patchHunk, error) {
+	var hunks []patchHunk
+	files := 0
+	var cur *patchHunk
+	for _, raw := range strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n") {
+		if cur != nil && !cur.complete() {
+			switch {
+			case raw == "":
+				// Some tools drop the leading space of empty context lines.
+				cur.lines = append(cur.lines, diffOp{' ', "\n"})
+			case raw[0] == ' ' || raw[0] == '-' || raw[0] == '+':
+				cur.lines = append(cur.lines, diffOp{raw[0], raw[1:] + "\n"})
+			case raw[0] == '\\':
+				cur.stripLastNewline()
+			default:
+				return nil, fmt.Errorf("invalid patch line: %q", raw)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(raw, "\\"):
+			// "\ No newline at end of file" after the last line of a hunk.
+			if cur != nil {
+				cur.stripLastNewline()
+			}
+		case strings.HasPrefix(raw, "--- "):
+			files++
+			if files > 1 {
+				return nil, fmt.Errorf("patch touches more than one file; apply one file per call")
+			}
+			cur = nil
+		case strings.HasPrefix(raw, "@@"):
+			h, err := parseHunkHeader(raw)
+			if err != nil {
+				return nil, err
+			}
+			hunks = append(hunks, h)
+			cur = &hunks[len(hunks)-1]
+		default:
+			// Preamble ("diff --git", "index ...", "+++ ") or trailing text.
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("patch contains no hunks")
+	}
+	for i := range hunks {
+		if !hunks[i].complete() {
+			return nil, fmt.Errorf("hunk %d (@@ -%d) is truncated", i+1, hunks[i].oldStart)
+		}
+	}
+	return hunks, nil
+}
+
+func (h *patchHunk) stripLastNewline() {
+	if n := len(h.lines); n > 0 {
+		h.lines[n-1].line = strings.TrimSuffix(h.lines[n-1].line, "\n")
+	}
+}
+
+func parseHunkHeader(s string) (patchHunk, error) {
+	// @@ -l[,s] +l[,s] @@ optional section
+	fields := strings.Fields(s)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return patchHunk{}, fmt.Errorf("invalid hunk header: %q", s)
+	}
+	oldStart, oldCount, err1 := parseHunkRange(fields[1][1:])
+	_, newCount, err2 := parseHunkRange(fields[2][1:])
+	if err1 != nil || err2 != nil {
+		return patchHunk{}, fmt.Errorf("invalid hunk header: %q", s)
+	}
+	return patchHunk{oldStart: oldStart, oldCount: oldCount, newCount: newCount}, nil
+}
+
+func parseHunkRange(s string) (start, count int, err error) {
+	count = 1
+	if i := strings.IndexByte(s, ','); i >= 0 {
+		if count, err = strconv.Atoi(s[i+1:]); err != nil {
+			return 0, 0, err
+		}
+		s = s[:i]
+	}
+	start, err = strconv.Atoi(s)
+	return start, count, err
+}
+
+// applyUnifiedPatch applies hunks to content. Hunks may drift from their
+// header line numbers; the nearest exact match of the old lines is used.
+func applyUnifiedPatch(content string, patch string) (string, error) {
+	hunks, err := parseUnifiedPatch(patch)
+	if err != nil {
+		return "", err
+	}
+	lines := splitLines(content)
+	crlf := strings.Contains(content, "\r\n")
+
+	var out []string
+	pos := 0 // index into lines of the next unconsumed line
+	for n, h := range hunks {
+		var old, repl []string
+		for _, l := range h.lines {
+			line := l.line
+			if crlf && strings.HasSuffix(line, "\n") {
+				line = strings.TrimSuffix(line, "\n") + "\r\n"
+			}
+			if l.kind != '+' {
+				old = append(old, line)
+			}
+			if l.kind != '-' {
+				repl = append(repl, line)
+			}
+		}
+		want := h.oldStart - 1
+		if len(old) == 0 {
+			// Pure insertion: header line is the line after which to insert.
+			want = h.oldStart
+		}
+		at := findLines(lines, old, pos, want)
+		if at < 0 {
+			return "", fmt.Errorf("hunk %d (@@ -%d) does not apply: context not found", n+1, h.oldStart)
+		}
+		out = append(out, lines[pos:at]...)
+		out = append(out, repl...)
+		pos = at + len(old)
+	}
+	out = append(out, lines[pos:]...)
+	return strings.Join(out, ""), nil
+}
+
+// findLines returns the index >= from closest to want where needle occurs in lines.
+// Trailing whitespace differences are tolerated.
+func findLines(lines, needle []string, from, want int) int {
+	if want < from {
+		want = from
+	}
+	if want > len(lines) {
+		want = len(lines)
+	}
+	match := func(at int) bool {
+		if at < from || at+len(needle) > len(lines) {
+			return false
+		}
+		for i, l := range needle {
+			if strings.TrimRight(lines[at+i], " \t\r\n") != strings.TrimRight(l, " \t\r\n") {
+				return false
+			}
+			// A missing final newline must match exactly so it is not silently added or removed.
+			if strings.HasSuffix(lines[at+i], "\n") != strings.HasSuffix(l, "\n") {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 0; d <= len(lines); d++ {
+		if match(want - d) {
+			return want - d
+		}
+		if d > 0 && match(want+d) {
+			return want + d
+		}
+	}
+	return -1
+}
@@ -0,0 +1,299 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EditFileTool edits existing text files in place and returns a unified diff.
+//
+// Paths follow the read_file policy (no "..", deny_paths, allowed_dirs, no
+// symlinks). AllowedDirs must be non-empty; the command layer defaults it to
+// file_cache_dir. Relative paths are resolved under the first allowed dir.
+type EditFileTool struct {
+	Enabled     bool
+	MaxBytes    int
+	DenyPaths   []string
+	AllowedDirs []string
+}
+
+func NewEditFileTool(enabled bool, maxBytes int, denyPaths []string, allowedDirs []string) *EditFileTool {
+	if maxBytes <= 0 {
+		maxBytes = 1024 * 1024
+	}
+	return &EditFileTool{
+		Enabled:     enabled,
+		MaxBytes:    maxBytes,
+		DenyPaths:   denyPaths,
+		AllowedDirs: allowedDirs,
+	}
+}
+
+func (t *EditFileTool) Name() string { return "edit_file" }
+
+func (t *EditFileTool) Description() string {
+	return "Edits an existing text file and returns a unified diff of the change. " +
+		"Use exactly one of: old_string/new_string (exact replace), start_line/end_line/new_string (replace a line range), or patch (apply a unified diff)."
+}
+
+func (t *EditFileTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File to edit. Relative paths are resolved under the first allowed directory (file_cache_dir by default).",
+			},
+			"old_string": map[string]any{
+				"type":        "string",
+				"description": "Exact text to replace. Must match exactly once unless replace_all is true.",
+			},
+			"new_string": map[string]any{
+				"type":        "string",
+				"description": "Replacement text (for old_string or for the start_line..end_line range).",
+			},
+			"replace_all": map[string]any{
+				"type":        "boolean",
+				"description": "Replace every occurrence of old_string.",
+			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "First line (1-based) of the range to replace.",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "Last line (inclusive) of the range to replace. Use start_line-1 to insert before start_line without removing lines.",
+			},
+			"patch": map[string]any{
+				"type":        "string",
+				"description": "Unified diff for this single file (hunks starting with @@). Context is matched near the hunk's line number.",
+			},
+			"dry_run": map[string]any{
+				"type":        "boolean",
+				"description": "If true, returns the diff without writing the file.",
+			},
+		},
+		"required": []string{"path"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *EditFileTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	if !t.Enabled {
+		return "", fmt.Errorf("edit_file tool is disabled (enable via config: tools.edit_file.enabled=true)")
+	}
+
+	path, _ := params["path"].(string)
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("missing required param: path")
+	}
+	path, err := t.resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("edit_file: not a regular file: %s", path)
+	}
+	if t.MaxBytes > 0 && fi.Size() > int64(t.MaxBytes) {
+		return "", fmt.Errorf("file too large (%d bytes > %d max)", fi.Size(), t.MaxBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	before := string(data)
+
+	after, err := applyEdit(before, params)
+	if err != nil {
+		return "", err
+	}
+	if t.MaxBytes > 0 && len(after) > t.MaxBytes {
+		return "", fmt.Errorf("edited content too large (%d bytes > %d max)", len(after), t.MaxBytes)
+	}
+
+	diff := unifiedDiff(filepath.Base(path), before, after)
+	if diff == "" {
+		return "no changes", nil
+	}
+	dryRun, _ := params["dry_run"].(bool)
+	if !dryRun {
+		if err := writeFileAtomic(path, []byte(after), fi.Mode().Perm()); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\n", path)
+	fmt.Fprintf(&b, "dry_run: %t\n", dryRun)
+	b.WriteString("diff:\n")
+	b.WriteString(diff)
+	return b.String(), nil
+}
+
+func (t *EditFileTool) resolvePath(path string) (string, error) {
+	if len(t.AllowedDirs) == 0 {
+		return "", fmt.Errorf("edit_file: no allowed directories configured (tools.edit_file.allowed_dirs)")
+	}
+	path = expandHomePath(path)
+	if !filepath.IsAbs(path) && !containsDotDot(path) {
+		path = filepath.Join(expandHomePath(strings.TrimSpace(t.AllowedDirs[0])), path)
+	}
+	cleaned, err := checkFilePathPolicy("edit_file", path, t.DenyPaths, t.AllowedDirs)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(cleaned)
+	if err != nil {
+		return "", err
+	}
+	// The symlink check above only covers the final element; also make sure no
+	// parent directory symlink leads outside the allowed dirs.
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", err
+	}
+	var realDirs []string
+	for _, d := range t.AllowedDirs {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		if r, err := filepath.EvalSymlinks(expandHomePath(d)); err == nil {
+			realDirs = append(realDirs, r)
+		}
+	}
+	if !isWithinAnyDir(real, realDirs) {
+		return "", fmt.Errorf("edit_file denied: path %q resolves outside the allowed directories", path)
+	}
+	return abs, nil
+}
+
+// applyEdit dispatches to the edit mode selected by params.
+func applyEdit(content string, params map[string]any) (string, error) {
+	oldStr, hasOld := params["old_string"].(string)
+	newStr, hasNew := params["new_string"].(string)
+	patch, _ := params["patch"].(string)
+	_, hasStart := params["start_line"]
+
+	modes := 0
+	for _, on := range []bool{hasOld, hasStart, strings.TrimSpace(patch) != ""} {
+		if on {
+			modes++
+		}
+	}
+	if modes != 1 {
+		return "", fmt.Errorf("specify exactly one of old_string, start_line or patch")
+	}
+
+	switch {
+	case hasOld:
+		if !hasNew {
+			return "", fmt.Errorf("missing required param: new_string")
+		}
+		replaceAll, _ := params["replace_all"].(bool)
+		return replaceExact(content, oldStr, newStr, replaceAll)
+	case hasStart:
+		if !hasNew {
+			return "", fmt.Errorf("missing required param: new_string")
+		}
+		start, ok := asInt64(params["start_line"])
+		if !ok {
+			return "", fmt.Errorf("invalid param: start_line")
+		}
+		end := start
+		if v, ok := params["end_line"]; ok {
+			if end, ok = asInt64(v); !ok {
+				return "", fmt.Errorf("invalid param: end_line")
+			}
+		}
+		return replaceLineRange(content, int(start), int(end), newStr)
+	default:
+		return applyUnifiedPatch(content, patch)
+	}
+}
+
+func replaceExact(content, oldStr, newStr string, replaceAll bool) (string, error) {
+	if oldStr == "" {
+		return "", fmt.Errorf("old_string must not be empty")
+	}
+	n := strings.Count(content, oldStr)
+	switch {
+	case n == 0:
+		return "", fmt.Errorf("old_string not found in file")
+	case n > 1 && !replaceAll:
+		return "", fmt.Errorf("old_string matches %d times; add surrounding context to make it unique or set replace_all=true", n)
+	}
+	return strings.ReplaceAll(content, oldStr, newStr), nil
+}
+
+func replaceLineRange(content string, start, end int, newStr string) (string, error) {
+	lines := splitLines(content)
+	if start < 1 || start > len(lines)+1 {
+		return "", fmt.Errorf("start_line %d out of range (file has %d lines)", start, len(lines))
+	}
+	if end < start-1 || end > len(lines) {
+		return "", fmt.Errorf("end_line %d out of range (start_line=%d, file has %d lines)", end, start, len(lines))
+	}
+	nl := "\n"
+	if strings.Contains(content, "\r\n") {
+		nl = "\r\n"
+	}
+	// Keep line structure: the replacement ends with a newline unless it
+	// replaces the file's unterminated last line.
+	if newStr != "" && !strings.HasSuffix(newStr, "\n") {
+		if end < len(lines) || strings.HasSuffix(content, "\n") {
+			newStr += nl
+		}
+	}
+	// Appending after an unterminated last line needs a separator first.
+	if newStr != "" && start > len(lines) && content != "" && !strings.HasSuffix(content, "\n") {
+		newStr = nl + newStr
+	}
+	var b strings.Builder
+	for _, l := range lines[:start-1] {
+		b.WriteString(l)
+	}
+	b.WriteString(newStr)
+	for _, l := range lines[end:] {
+		b.WriteString(l)
+	}
+	return b.String(), nil
+}
+
+// writeFileAtomic replaces path via a temp file in the same directory.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
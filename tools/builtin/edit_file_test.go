@@ -0,0 +1,178 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newEditFixture(t *testing.T, content string) (*EditFileTool, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(path, []byte(content), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	return NewEditFileTool(true, 0, []string{"config.yaml"}, []string{dir}), path
+}
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestEditFileTool_ExactReplace(t *testing.T) {
+	tool, path := newEditFixture(t, "a = 1\nb = 2\nc = 1\n")
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "old_string": "= 1", "new_string": "= 9"}); err == nil || !strings.Contains(err.Error(), "matches 2 times") {
+		t.Fatalf("expected ambiguity error, got %v", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "old_string": "zzz", "new_string": "x"}); err == nil {
+		t.Fatalf("expected not found error")
+	}
+
+	out, err := tool.Execute(context.Background(), map[string]any{"path": "app.conf", "old_string": "b = 2", "new_string": "b = 3"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(out, "@@ -1,3 +1,3 @@\n a = 1\n-b = 2\n+b = 3\n c = 1\n") {
+		t.Fatalf("unexpected diff:\n%s", out)
+	}
+	if got := readString(t, path); got != "a = 1\nb = 3\nc = 1\n" {
+		t.Fatalf("file=%q", got)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o640 {
+		t.Fatalf("mode not preserved: %v", fi.Mode())
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "old_string": "= 1", "new_string": "= 0", "replace_all": true}); err != nil {
+		t.Fatalf("replace_all: %v", err)
+	}
+	if got := readString(t, path); got != "a = 0\nb = 3\nc = 0\n" {
+		t.Fatalf("file=%q", got)
+	}
+}
+
+func TestEditFileTool_LineRangeAndDryRun(t *testing.T) {
+	tool, path := newEditFixture(t, "one\ntwo\nthree\nfour")
+
+	out, err := tool.Execute(context.Background(), map[string]any{"path": path, "start_line": 2, "end_line": 3, "new_string": "TWO-THREE", "dry_run": true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(out, "dry_run: true") || !strings.Contains(out, "+TWO-THREE") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if got := readString(t, path); got != "one\ntwo\nthree\nfour" {
+		t.Fatalf("dry run modified file: %q", got)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "start_line": 2, "end_line": 3, "new_string": "TWO-THREE"}); err != nil {
+		t.Fatalf("range: %v", err)
+	}
+	// Insert before line 1 without removing anything.
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "start_line": 1, "end_line": 0, "new_string": "zero"}); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if got := readString(t, path); got != "zero\none\nTWO-THREE\nfour" {
+		t.Fatalf("file=%q", got)
+	}
+	// Append after the unterminated last line.
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "start_line": 5, "end_line": 4, "new_string": "five"}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	if got := readString(t, path); got != "zero\none\nTWO-THREE\nfour\nfive" {
+		t.Fatalf("file=%q", got)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "start_line": 9, "new_string": "x"}); err == nil {
+		t.Fatalf("expected out of range error")
+	}
+}
+
+func TestEditFileTool_Patch(t *testing.T) {
+	var b strings.Builder
+	for i := 1; i <= 20; i++ {
+		b.WriteString("line ")
+		b.WriteString(string(rune('a' + i - 1)))
+		b.WriteString("\n")
+	}
+	tool, path := newEditFixture(t, b.String())
+
+	// Header line numbers are off by two; the hunk should still apply.
+	patch := "--- a/app.conf\n+++ b/app.conf\n@@ -7,3 +7,4 @@\n line e\n-line f\n+line F\n+line f2\n line g\n@@ -17,2 +18,1 @@\n line s\n-line t\n"
+	out, err := tool.Execute(context.Background(), map[string]any{"path": path, "patch": patch})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	got := readString(t, path)
+	if !strings.Contains(got, "line e\nline F\nline f2\nline g\n") || strings.HasSuffix(got, "line t\n") {
+		t.Fatalf("patch not applied:\n%s", got)
+	}
+	if !strings.Contains(out, "+line F") || !strings.Contains(out, "-line t") {
+		t.Fatalf("unexpected diff:\n%s", out)
+	}
+
+	bad := "@@ -1,1 +1,1 @@\n-nope\n+yes\n"
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": path, "patch": bad}); err == nil || !strings.Contains(err.Error(), "does not apply") {
+		t.Fatalf("expected apply failure, got %v", err)
+	}
+}
+
+func TestEditFileTool_PathPolicy(t *testing.T) {
+	tool, path := newEditFixture(t, "x\n")
+	dir := filepath.Dir(path)
+
+	outside := filepath.Join(t.TempDir(), "other.txt")
+	_ = os.WriteFile(outside, []byte("x\n"), 0o644)
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": outside, "old_string": "x", "new_string": "y"}); err == nil {
+		t.Fatalf("expected outside-dir edit to be denied")
+	}
+
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": link, "old_string": "x", "new_string": "y"}); err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Fatalf("expected symlink denial, got %v", err)
+	}
+
+	linkDir := filepath.Join(dir, "sub")
+	if err := os.Symlink(filepath.Dir(outside), linkDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": "sub/other.txt", "old_string": "x", "new_string": "y"}); err == nil {
+		t.Fatalf("expected parent-symlink escape to be denied")
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("k: v\n"), 0o644)
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": "config.yaml", "old_string": "v", "new_string": "w"}); err == nil {
+		t.Fatalf("expected deny_paths to apply")
+	}
+	if got := readString(t, outside); got != "x\n" {
+		t.Fatalf("outside file modified: %q", got)
+	}
+}
+
+func TestUnifiedDiff_NoNewlineAndHunks(t *testing.T) {
+	d := unifiedDiff("f", "a\nb", "a\nc")
+	if !strings.Contains(d, "-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n") {
+		t.Fatalf("diff:\n%s", d)
+	}
+	// Round-trip: applying our own diff reproduces the target.
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	after := strings.Replace(strings.Replace(before, "2\n", "two\n", 1), "14\n", "", 1)
+	diff := unifiedDiff("f", before, after)
+	if strings.Count(diff, "@@ -") != 2 {
+		t.Fatalf("expected two hunks:\n%s", diff)
+	}
+	got, err := applyUnifiedPatch(before, diff)
+	if err != nil || got != after {
+		t.Fatalf("round trip: %v\n%q", err, got)
+	}
+}
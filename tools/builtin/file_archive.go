@@ -0,0 +1,678 @@
+package builtin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileArchiveTool lists, extracts and creates zip, tar and tar.gz archives.
+// Archives and sources are read under the read_file policy (file_cache_dir is
+// always readable); everything it writes stays under file_cache_dir.
+type FileArchiveTool struct {
+	Policy        FSPolicy
+	BaseDir       string // file_cache_dir
+	MaxEntries    int
+	MaxTotalBytes int64 // uncompressed bytes extracted, or source bytes archived, per call
+}
+
+func NewFileArchiveTool(policy FSPolicy, baseDir string, maxEntries int, maxTotalBytes int64) *FileArchiveTool {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	if maxTotalBytes <= 0 {
+		maxTotalBytes = 512 * 1024 * 1024
+	}
+	return &FileArchiveTool{
+		Policy:        policy,
+		BaseDir:       strings.TrimSpace(baseDir),
+		MaxEntries:    maxEntries,
+		MaxTotalBytes: maxTotalBytes,
+	}
+}
+
+const fileArchiveMaxListed = 200
+
+func (t *FileArchiveTool) Name() string { return "file_archive" }
+
+func (t *FileArchiveTool) Description() string {
+	return "Works with zip, tar and tar.gz archives without bash. op=list shows the entries; op=extract unpacks into a directory under file_cache_dir; " +
+		"op=create packs files/directories into a new archive under file_cache_dir (e.g. to send generated outputs with telegram_send_file)."
+}
+
+func (t *FileArchiveTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"op": map[string]any{
+				"type":        "string",
+				"enum":        []string{"list", "extract", "create"},
+				"description": "Operation.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "list/extract: the archive. create: the archive to write (.zip, .tar, .tar.gz or .tgz). Relative paths are under file_cache_dir.",
+			},
+			"dest": map[string]any{
+				"type":        "string",
+				"description": "extract: directory under file_cache_dir (default: extracted/<archive name>).",
+			},
+			"files": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "extract: only entries matching these glob patterns (** matches directories). create: files or directories to add.",
+			},
+			"overwrite": map[string]any{
+				"type":        "boolean",
+				"description": "extract: allow extracting into a non-empty directory. create: replace an existing archive.",
+			},
+		},
+		"required": []string{"op", "path"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *FileArchiveTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	op, _ := params["op"].(string)
+	op = strings.ToLower(strings.TrimSpace(op))
+	p, _ := params["path"].(string)
+	if strings.TrimSpace(p) == "" {
+		return "", fmt.Errorf("missing required param: path")
+	}
+	files, ok := asStringSlice(params["files"])
+	if !ok {
+		return "", fmt.Errorf("invalid param: files must be an array of strings")
+	}
+	overwrite, _ := params["overwrite"].(bool)
+	switch op {
+	case "":
+		return "", fmt.Errorf("missing required param: op")
+	case "list":
+		return t.list(ctx, p)
+	case "extract":
+		dest, _ := params["dest"].(string)
+		return t.extract(ctx, p, dest, files, overwrite)
+	case "create":
+		if len(files) == 0 {
+			return "", fmt.Errorf("missing required param: files")
+		}
+		return t.create(ctx, p, files, overwrite)
+	default:
+		return "", fmt.Errorf("unsupported op: %s (use list, extract or create)", op)
+	}
+}
+
+type archiveEntry struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	kind    byte // 'f' file, 'd' dir, 'l' link, 'o' other
+}
+
+// archiveFormat detects zip, tar.gz or tar from the file header, falling back
+// to the extension.
+func archiveFormat(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return "zip", nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return "tar.gz", nil
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return "tar", nil
+	}
+	if f := archiveFormatFromName(p); f != "" {
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported archive format: %s (zip, tar and tar.gz are supported)", filepath.Base(p))
+}
+
+func archiveFormatFromName(p string) string {
+	lower := strings.ToLower(p)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar"
+	}
+	return ""
+}
+
+// walkArchive calls fn for every entry; open returns the entry's content.
+func walkArchive(ctx context.Context, p, format string, fn func(e archiveEntry, open func() (io.ReadCloser, error)) error) error {
+	if format == "zip" {
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		for _, zf := range zr.File {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			e := archiveEntry{name: zf.Name, size: int64(zf.UncompressedSize64), mode: zf.Mode(), modTime: zf.Modified, kind: 'f'}
+			switch {
+			case zf.Mode().IsDir() || strings.HasSuffix(zf.Name, "/"):
+				e.kind = 'd'
+			case zf.Mode()&fs.ModeSymlink != 0:
+				e.kind = 'l'
+			case !zf.Mode().IsRegular():
+				e.kind = 'o'
+			}
+			if err := fn(e, zf.Open); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if format == "tar.gz" {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading tar: %w", err)
+		}
+		e := archiveEntry{name: h.Name, size: h.Size, mode: h.FileInfo().Mode(), modTime: h.ModTime, kind: 'o'}
+		switch h.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			e.kind = 'f'
+		case tar.TypeDir:
+			e.kind = 'd'
+		case tar.TypeSymlink, tar.TypeLink:
+			e.kind = 'l'
+		case tar.TypeXGlobalHeader:
+			continue
+		}
+		if err := fn(e, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }); err != nil {
+			return err
+		}
+	}
+}
+
+var windowsDriveRe = regexp.MustCompile(`^[A-Za-z]:`)
+
+// safeEntryPath returns the slash-separated relative path an entry may be
+// extracted to, or an error for absolute paths and ".." components (zip slip).
+func safeEntryPath(name string) (string, error) {
+	n := strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(n, "/") || windowsDriveRe.MatchString(n) {
+		return "", fmt.Errorf("absolute path")
+	}
+	for _, part := range strings.Split(n, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("path traversal")
+		}
+	}
+	n = path.Clean(n)
+	if n == "." || n == "" {
+		return "", fmt.Errorf("empty name")
+	}
+	return n, nil
+}
+
+func (t *FileArchiveTool) list(ctx context.Context, raw string) (string, error) {
+	p, err := t.Policy.resolveCachePath(t.Name(), raw, t.BaseDir)
+	if err != nil {
+		return "", err
+	}
+	format, err := archiveFormat(p)
+	if err != nil {
+		return "", err
+	}
+	var (
+		b            strings.Builder
+		count, files int
+		total        int64
+	)
+	err = walkArchive(ctx, p, format, func(e archiveEntry, _ func() (io.ReadCloser, error)) error {
+		count++
+		if e.kind == 'f' {
+			files++
+			total += e.size
+		}
+		if count > fileArchiveMaxListed {
+			return nil
+		}
+		note := ""
+		if _, err := safeEntryPath(e.name); err != nil {
+			note = fmt.Sprintf("  (unsafe: %v; skipped on extract)", err)
+		} else if e.kind == 'l' || e.kind == 'o' {
+			note = "  (link/special file; skipped on extract)"
+		}
+		size := fmt.Sprint(e.size)
+		if e.kind == 'd' {
+			size = "-"
+		}
+		fmt.Fprintf(&b, "%10s  %s  %s%s\n", size, e.modTime.Format("2006-01-02 15:04"), e.name, note)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if count > fileArchiveMaxListed {
+		fmt.Fprintf(&b, "...(%d more entries)\n", count-fileArchiveMaxListed)
+	}
+	return fmt.Sprintf("archive: %s\nformat: %s\nentries: %d\nfiles: %d\nuncompressed_bytes: %d\n\n", p, format, count, files, total) + b.String(), nil
+}
+
+func (t *FileArchiveTool) extract(ctx context.Context, raw, dest string, patterns []string, overwrite bool) (string, error) {
+	p, err := t.Policy.resolveCachePath(t.Name(), raw, t.BaseDir)
+	if err != nil {
+		return "", err
+	}
+	format, err := archiveFormat(p)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(dest) == "" {
+		stem := filepath.Base(p)
+		for _, ext := range []string{".tar.gz", ".tgz", ".tar", ".zip"} {
+			if strings.HasSuffix(strings.ToLower(stem), ext) {
+				stem = stem[:len(stem)-len(ext)]
+				break
+			}
+		}
+		dest = path.Join("extracted", strings.Trim(downloadNameRe.ReplaceAllString(stem, "_"), "._"))
+	}
+	_, destAbs, err := resolveWritePath(t.BaseDir, dest)
+	if err != nil {
+		return "", err
+	}
+	if entries, err := os.ReadDir(destAbs); err == nil && len(entries) > 0 && !overwrite {
+		return "", fmt.Errorf("dest %s is not empty (pass overwrite=true or another dest)", destAbs)
+	}
+	if err := os.MkdirAll(destAbs, 0o700); err != nil {
+		return "", err
+	}
+
+	var (
+		extracted []string
+		skipped   []string
+		count     int
+		written   int64
+	)
+	err = walkArchive(ctx, p, format, func(e archiveEntry, open func() (io.ReadCloser, error)) error {
+		count++
+		if count > t.MaxEntries {
+			return fmt.Errorf("archive has more than %d entries (tools.file_archive.max_entries)", t.MaxEntries)
+		}
+		rel, err := safeEntryPath(e.name)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", e.name, err))
+			return nil
+		}
+		if len(patterns) > 0 && !matchAnyGlob(patterns, rel) {
+			return nil
+		}
+		target := filepath.Join(destAbs, filepath.FromSlash(rel))
+		if !isWithinDir(destAbs, target) {
+			skipped = append(skipped, fmt.Sprintf("%s (outside dest)", e.name))
+			return nil
+		}
+		switch e.kind {
+		case 'd':
+			if err := mkdirNoSymlink(destAbs, rel); err != nil {
+				return err
+			}
+			return nil
+		case 'l', 'o':
+			skipped = append(skipped, fmt.Sprintf("%s (link or special file)", e.name))
+			return nil
+		}
+		if written+e.size > t.MaxTotalBytes {
+			return fmt.Errorf("extraction exceeds %d bytes (tools.file_archive.max_total_bytes)", t.MaxTotalBytes)
+		}
+		if err := mkdirNoSymlink(destAbs, path.Dir(rel)); err != nil {
+			return err
+		}
+		if fi, err := os.Lstat(target); err == nil && !fi.Mode().IsRegular() {
+			return fmt.Errorf("refusing to overwrite non-regular file %s", target)
+		}
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		perm := fs.FileMode(0o644)
+		if e.mode&0o111 != 0 {
+			perm = 0o755
+		}
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+		if err != nil {
+			return err
+		}
+		// Headers can lie about sizes, so the limit is enforced on the bytes actually written.
+		n, err := io.Copy(out, io.LimitReader(rc, t.MaxTotalBytes-written+1))
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		written += n
+		if written > t.MaxTotalBytes {
+			_ = os.Remove(target)
+			return fmt.Errorf("extraction exceeds %d bytes (tools.file_archive.max_total_bytes)", t.MaxTotalBytes)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.name, err)
+		}
+		extracted = append(extracted, rel)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("extract %s: %w (%d files were extracted to %s)", filepath.Base(p), err, len(extracted), destAbs)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "dest: %s\nformat: %s\nfiles: %d\nbytes: %d\n", destAbs, format, len(extracted), written)
+	for i, rel := range extracted {
+		if i == fileArchiveMaxListed {
+			fmt.Fprintf(&b, "...(%d more)\n", len(extracted)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s\n", rel)
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "skipped: %d\n", len(skipped))
+		for i, s := range skipped {
+			if i == 20 {
+				fmt.Fprintf(&b, "...(%d more)\n", len(skipped)-i)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", s)
+		}
+	}
+	return b.String(), nil
+}
+
+func matchAnyGlob(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if matchGlobPath(p, rel) || matchGlobPath(p+"/**", rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// mkdirNoSymlink creates rel (slash-separated) under base, refusing to pass
+// through symlinks that may already exist there.
+func mkdirNoSymlink(base, rel string) error {
+	cur := base
+	for _, part := range strings.Split(rel, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		cur = filepath.Join(cur, part)
+		fi, err := os.Lstat(cur)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if err := os.Mkdir(cur, 0o755); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case fi.Mode()&fs.ModeSymlink != 0:
+			return fmt.Errorf("refusing to extract through symlink %s", cur)
+		case !fi.IsDir():
+			return fmt.Errorf("%s exists and is not a directory", cur)
+		}
+	}
+	return nil
+}
+
+type archiveSource struct {
+	abs  string
+	name string // slash-separated name inside the archive
+	info fs.FileInfo
+}
+
+func (t *FileArchiveTool) create(ctx context.Context, raw string, files []string, overwrite bool) (string, error) {
+	format := archiveFormatFromName(raw)
+	if format == "" {
+		return "", fmt.Errorf("archive path must end in .zip, .tar, .tar.gz or .tgz")
+	}
+	_, outAbs, err := resolveWritePath(t.BaseDir, raw)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Lstat(outAbs); err == nil && !overwrite {
+		return "", fmt.Errorf("%s already exists (pass overwrite=true)", outAbs)
+	}
+
+	var (
+		sources []archiveSource
+		total   int64
+	)
+	names := map[string]bool{}
+	add := func(s archiveSource) error {
+		if names[s.name] {
+			return fmt.Errorf("duplicate archive entry %q", s.name)
+		}
+		names[s.name] = true
+		if len(sources) >= t.MaxEntries {
+			return fmt.Errorf("more than %d entries (tools.file_archive.max_entries)", t.MaxEntries)
+		}
+		if s.info.Mode().IsRegular() {
+			total += s.info.Size()
+			if total > t.MaxTotalBytes {
+				return fmt.Errorf("sources exceed %d bytes (tools.file_archive.max_total_bytes)", t.MaxTotalBytes)
+			}
+		}
+		sources = append(sources, s)
+		return nil
+	}
+	for _, f := range files {
+		src, err := t.Policy.resolveCachePath(t.Name(), f, t.BaseDir)
+		if err != nil {
+			return "", err
+		}
+		srcAbs, err := filepath.Abs(src)
+		if err != nil {
+			return "", err
+		}
+		fi, err := os.Lstat(srcAbs)
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("refusing symlink %s", srcAbs)
+		}
+		if !fi.IsDir() {
+			if err := add(archiveSource{abs: srcAbs, name: filepath.Base(srcAbs), info: fi}); err != nil {
+				return "", err
+			}
+			continue
+		}
+		parent := filepath.Dir(srcAbs)
+		err = filepath.WalkDir(srcAbs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if p == outAbs || strings.HasPrefix(p, outAbs+".") {
+				return nil // the archive being written
+			}
+			if _, denied := denyPath(p, t.Policy.DenyPaths); denied || d.Type()&fs.ModeSymlink != 0 {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.IsDir() && !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(parent, p)
+			if err != nil {
+				return err
+			}
+			return add(archiveSource{abs: p, name: filepath.ToSlash(rel), info: info})
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	sort.SliceStable(sources, func(i, j int) bool { return sources[i].name < sources[j].name })
+
+	tmp, err := os.CreateTemp(filepath.Dir(outAbs), "."+filepath.Base(outAbs)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if format == "zip" {
+		err = writeZip(ctx, tmp, sources)
+	} else {
+		err = writeTar(ctx, tmp, sources, format == "tar.gz")
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), outAbs); err != nil {
+		return "", err
+	}
+	fi, err := os.Stat(outAbs)
+	if err != nil {
+		return "", err
+	}
+	fileCount := 0
+	for _, s := range sources {
+		if s.info.Mode().IsRegular() {
+			fileCount++
+		}
+	}
+	return fmt.Sprintf("path: %s\nformat: %s\nfiles: %d\nsource_bytes: %d\nbytes: %d\n", outAbs, format, fileCount, total, fi.Size()), nil
+}
+
+func writeZip(ctx context.Context, w io.Writer, sources []archiveSource) error {
+	zw := zip.NewWriter(w)
+	for _, s := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h, err := zip.FileInfoHeader(s.info)
+		if err != nil {
+			return err
+		}
+		h.Name = s.name
+		if s.info.IsDir() {
+			h.Name += "/"
+			h.Method = zip.Store
+		} else {
+			h.Method = zip.Deflate
+		}
+		fw, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		if !s.info.IsDir() {
+			if err := copyFileTo(fw, s.abs); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+func writeTar(ctx context.Context, w io.Writer, sources []archiveSource, gz bool) error {
+	var gw *gzip.Writer
+	if gz {
+		gw = gzip.NewWriter(w)
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	for _, s := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		h, err := tar.FileInfoHeader(s.info, "")
+		if err != nil {
+			return err
+		}
+		h.Name = s.name
+		if s.info.IsDir() {
+			h.Name += "/"
+		}
+		// Don't leak local account names into shared archives.
+		h.Uid, h.Gid, h.Uname, h.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if !s.info.IsDir() {
+			if err := copyFileTo(tw, s.abs); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gw != nil {
+		return gw.Close()
+	}
+	return nil
+}
+
+func copyFileTo(w io.Writer, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,146 @@
+package builtin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileArchiveTool_CreateListExtract(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "report", "data"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(base, "report", "summary.md"), "# Summary\n")
+	writeFile(t, filepath.Join(base, "report", "data", "rows.csv"), "a,b\n1,2\n")
+	writeFile(t, filepath.Join(base, "chart.png"), "\x89PNG")
+	tool := NewFileArchiveTool(FSPolicy{}, base, 0, 0)
+	ctx := context.Background()
+
+	for _, name := range []string{"out.zip", "out.tar.gz", "out.tar"} {
+		out, err := tool.Execute(ctx, map[string]any{"op": "create", "path": name, "files": []any{"report", "chart.png"}})
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if !strings.Contains(out, "files: 3\n") {
+			t.Fatalf("create %s:\n%s", name, out)
+		}
+		if _, err := tool.Execute(ctx, map[string]any{"op": "create", "path": name, "files": []any{"chart.png"}}); err == nil {
+			t.Fatalf("expected %s to exist without overwrite", name)
+		}
+
+		out, err = tool.Execute(ctx, map[string]any{"op": "list", "path": name})
+		if err != nil {
+			t.Fatalf("list %s: %v", name, err)
+		}
+		for _, want := range []string{"files: 3\n", "report/data/rows.csv", "chart.png"} {
+			if !strings.Contains(out, want) {
+				t.Fatalf("list %s: missing %q in\n%s", name, want, out)
+			}
+		}
+
+		out, err = tool.Execute(ctx, map[string]any{"op": "extract", "path": name, "dest": "x/" + name, "files": []any{"report"}})
+		if err != nil {
+			t.Fatalf("extract %s: %v", name, err)
+		}
+		got, err := os.ReadFile(filepath.Join(base, "x", name, "report", "data", "rows.csv"))
+		if err != nil || string(got) != "a,b\n1,2\n" {
+			t.Fatalf("extract %s: %v %q\n%s", name, err, got, out)
+		}
+		if _, err := os.Stat(filepath.Join(base, "x", name, "chart.png")); err == nil {
+			t.Fatalf("extract %s: files filter not applied", name)
+		}
+	}
+
+	if _, err := tool.Execute(ctx, map[string]any{"op": "extract", "path": "out.zip", "dest": "x/out.zip"}); err == nil {
+		t.Fatalf("expected non-empty dest to be refused")
+	}
+	if _, err := tool.Execute(ctx, map[string]any{"op": "create", "path": "../escape.zip", "files": []any{"chart.png"}}); err == nil {
+		t.Fatalf("expected archive outside file_cache_dir to be refused")
+	}
+}
+
+func TestFileArchiveTool_ExtractUnsafeEntries(t *testing.T) {
+	base := t.TempDir()
+	var zb bytes.Buffer
+	zw := zip.NewWriter(&zb)
+	for _, name := range []string{"../evil.txt", "/etc/evil", `C:\evil.txt`, "ok/../../evil2.txt", "ok/good.txt"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte("x"))
+	}
+	zw.Close()
+	writeFile(t, filepath.Join(base, "slip.zip"), zb.String())
+
+	var tb bytes.Buffer
+	gz := gzip.NewWriter(&tb)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"})
+	tw.WriteHeader(&tar.Header{Name: "link/passwd", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	tw.Write([]byte("x"))
+	tw.WriteHeader(&tar.Header{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	tw.Write([]byte("a"))
+	tw.Close()
+	gz.Close()
+	writeFile(t, filepath.Join(base, "links.tgz"), tb.String())
+
+	tool := NewFileArchiveTool(FSPolicy{}, base, 0, 0)
+	ctx := context.Background()
+
+	out, err := tool.Execute(ctx, map[string]any{"op": "extract", "path": "slip.zip"})
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if !strings.Contains(out, "files: 1\n") || !strings.Contains(out, "skipped: 4\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	for _, p := range []string{filepath.Join(base, "evil.txt"), filepath.Join(base, "extracted", "evil2.txt"), filepath.Join(filepath.Dir(base), "evil.txt")} {
+		if _, err := os.Stat(p); err == nil {
+			t.Fatalf("zip slip wrote %s", p)
+		}
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"op": "extract", "path": "links.tgz"})
+	if err != nil {
+		t.Fatalf("extract links: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(base, "extracted", "links", "link")); err != nil {
+		// The symlink is skipped, so link/ is created as a plain directory.
+		t.Fatalf("expected link/ dir: %v\n%s", err, out)
+	}
+	if fi, _ := os.Lstat(filepath.Join(base, "extracted", "links", "link")); fi.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("symlink was extracted")
+	}
+}
+
+func TestFileArchiveTool_Limits(t *testing.T) {
+	base := t.TempDir()
+	var zb bytes.Buffer
+	zw := zip.NewWriter(&zb)
+	w, _ := zw.Create("big.txt")
+	w.Write(bytes.Repeat([]byte("0"), 10000)) // compresses to a few bytes
+	for i := 0; i < 3; i++ {
+		zw.Create(strings.Repeat("d", i+1) + "/")
+	}
+	zw.Close()
+	writeFile(t, filepath.Join(base, "bomb.zip"), zb.String())
+	ctx := context.Background()
+
+	tool := NewFileArchiveTool(FSPolicy{}, base, 0, 1000)
+	if _, err := tool.Execute(ctx, map[string]any{"op": "extract", "path": "bomb.zip"}); err == nil || !strings.Contains(err.Error(), "max_total_bytes") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+	tool = NewFileArchiveTool(FSPolicy{}, base, 2, 0)
+	if _, err := tool.Execute(ctx, map[string]any{"op": "extract", "path": "bomb.zip", "dest": "e2"}); err == nil || !strings.Contains(err.Error(), "max_entries") {
+		t.Fatalf("expected entry limit error, got %v", err)
+	}
+	tool = NewFileArchiveTool(FSPolicy{DenyPaths: []string{"bomb.zip"}}, base, 0, 0)
+	if _, err := tool.Execute(ctx, map[string]any{"op": "list", "path": "bomb.zip"}); err == nil {
+		t.Fatalf("expected deny_paths to apply")
+	}
+}
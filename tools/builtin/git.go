@@ -0,0 +1,630 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitTool runs structured git operations inside allowed directories. Read
+// operations are always available; commit and checkout need AllowWrite and are
+// gated by guard approval (guard.git.require_approval).
+type GitTool struct {
+	Policy         FSPolicy
+	Timeout        time.Duration
+	MaxOutputBytes int
+	AllowWrite     bool
+}
+
+func NewGitTool(policy FSPolicy, timeout time.Duration, maxOutputBytes int, allowWrite bool) *GitTool {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = 64 * 1024
+	}
+	return &GitTool{Policy: policy, Timeout: timeout, MaxOutputBytes: maxOutputBytes, AllowWrite: allowWrite}
+}
+
+const (
+	gitDefaultLogCount = 20
+	gitMaxLogCount     = 200
+)
+
+func (t *GitTool) Name() string { return "git" }
+
+func (t *GitTool) Description() string {
+	desc := "Runs git inside an allowed repository and returns parsed, size-capped output. Operations: status, log, diff, show, blame, branches"
+	if t.AllowWrite {
+		desc += ", commit and checkout (these modify the repository and may require approval)"
+	}
+	return desc + ". Prefer this over running git through bash."
+}
+
+func (t *GitTool) ParameterSchema() string {
+	ops := []string{"status", "log", "diff", "show", "blame", "branches"}
+	if t.AllowWrite {
+		ops = append(ops, "commit", "checkout")
+	}
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"op": map[string]any{
+				"type":        "string",
+				"enum":        ops,
+				"description": "Git operation.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Repository directory (default: the first allowed directory, or the current directory).",
+			},
+			"ref": map[string]any{
+				"type":        "string",
+				"description": "Revision: log start point, diff base or range (\"main..HEAD\"), show/blame revision, or checkout target. \"<rev>:<path>\" is not accepted; use file.",
+			},
+			"file": map[string]any{
+				"type":        "string",
+				"description": "Repository-relative file to limit log/diff to; required for blame; with show, returns the file's content at ref.",
+			},
+			"max_count": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("log: number of commits (default %d, max %d).", gitDefaultLogCount, gitMaxLogCount),
+			},
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "diff: show staged changes instead of the working tree.",
+			},
+			"stat": map[string]any{
+				"type":        "boolean",
+				"description": "diff/show: only a diffstat summary.",
+			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "blame: first line.",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "blame: last line.",
+			},
+		},
+		"required": []string{"op"},
+	}
+	if t.AllowWrite {
+		props := s["properties"].(map[string]any)
+		props["message"] = map[string]any{
+			"type":        "string",
+			"description": "commit: commit message.",
+		}
+		props["files"] = map[string]any{
+			"type":        "array",
+			"items":       map[string]any{"type": "string"},
+			"description": "commit: repository-relative files to stage before committing.",
+		}
+		props["all"] = map[string]any{
+			"type":        "boolean",
+			"description": "commit: stage all tracked modified files (git commit -a).",
+		}
+		props["create"] = map[string]any{
+			"type":        "boolean",
+			"description": "checkout: create a new branch named ref from HEAD.",
+		}
+		props["detach"] = map[string]any{
+			"type":        "boolean",
+			"description": "checkout: check out a commit or tag in detached HEAD state.",
+		}
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *GitTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	op, _ := params["op"].(string)
+	op = strings.ToLower(strings.TrimSpace(op))
+	if op == "" {
+		return "", fmt.Errorf("missing required param: op")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", fmt.Errorf("git executable not found in PATH")
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	raw, _ := params["path"].(string)
+	dir, err := t.Policy.resolveRoot("git", raw)
+	if err != nil {
+		return "", err
+	}
+	repo, err := t.repoRoot(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+
+	switch op {
+	case "status":
+		return t.status(ctx, repo)
+	case "log":
+		return t.log(ctx, repo, params)
+	case "diff":
+		return t.diff(ctx, repo, params)
+	case "show":
+		return t.show(ctx, repo, params)
+	case "blame":
+		return t.blame(ctx, repo, params)
+	case "branches":
+		return t.branches(ctx, repo)
+	case "commit", "checkout":
+		if !t.AllowWrite {
+			return "", fmt.Errorf("git %s is disabled (enable via config: tools.git.allow_write=true)", op)
+		}
+		if op == "commit" {
+			return t.commit(ctx, repo, params)
+		}
+		return t.checkout(ctx, repo, params)
+	default:
+		return "", fmt.Errorf("unsupported git op: %s", op)
+	}
+}
+
+// repoRoot returns the work tree root containing dir. With allowed_dirs set,
+// the whole repository must be inside them: log/diff/show see every file.
+func (t *GitTool) repoRoot(ctx context.Context, dir string) (string, error) {
+	out, err := t.run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", dir)
+	}
+	top := filepath.Clean(strings.TrimSpace(out))
+	if len(t.Policy.AllowedDirs) == 0 {
+		return top, nil
+	}
+	for _, d := range t.Policy.AllowedDirs {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		abs, err := filepath.Abs(expandHomePath(d))
+		if err != nil {
+			continue
+		}
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = real
+		}
+		if filepath.Clean(abs) == top || isWithinDir(abs, top) {
+			return top, nil
+		}
+	}
+	return "", fmt.Errorf("git denied: repository root %q is not within any allowed directory", top)
+}
+
+// run executes git in dir with repository-controlled code paths disabled
+// (hooks, fsmonitor, pagers, external diff/textconv drivers, credential prompts).
+func (t *GitTool) run(ctx context.Context, dir string, args ...string) (string, error) {
+	full := append([]string{
+		"-c", "core.hooksPath=" + os.DevNull,
+		"-c", "core.fsmonitor=false",
+		"-c", "core.pager=cat",
+		"-c", "color.ui=false",
+		"-c", "log.showSignature=false",
+	}, args...)
+	cmd := exec.CommandContext(ctx, "git", full...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_PAGER=cat",
+		"GIT_OPTIONAL_LOCKS=0",
+		"GIT_EXTERNAL_DIFF=",
+		"LC_ALL=C",
+	)
+	stdout := &limitedBuffer{Limit: t.MaxOutputBytes}
+	var stderr limitedBuffer
+	stderr.Limit = 4096
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	out := string(bytes.ToValidUTF8(stdout.Bytes(), []byte("�")))
+	if stdout.Truncated {
+		out += fmt.Sprintf("\n...(truncated at %d bytes; narrow with file, ref or stat)", t.MaxOutputBytes)
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return out, fmt.Errorf("git %s timed out after %s", args[0], t.Timeout)
+		}
+		msg := strings.TrimSpace(string(stderr.Bytes()))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return out, fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return out, nil
+}
+
+// checkRef rejects revisions that git would parse as options, and
+// "<rev>:<path>" forms (":path", ":0:path", ":/text" included), which name
+// blobs directly and would bypass deny_paths; use the file param instead.
+func checkRef(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\r\n\x00") {
+		return "", fmt.Errorf("invalid ref: %q", ref)
+	}
+	if strings.Contains(ref, ":") {
+		return "", fmt.Errorf("invalid ref: %q (use the file param to read a file at a revision)", ref)
+	}
+	return ref, nil
+}
+
+// checkRepoFile validates a repository-relative path against the read_file
+// policy and returns it cleaned and slash-separated.
+func (t *GitTool) checkRepoFile(repo, file string) (string, error) {
+	file = strings.TrimSpace(file)
+	if file == "" {
+		return "", nil
+	}
+	if containsDotDot(file) {
+		return "", fmt.Errorf("path traversal not allowed: %s", file)
+	}
+	if filepath.IsAbs(file) {
+		rel, err := filepath.Rel(repo, file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("file %q is outside the repository", file)
+		}
+		file = rel
+	}
+	file = filepath.ToSlash(filepath.Clean(file))
+	if offending, ok := denyPath(file, t.Policy.DenyPaths); ok {
+		return "", fmt.Errorf("git denied for path %q (matched %q)", file, offending)
+	}
+	return file, nil
+}
+
+// pathspec limits output to file, or to the whole tree minus deny_paths.
+func (t *GitTool) pathspec(file string) []string {
+	if file != "" {
+		return []string{"--", file}
+	}
+	spec := []string{"--", "."}
+	for _, d := range t.Policy.DenyPaths {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		spec = append(spec, ":(exclude,glob)**/"+filepath.Base(filepath.ToSlash(d)))
+	}
+	return spec
+}
+
+type gitStatusEntry struct {
+	Path     string `json:"path"`
+	OrigPath string `json:"orig_path,omitempty"`
+	Index    string `json:"index"`
+	Worktree string `json:"worktree"`
+}
+
+func (t *GitTool) status(ctx context.Context, repo string) (string, error) {
+	out, err := t.run(ctx, repo, "status", "--porcelain=v1", "--branch", "-z", "--untracked-files=normal")
+	if err != nil {
+		return "", err
+	}
+	res := map[string]any{"repo": repo}
+	var entries []gitStatusEntry
+	fields := strings.Split(out, "\x00")
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if strings.HasPrefix(f, "## ") {
+			parseGitBranchLine(strings.TrimPrefix(f, "## "), res)
+			continue
+		}
+		if len(f) < 4 {
+			continue
+		}
+		e := gitStatusEntry{Index: string(f[0]), Worktree: string(f[1]), Path: f[3:]}
+		if (f[0] == 'R' || f[0] == 'C') && i+1 < len(fields) {
+			e.OrigPath = fields[i+1]
+			i++
+		}
+		if _, denied := denyPath(e.Path, t.Policy.DenyPaths); denied {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	res["clean"] = len(entries) == 0
+	res["entries"] = entries
+	b, _ := json.MarshalIndent(res, "", "  ")
+	return string(b), nil
+}
+
+// parseGitBranchLine parses "main...origin/main [ahead 1, behind 2]".
+func parseGitBranchLine(line string, res map[string]any) {
+	if rest, ok := strings.CutPrefix(line, "No commits yet on "); ok {
+		res["branch"] = rest
+		return
+	}
+	head, track, _ := strings.Cut(line, " [")
+	branch, upstream, _ := strings.Cut(head, "...")
+	if branch == "HEAD (no branch)" {
+		res["detached"] = true
+	} else {
+		res["branch"] = branch
+	}
+	if upstream != "" {
+		res["upstream"] = upstream
+	}
+	for _, part := range strings.Split(strings.TrimSuffix(track, "]"), ", ") {
+		if n, ok := strings.CutPrefix(part, "ahead "); ok {
+			res["ahead"], _ = strconv.Atoi(n)
+		}
+		if n, ok := strings.CutPrefix(part, "behind "); ok {
+			res["behind"], _ = strconv.Atoi(n)
+		}
+	}
+}
+
+type gitCommit struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Email   string `json:"email"`
+	Date    string `json:"date"`
+	Subject string `json:"subject"`
+}
+
+func (t *GitTool) log(ctx context.Context, repo string, params map[string]any) (string, error) {
+	n := int64(gitDefaultLogCount)
+	if v, ok := asInt64(params["max_count"]); ok && v > 0 {
+		n = min(v, gitMaxLogCount)
+	}
+	args := []string{"log", "--no-color", "-n", strconv.FormatInt(n, 10), "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%s%x1e"}
+	rawRef, _ := params["ref"].(string)
+	ref, err := checkRef(rawRef)
+	if err != nil {
+		return "", err
+	}
+	if ref != "" {
+		args = append(args, ref)
+	}
+	rawFile, _ := params["file"].(string)
+	file, err := t.checkRepoFile(repo, rawFile)
+	if err != nil {
+		return "", err
+	}
+	if file != "" {
+		args = append(args, "--", file)
+	}
+	out, err := t.run(ctx, repo, args...)
+	if err != nil {
+		return "", err
+	}
+	var commits []gitCommit
+	for _, rec := range strings.Split(out, "\x1e") {
+		f := strings.Split(strings.TrimSpace(rec), "\x1f")
+		if len(f) != 5 {
+			continue
+		}
+		commits = append(commits, gitCommit{Hash: f[0], Author: f[1], Email: f[2], Date: f[3], Subject: f[4]})
+	}
+	b, _ := json.MarshalIndent(map[string]any{"repo": repo, "count": len(commits), "commits": commits}, "", "  ")
+	return string(b), nil
+}
+
+func (t *GitTool) diff(ctx context.Context, repo string, params map[string]any) (string, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff", "--no-textconv"}
+	if staged, _ := params["staged"].(bool); staged {
+		args = append(args, "--cached")
+	}
+	if stat, _ := params["stat"].(bool); stat {
+		args = append(args, "--stat")
+	}
+	rawRef, _ := params["ref"].(string)
+	ref, err := checkRef(rawRef)
+	if err != nil {
+		return "", err
+	}
+	if ref != "" {
+		args = append(args, ref)
+	}
+	rawFile, _ := params["file"].(string)
+	file, err := t.checkRepoFile(repo, rawFile)
+	if err != nil {
+		return "", err
+	}
+	out, err := t.run(ctx, repo, append(args, t.pathspec(file)...)...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return "no changes\n", nil
+	}
+	return out, nil
+}
+
+func (t *GitTool) show(ctx context.Context, repo string, params map[string]any) (string, error) {
+	rawRef, _ := params["ref"].(string)
+	ref, err := checkRef(rawRef)
+	if err != nil {
+		return "", err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	rawFile, _ := params["file"].(string)
+	file, err := t.checkRepoFile(repo, rawFile)
+	if err != nil {
+		return "", err
+	}
+	if file != "" {
+		return t.run(ctx, repo, "show", "--no-textconv", ref+":"+file)
+	}
+	// A bare blob id would print file content without any path to check.
+	kind, err := t.run(ctx, repo, "cat-file", "-t", ref)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(kind) == "blob" {
+		return "", fmt.Errorf("ref %q names a blob; use the file param to read a file", ref)
+	}
+	args := []string{"show", "--no-color", "--no-ext-diff", "--no-textconv", "--format=commit %H%nAuthor: %an <%ae>%nDate:   %aI%n%n%B"}
+	if stat, _ := params["stat"].(bool); stat {
+		args = append(args, "--stat")
+	}
+	args = append(args, ref)
+	return t.run(ctx, repo, append(args, t.pathspec("")...)...)
+}
+
+func (t *GitTool) blame(ctx context.Context, repo string, params map[string]any) (string, error) {
+	rawFile, _ := params["file"].(string)
+	file, err := t.checkRepoFile(repo, rawFile)
+	if err != nil {
+		return "", err
+	}
+	if file == "" {
+		return "", fmt.Errorf("missing required param: file")
+	}
+	args := []string{"blame", "--line-porcelain"}
+	start, _ := asInt64(params["start_line"])
+	end, _ := asInt64(params["end_line"])
+	if start > 0 || end > 0 {
+		r := strconv.FormatInt(max(start, 1), 10) + ","
+		if end > 0 {
+			r += strconv.FormatInt(end, 10)
+		}
+		args = append(args, "-L", r)
+	}
+	rawRef, _ := params["ref"].(string)
+	ref, err := checkRef(rawRef)
+	if err != nil {
+		return "", err
+	}
+	if ref != "" {
+		args = append(args, ref)
+	}
+	out, err := t.run(ctx, repo, append(args, "--", file)...)
+	if err != nil {
+		return "", err
+	}
+	return formatBlame(out), nil
+}
+
+// formatBlame turns --line-porcelain output into "hash author date line: text".
+func formatBlame(out string) string {
+	var b strings.Builder
+	var hash, author, date, lineNo string
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			fmt.Fprintf(&b, "%s %s %s %s: %s\n", hash, author, date, lineNo, line[1:])
+		case strings.HasPrefix(line, "author "):
+			author = strings.TrimPrefix(line, "author ")
+		case strings.HasPrefix(line, "author-time "):
+			if sec, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64); err == nil {
+				date = time.Unix(sec, 0).UTC().Format("2006-01-02")
+			}
+		default:
+			f := strings.Fields(line)
+			if len(f) >= 3 && len(f[0]) == 40 {
+				hash, lineNo = f[0][:8], f[2]
+			}
+		}
+	}
+	if i := strings.LastIndex(out, "\n...(truncated at "); i >= 0 {
+		b.WriteString(out[i+1:] + "\n")
+	}
+	return b.String()
+}
+
+type gitBranch struct {
+	Name     string `json:"name"`
+	Commit   string `json:"commit"`
+	Upstream string `json:"upstream,omitempty"`
+	Current  bool   `json:"current,omitempty"`
+	Remote   bool   `json:"remote,omitempty"`
+	Subject  string `json:"subject"`
+}
+
+func (t *GitTool) branches(ctx context.Context, repo string) (string, error) {
+	out, err := t.run(ctx, repo, "for-each-ref", "--format=%(refname)%1f%(objectname:short)%1f%(upstream:short)%1f%(HEAD)%1f%(subject)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return "", err
+	}
+	var list []gitBranch
+	for _, line := range strings.Split(out, "\n") {
+		f := strings.Split(line, "\x1f")
+		if len(f) != 5 {
+			continue
+		}
+		br := gitBranch{Commit: f[1], Upstream: f[2], Current: f[3] == "*", Subject: f[4]}
+		if name, ok := strings.CutPrefix(f[0], "refs/heads/"); ok {
+			br.Name = name
+		} else {
+			br.Name = strings.TrimPrefix(f[0], "refs/remotes/")
+			br.Remote = true
+			if strings.HasSuffix(br.Name, "/HEAD") {
+				continue
+			}
+		}
+		list = append(list, br)
+	}
+	b, _ := json.MarshalIndent(map[string]any{"repo": repo, "branches": list}, "", "  ")
+	return string(b), nil
+}
+
+func (t *GitTool) commit(ctx context.Context, repo string, params map[string]any) (string, error) {
+	msg, _ := params["message"].(string)
+	if strings.TrimSpace(msg) == "" {
+		return "", fmt.Errorf("missing required param: message")
+	}
+	files, ok := asStringSlice(params["files"])
+	if !ok {
+		return "", fmt.Errorf("invalid param: files must be an array of strings")
+	}
+	all, _ := params["all"].(bool)
+	var stage []string
+	for _, f := range files {
+		clean, err := t.checkRepoFile(repo, f)
+		if err != nil {
+			return "", err
+		}
+		if clean != "" {
+			stage = append(stage, clean)
+		}
+	}
+	if len(stage) > 0 {
+		if _, err := t.run(ctx, repo, append([]string{"add", "--"}, stage...)...); err != nil {
+			return "", err
+		}
+	}
+	args := []string{"commit", "--no-verify", "-m", msg}
+	if all {
+		args = append(args, "-a")
+	}
+	if _, err := t.run(ctx, repo, args...); err != nil {
+		return "", err
+	}
+	return t.run(ctx, repo, "show", "--no-color", "--stat", "--format=committed %H%n%s%n", "HEAD")
+}
+
+func (t *GitTool) checkout(ctx context.Context, repo string, params map[string]any) (string, error) {
+	rawRef, _ := params["ref"].(string)
+	ref, err := checkRef(rawRef)
+	if err != nil {
+		return "", err
+	}
+	if ref == "" {
+		return "", fmt.Errorf("missing required param: ref")
+	}
+	// switch (unlike checkout) never overwrites files with local changes.
+	args := []string{"switch"}
+	if create, _ := params["create"].(bool); create {
+		args = append(args, "-c")
+	} else if detach, _ := params["detach"].(bool); detach {
+		args = append(args, "--detach")
+	}
+	if _, err := t.run(ctx, repo, append(args, ref)...); err != nil {
+		return "", err
+	}
+	return t.status(ctx, repo)
+}
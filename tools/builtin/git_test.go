@@ -0,0 +1,181 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Ada")
+	t.Setenv("GIT_AUTHOR_EMAIL", "ada@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Ada")
+	t.Setenv("GIT_COMMITTER_EMAIL", "ada@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	dir := t.TempDir()
+	gitRun := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	gitRun("init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() {}\n")
+	writeFile(t, filepath.Join(dir, "config.yaml"), "api_key: secret\n")
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "initial commit")
+	writeFile(t, filepath.Join(dir, "main.go"), "package main\n\nfunc main() { println(1) }\n")
+	writeFile(t, filepath.Join(dir, "config.yaml"), "api_key: changed\n")
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitTool_ReadOps(t *testing.T) {
+	repo := newTestRepo(t)
+	tool := NewGitTool(FSPolicy{DenyPaths: []string{"config.yaml"}, AllowedDirs: []string{repo}}, 10*time.Second, 0, false)
+	ctx := context.Background()
+
+	out, err := tool.Execute(ctx, map[string]any{"op": "status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st struct {
+		Branch  string           `json:"branch"`
+		Clean   bool             `json:"clean"`
+		Entries []gitStatusEntry `json:"entries"`
+	}
+	if err := json.Unmarshal([]byte(out), &st); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if st.Branch != "main" || st.Clean || len(st.Entries) != 1 || st.Entries[0].Path != "main.go" || st.Entries[0].Worktree != "M" {
+		t.Fatalf("status: %s", out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"op": "diff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "+func main() { println(1) }") || strings.Contains(out, "api_key") {
+		t.Fatalf("diff: %s", out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"op": "log", "max_count": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"subject": "initial commit"`) || !strings.Contains(out, `"author": "Ada"`) {
+		t.Fatalf("log: %s", out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"op": "blame", "file": "main.go", "start_line": 1, "end_line": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, " Ada ") || !strings.HasSuffix(strings.TrimSpace(out), "1: package main") {
+		t.Fatalf("blame: %q", out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"op": "show", "file": "main.go"})
+	if err != nil || out != "package main\n\nfunc main() {}\n" {
+		t.Fatalf("show file: %q, %v", out, err)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"op": "branches"})
+	if err != nil || !strings.Contains(out, `"name": "main"`) || !strings.Contains(out, `"current": true`) {
+		t.Fatalf("branches: %s, %v", out, err)
+	}
+
+	if _, err := tool.Execute(ctx, map[string]any{"op": "show", "file": "config.yaml"}); err == nil {
+		t.Fatal("expected deny_paths to block config.yaml")
+	}
+	for _, ref := range []string{"HEAD:config.yaml", ":config.yaml", ":0:config.yaml"} {
+		out, err := tool.Execute(ctx, map[string]any{"op": "show", "ref": ref})
+		if err == nil || strings.Contains(out, "secret") {
+			t.Fatalf("expected ref %q to be rejected, got %q, %v", ref, out, err)
+		}
+	}
+	cmd := exec.Command("git", "rev-parse", "HEAD:config.yaml")
+	cmd.Dir = repo
+	blobID, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := strings.TrimSpace(string(blobID))
+	if out, err := tool.Execute(ctx, map[string]any{"op": "show", "ref": blob}); err == nil || strings.Contains(out, "secret") {
+		t.Fatalf("expected blob id to be rejected, got %q, %v", out, err)
+	}
+	if _, err := tool.Execute(ctx, map[string]any{"op": "log", "ref": "--output=/tmp/x"}); err == nil {
+		t.Fatal("expected option-like ref to be rejected")
+	}
+	if _, err := tool.Execute(ctx, map[string]any{"op": "commit", "message": "x"}); err == nil || !strings.Contains(err.Error(), "allow_write") {
+		t.Fatalf("expected commit to be disabled, got %v", err)
+	}
+}
+
+func TestGitTool_RepoOutsideAllowedDirs(t *testing.T) {
+	repo := newTestRepo(t)
+	sub := filepath.Join(repo, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewGitTool(FSPolicy{AllowedDirs: []string{sub}}, 10*time.Second, 0, false)
+	if _, err := tool.Execute(context.Background(), map[string]any{"op": "status"}); err == nil || !strings.Contains(err.Error(), "not within any allowed directory") {
+		t.Fatalf("expected repository root to be rejected, got %v", err)
+	}
+}
+
+func TestGitTool_CommitAndCheckout(t *testing.T) {
+	repo := newTestRepo(t)
+	// A repository hook must never run.
+	hook := filepath.Join(repo, ".git", "hooks", "pre-commit")
+	writeFile(t, hook, "#!/bin/sh\ntouch "+filepath.Join(repo, "hook-ran")+"\nexit 1\n")
+	if err := os.Chmod(hook, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := NewGitTool(FSPolicy{DenyPaths: []string{"config.yaml"}, AllowedDirs: []string{repo}}, 10*time.Second, 0, true)
+	ctx := context.Background()
+
+	if _, err := tool.Execute(ctx, map[string]any{"op": "checkout", "ref": "feature", "create": true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tool.Execute(ctx, map[string]any{"op": "commit", "message": "x", "files": []any{"config.yaml"}}); err == nil {
+		t.Fatal("expected staging a denied path to fail")
+	}
+	out, err := tool.Execute(ctx, map[string]any{"op": "commit", "message": "print one", "files": []any{"main.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "print one") || !strings.Contains(out, "main.go") {
+		t.Fatalf("commit: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "hook-ran")); err == nil {
+		t.Fatal("pre-commit hook ran")
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"op": "log", "max_count": 1})
+	if err != nil || !strings.Contains(out, "print one") {
+		t.Fatalf("log after commit: %s, %v", out, err)
+	}
+	// The uncommitted config.yaml change doesn't conflict, so it carries over to main.
+	out, err = tool.Execute(ctx, map[string]any{"op": "checkout", "ref": "main"})
+	if err != nil || !strings.Contains(out, `"branch": "main"`) {
+		t.Fatalf("checkout main: %s, %v", out, err)
+	}
+}
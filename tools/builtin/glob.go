@@ -0,0 +1,114 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+type GlobTool struct {
+	Policy     FSPolicy
+	MaxResults int
+}
+
+func NewGlobTool(policy FSPolicy, maxResults int) *GlobTool {
+	if maxResults <= 0 {
+		maxResults = 500
+	}
+	return &GlobTool{Policy: policy, MaxResults: maxResults}
+}
+
+func (t *GlobTool) Name() string { return "glob" }
+
+func (t *GlobTool) Description() string {
+	return "Finds files whose path matches a glob pattern (read-only). Supports * ? [..] and ** for any number of directories. Skips .git, hidden entries and .gitignore'd paths by default."
+}
+
+func (t *GlobTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Glob relative to path, e.g. \"**/*.go\" or \"docs/*.md\". A pattern without '/' matches file names at any depth.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Directory to search (default: the first allowed directory, or the current directory).",
+			},
+			"include_hidden": map[string]any{
+				"type":        "boolean",
+				"description": "Include dotfiles and dot-directories.",
+			},
+			"include_ignored": map[string]any{
+				"type":        "boolean",
+				"description": "Include paths matched by .gitignore.",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *GlobTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	pattern, _ := params["pattern"].(string)
+	pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+	if pattern == "" {
+		return "", fmt.Errorf("missing required param: pattern")
+	}
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	raw, _ := params["path"].(string)
+	root, err := t.Policy.resolveRoot("glob", raw)
+	if err != nil {
+		return "", err
+	}
+	var opts walkOptions
+	opts.IncludeHidden, _ = params["include_hidden"].(bool)
+	opts.NoIgnore, _ = params["include_ignored"].(bool)
+
+	var matches []string
+	truncated := false
+	err = walkWorkspace(root, t.Policy, opts, func(rel string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		if !globMatches(pattern, rel) {
+			return nil
+		}
+		if len(matches) >= t.MaxResults {
+			truncated = true
+			return errStopWalk
+		}
+		matches = append(matches, rel)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "root: %s\n", root)
+	fmt.Fprintf(&b, "matches: %d\n", len(matches))
+	for _, m := range matches {
+		b.WriteString(m)
+		b.WriteByte('\n')
+	}
+	if truncated {
+		fmt.Fprintf(&b, "...(truncated at %d results; narrow the pattern)\n", t.MaxResults)
+	}
+	return b.String(), nil
+}
+
+func globMatches(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchGlobPath(pattern, rel)
+}
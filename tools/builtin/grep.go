@@ -0,0 +1,274 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/quailyquaily/mistermorph/internal/strutil"
+)
+
+type GrepTool struct {
+	Policy       FSPolicy
+	MaxResults   int
+	MaxFileBytes int64
+}
+
+func NewGrepTool(policy FSPolicy, maxResults int, maxFileBytes int64) *GrepTool {
+	if maxResults <= 0 {
+		maxResults = 200
+	}
+	if maxFileBytes <= 0 {
+		maxFileBytes = 4 * 1024 * 1024
+	}
+	return &GrepTool{Policy: policy, MaxResults: maxResults, MaxFileBytes: maxFileBytes}
+}
+
+const grepMaxLineChars = 400
+
+func (t *GrepTool) Name() string { return "grep" }
+
+func (t *GrepTool) Description() string {
+	return "Searches file contents for a regular expression (RE2 syntax, read-only) and returns matching lines as path:line:text. Skips binary files, .git, hidden entries and .gitignore'd paths by default."
+}
+
+func (t *GrepTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"description": "Regular expression (RE2) to search for.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File or directory to search (default: the first allowed directory, or the current directory).",
+			},
+			"glob": map[string]any{
+				"type":        "string",
+				"description": "Only search files matching this glob (e.g. \"*.go\" or \"src/**/*.ts\").",
+			},
+			"ignore_case": map[string]any{
+				"type":        "boolean",
+				"description": "Case-insensitive match.",
+			},
+			"fixed_string": map[string]any{
+				"type":        "boolean",
+				"description": "Treat pattern as a literal string instead of a regex.",
+			},
+			"context": map[string]any{
+				"type":        "integer",
+				"description": "Lines of context to show around each match (default: 0, max: 5).",
+			},
+			"include_hidden": map[string]any{
+				"type":        "boolean",
+				"description": "Include dotfiles and dot-directories.",
+			},
+			"include_ignored": map[string]any{
+				"type":        "boolean",
+				"description": "Include paths matched by .gitignore.",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *GrepTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	pattern, _ := params["pattern"].(string)
+	if pattern == "" {
+		return "", fmt.Errorf("missing required param: pattern")
+	}
+	if fixed, _ := params["fixed_string"].(bool); fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ic, _ := params["ignore_case"].(bool); ic {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	include, _ := params["glob"].(string)
+	include = strings.Trim(strings.TrimSpace(include), "/")
+	contextLines := 0
+	if v, ok := asInt64(params["context"]); ok && v > 0 {
+		contextLines = int(min(v, 5))
+	}
+
+	s := &grepSearch{re: re, context: contextLines, max: t.MaxResults}
+
+	raw, _ := params["path"].(string)
+	if file, ok, err := t.singleFile(raw); err != nil {
+		return "", err
+	} else if ok {
+		if err := s.searchFile(file, filepath.Base(file), t.MaxFileBytes); err != nil {
+			return "", err
+		}
+		return s.result(filepath.Dir(file)), nil
+	}
+
+	root, err := t.Policy.resolveRoot("grep", raw)
+	if err != nil {
+		return "", err
+	}
+	var opts walkOptions
+	opts.IncludeHidden, _ = params["include_hidden"].(bool)
+	opts.NoIgnore, _ = params["include_ignored"].(bool)
+	err = walkWorkspace(root, t.Policy, opts, func(rel string, d fs.DirEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if include != "" && !globMatches(include, rel) {
+			return nil
+		}
+		if err := s.searchFile(filepath.Join(root, filepath.FromSlash(rel)), rel, t.MaxFileBytes); err != nil {
+			return nil
+		}
+		if s.truncated {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.result(root), nil
+}
+
+// singleFile reports whether raw names a regular file and, if so, validates it under the policy.
+func (t *GrepTool) singleFile(raw string) (string, bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", false, nil
+	}
+	fi, err := os.Stat(expandHomePath(raw))
+	if err != nil || fi.IsDir() {
+		return "", false, nil
+	}
+	cleaned, err := checkFilePathPolicy("grep", raw, t.Policy.DenyPaths, t.Policy.AllowedDirs)
+	if err != nil {
+		return "", false, err
+	}
+	return cleaned, true, nil
+}
+
+type grepSearch struct {
+	re      *regexp.Regexp
+	context int
+	max     int
+
+	out          strings.Builder
+	matches      int
+	files        int
+	skippedBin   int
+	skippedLarge int
+	truncated    bool
+}
+
+func (s *grepSearch) searchFile(path, rel string, maxBytes int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil && fi.Size() > maxBytes {
+		s.skippedLarge++
+		return nil
+	}
+	br := bufio.NewReaderSize(f, 64*1024)
+	if head, _ := br.Peek(8000); looksBinary(head) {
+		s.skippedBin++
+		return nil
+	}
+
+	var (
+		before    []string // ring of previous lines for context
+		afterLeft int
+		lastOut   = 0 // last line number written
+		found     = false
+	)
+	lineNo := 0
+	for {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		lineNo++
+		text := strings.TrimRight(line, "\r\n")
+		if s.re.MatchString(text) {
+			if s.matches >= s.max {
+				s.truncated = true
+				break
+			}
+			if !found {
+				found = true
+				s.files++
+			} else if s.context > 0 && lineNo-len(before) > lastOut+1 {
+				s.out.WriteString("--\n")
+			}
+			for i, b := range before {
+				n := lineNo - len(before) + i
+				if n > lastOut {
+					s.writeLine(rel, n, '-', b)
+				}
+			}
+			s.writeLine(rel, lineNo, ':', text)
+			s.matches++
+			lastOut = lineNo
+			before = before[:0]
+			afterLeft = s.context
+		} else if afterLeft > 0 {
+			s.writeLine(rel, lineNo, '-', text)
+			lastOut = lineNo
+			afterLeft--
+		} else if s.context > 0 {
+			before = append(before, text)
+			if len(before) > s.context {
+				before = before[1:]
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if found && s.context > 0 {
+		s.out.WriteString("--\n")
+	}
+	return nil
+}
+
+func (s *grepSearch) writeLine(rel string, n int, sep byte, text string) {
+	if len(text) > grepMaxLineChars {
+		text = strutil.TruncateUTF8(text, grepMaxLineChars) + "..."
+	}
+	fmt.Fprintf(&s.out, "%s%c%d%c%s\n", rel, sep, n, sep, text)
+}
+
+func (s *grepSearch) result(root string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "root: %s\n", root)
+	fmt.Fprintf(&b, "matches: %d in %d files\n", s.matches, s.files)
+	if s.skippedBin > 0 || s.skippedLarge > 0 {
+		fmt.Fprintf(&b, "skipped: %d binary, %d too large\n", s.skippedBin, s.skippedLarge)
+	}
+	b.WriteString(s.out.String())
+	if s.truncated {
+		fmt.Fprintf(&b, "...(truncated at %d matches; narrow the pattern, path or glob)\n", s.max)
+	}
+	return b.String()
+}
@@ -0,0 +1,311 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONQueryTool applies a jq expression to a JSON, JSON Lines or YAML
+// document, so large API responses can be saved with url_fetch download_path
+// and then read selectively.
+type JSONQueryTool struct {
+	Policy         FSPolicy
+	BaseDir        string // file_cache_dir; relative paths resolve here and it is always readable
+	MaxInputBytes  int64
+	MaxOutputBytes int
+	Timeout        time.Duration
+}
+
+func NewJSONQueryTool(policy FSPolicy, baseDir string, maxInputBytes int64, maxOutputBytes int) *JSONQueryTool {
+	if maxInputBytes <= 0 {
+		maxInputBytes = 64 * 1024 * 1024
+	}
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = 32 * 1024
+	}
+	return &JSONQueryTool{
+		Policy:         policy,
+		BaseDir:        strings.TrimSpace(baseDir),
+		MaxInputBytes:  maxInputBytes,
+		MaxOutputBytes: maxOutputBytes,
+		Timeout:        10 * time.Second,
+	}
+}
+
+func (t *JSONQueryTool) Name() string { return "json_query" }
+
+func (t *JSONQueryTool) Description() string {
+	return "Runs a jq expression over a JSON, JSON Lines or YAML document (a file, e.g. from url_fetch download_path, or inline text) and returns only the selected values, one JSON value per line. " +
+		"Examples: `keys`, `.items | length`, `.items[] | select(.state == \"open\") | {id, title}`, `[.data[].price] | add / length`."
+}
+
+func (t *JSONQueryTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "jq expression (default: \".\").",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "File to query. Relative paths are resolved under file_cache_dir.",
+			},
+			"input": map[string]any{
+				"type":        "string",
+				"description": "Inline JSON or YAML text (instead of path).",
+			},
+			"format": map[string]any{
+				"type":        "string",
+				"enum":        []string{"auto", "json", "jsonl", "yaml"},
+				"description": "Input format (default: auto, from the file extension or content).",
+			},
+			"slurp": map[string]any{
+				"type":        "boolean",
+				"description": "Read all values of a multi-document input (JSON Lines, YAML ---) into one array, like jq -s.",
+			},
+			"raw": map[string]any{
+				"type":        "boolean",
+				"description": "Print string results without JSON quotes, like jq -r.",
+			},
+		},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *JSONQueryTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	query, _ := params["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		query = "."
+	}
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid query: %w", err)
+	}
+	// No environment, modules or input/inputs: the query only sees the document.
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return "", fmt.Errorf("invalid query: %w", err)
+	}
+
+	format, _ := params["format"].(string)
+	format = strings.ToLower(strings.TrimSpace(format))
+	var data []byte
+	path, _ := params["path"].(string)
+	inline, _ := params["input"].(string)
+	switch {
+	case strings.TrimSpace(path) != "" && inline != "":
+		return "", fmt.Errorf("pass only one of path or input")
+	case strings.TrimSpace(path) != "":
+		p, err := t.Policy.resolveCachePath(t.Name(), path, t.BaseDir)
+		if err != nil {
+			return "", err
+		}
+		if data, err = t.readInput(p); err != nil {
+			return "", err
+		}
+		if format == "" || format == "auto" {
+			format = formatFromExt(p)
+		}
+	case inline != "":
+		data = []byte(inline)
+	default:
+		return "", fmt.Errorf("missing required param: path or input")
+	}
+
+	docs, err := decodeQueryInput(data, format)
+	if err != nil {
+		return "", err
+	}
+	if slurp, _ := params["slurp"].(bool); slurp {
+		docs = []any{docs}
+	}
+	raw, _ := params["raw"].(bool)
+
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	out := limitedBuffer{Limit: t.MaxOutputBytes}
+	n := 0
+	for _, doc := range docs {
+		iter := code.RunWithContext(ctx, doc)
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := v.(error); ok {
+				var halt *gojq.HaltError
+				if errors.As(err, &halt) && halt.Value() == nil {
+					break
+				}
+				if ctx.Err() != nil {
+					return "", fmt.Errorf("query timed out after %s", t.Timeout)
+				}
+				return "", fmt.Errorf("query error: %w", err)
+			}
+			if s, ok := v.(string); ok && raw {
+				out.Write([]byte(s))
+			} else {
+				b, err := gojq.Marshal(v)
+				if err != nil {
+					return "", err
+				}
+				out.Write(b)
+			}
+			out.Write([]byte("\n"))
+			n++
+			if out.Truncated {
+				break
+			}
+		}
+		if out.Truncated {
+			break
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "results: %d\n", n)
+	b.Write(bytes.ToValidUTF8(out.Bytes(), nil))
+	if out.Truncated {
+		fmt.Fprintf(&b, "\n...(truncated at %d bytes; narrow the query, e.g. with select(), limit(n; ...) or length)\n", t.MaxOutputBytes)
+	}
+	return b.String(), nil
+}
+
+func (t *JSONQueryTool) readInput(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil && fi.Size() > t.MaxInputBytes {
+		return nil, fmt.Errorf("%s is too large (%d bytes > %d)", path, fi.Size(), t.MaxInputBytes)
+	}
+	return io.ReadAll(io.LimitReader(f, t.MaxInputBytes))
+}
+
+func formatFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	case ".json":
+		return "json"
+	}
+	return "auto"
+}
+
+// decodeQueryInput returns the top-level documents of data. JSON streams
+// (JSON Lines or concatenated values) yield one document per value.
+func decodeQueryInput(data []byte, format string) ([]any, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	switch format {
+	case "", "auto":
+		docs, err := decodeJSONStream(data)
+		if err == nil {
+			return docs, nil
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return nil, err
+		}
+		return decodeYAMLStream(data)
+	case "json", "jsonl":
+		return decodeJSONStream(data)
+	case "yaml", "yml":
+		return decodeYAMLStream(data)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s (use auto, json, jsonl or yaml)", format)
+	}
+}
+
+func decodeJSONStream(data []byte) ([]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var docs []any
+	for {
+		var v any
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid JSON (value %d): %w", len(docs)+1, err)
+		}
+		docs = append(docs, normalizeQueryValue(v))
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("empty input")
+	}
+	return docs, nil
+}
+
+func decodeYAMLStream(data []byte) ([]any, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []any
+	for {
+		var v any
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		docs = append(docs, normalizeQueryValue(v))
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("empty input")
+	}
+	return docs, nil
+}
+
+// normalizeQueryValue converts decoded values to the types gojq accepts:
+// integers that fit stay exact (large ones become *big.Int), YAML maps get
+// string keys and timestamps become RFC 3339 strings.
+func normalizeQueryValue(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return int(i)
+		}
+		if bi, ok := new(big.Int).SetString(x.String(), 10); ok {
+			return bi
+		}
+		f, _ := x.Float64()
+		return f
+	case int64:
+		return int(x)
+	case uint64:
+		return new(big.Int).SetUint64(x)
+	case time.Time:
+		return x.Format(time.RFC3339Nano)
+	case []any:
+		for i := range x {
+			x[i] = normalizeQueryValue(x[i])
+		}
+		return x
+	case map[string]any:
+		for k, e := range x {
+			x[k] = normalizeQueryValue(e)
+		}
+		return x
+	case map[any]any:
+		m := make(map[string]any, len(x))
+		for k, e := range x {
+			m[fmt.Sprint(k)] = normalizeQueryValue(e)
+		}
+		return m
+	default:
+		return v
+	}
+}
@@ -0,0 +1,68 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONQueryTool(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "cache")
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Exists, so the traversal case below fails on policy rather than on open.
+	writeFile(t, filepath.Join(root, "outside.json"), `{"secret":true}`)
+	writeFile(t, filepath.Join(base, "issues.json"), `{"items":[{"id":9007199254740993,"state":"open","title":"a"},{"id":2,"state":"closed","title":"b"},{"id":3,"state":"open","title":"c"}]}`)
+	writeFile(t, filepath.Join(base, "events.jsonl"), "{\"n\":1}\n{\"n\":2}\n{\"n\":3}\n")
+	writeFile(t, filepath.Join(base, "deploy.yaml"), "services:\n  web:\n    replicas: 3\n    ports: [80, 443]\n  1: numeric-key\n")
+	writeFile(t, filepath.Join(base, "config.yaml"), "secret: x\n")
+	tool := NewJSONQueryTool(FSPolicy{DenyPaths: []string{"config.yaml"}}, base, 0, 0)
+	ctx := context.Background()
+
+	cases := []struct {
+		params map[string]any
+		want   string
+	}{
+		{map[string]any{"path": "issues.json", "query": `.items[] | select(.state == "open") | .id`}, "results: 2\n9007199254740993\n3\n"},
+		{map[string]any{"path": filepath.Join(base, "issues.json"), "query": `[.items[].title] | join(",")`, "raw": true}, "results: 1\na,b,c\n"},
+		{map[string]any{"path": "events.jsonl", "query": ".n * 10"}, "results: 3\n10\n20\n30\n"},
+		{map[string]any{"path": "events.jsonl", "query": "map(.n) | add", "slurp": true}, "results: 1\n6\n"},
+		{map[string]any{"path": "deploy.yaml", "query": ".services.web"}, "results: 1\n{\"ports\":[80,443],\"replicas\":3}\n"},
+		{map[string]any{"path": "deploy.yaml", "query": `.services["1"]`}, "results: 1\n\"numeric-key\"\n"},
+		{map[string]any{"input": "a: [1, 2]\n", "query": ".a | length"}, "results: 1\n2\n"},
+		{map[string]any{"input": `{"x": 1}`, "query": "$ENV | length"}, "results: 1\n0\n"},
+		{map[string]any{"input": `[1,2,3]`, "query": ".[] | if . == 2 then halt else . end"}, "results: 1\n1\n"},
+	}
+	for _, c := range cases {
+		out, err := tool.Execute(ctx, c.params)
+		if err != nil {
+			t.Fatalf("%v: %v", c.params, err)
+		}
+		if out != c.want {
+			t.Fatalf("%v:\ngot  %q\nwant %q", c.params, out, c.want)
+		}
+	}
+
+	for _, params := range []map[string]any{
+		{"path": "config.yaml"},
+		{"path": "../outside.json"},
+		{"input": `{"a":`, "query": "."},
+		{"input": `{}`, "query": ".a |"},
+		{"input": `{}`, "query": `error("boom")`},
+		{"input": "{}", "path": "issues.json"},
+	} {
+		if _, err := tool.Execute(ctx, params); err == nil {
+			t.Fatalf("expected error for %v", params)
+		}
+	}
+
+	tool.MaxOutputBytes = 20
+	out, err := tool.Execute(ctx, map[string]any{"path": "issues.json", "query": ".items[]"})
+	if err != nil || !strings.Contains(out, "...(truncated at 20 bytes") {
+		t.Fatalf("err=%v out=%s", err, out)
+	}
+}
@@ -0,0 +1,106 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+type ListDirTool struct {
+	Policy     FSPolicy
+	MaxEntries int
+}
+
+func NewListDirTool(policy FSPolicy, maxEntries int) *ListDirTool {
+	if maxEntries <= 0 {
+		maxEntries = 500
+	}
+	return &ListDirTool{Policy: policy, MaxEntries: maxEntries}
+}
+
+func (t *ListDirTool) Name() string { return "list_dir" }
+
+func (t *ListDirTool) Description() string {
+	return "Lists files and directories under a path (read-only). Directories end with '/'. Skips .git, hidden entries and .gitignore'd paths by default."
+}
+
+func (t *ListDirTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Directory to list (default: the first allowed directory, or the current directory).",
+			},
+			"depth": map[string]any{
+				"type":        "integer",
+				"description": "How many levels to descend (default: 1, max: 10).",
+			},
+			"include_hidden": map[string]any{
+				"type":        "boolean",
+				"description": "Include dotfiles and dot-directories.",
+			},
+			"include_ignored": map[string]any{
+				"type":        "boolean",
+				"description": "Include paths matched by .gitignore.",
+			},
+		},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *ListDirTool) Execute(_ context.Context, params map[string]any) (string, error) {
+	raw, _ := params["path"].(string)
+	root, err := t.Policy.resolveRoot("list_dir", raw)
+	if err != nil {
+		return "", err
+	}
+	depth := 1
+	if v, ok := asInt64(params["depth"]); ok && v > 0 {
+		depth = int(v)
+	}
+	if depth > 10 {
+		depth = 10
+	}
+	opts := walkOptions{MaxDepth: depth}
+	opts.IncludeHidden, _ = params["include_hidden"].(bool)
+	opts.NoIgnore, _ = params["include_ignored"].(bool)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "root: %s\n", root)
+	n := 0
+	truncated := false
+	err = walkWorkspace(root, t.Policy, opts, func(rel string, d fs.DirEntry) error {
+		if n >= t.MaxEntries {
+			truncated = true
+			return errStopWalk
+		}
+		n++
+		switch {
+		case d.IsDir():
+			fmt.Fprintf(&b, "%s/\n", rel)
+		case d.Type()&fs.ModeSymlink != 0:
+			fmt.Fprintf(&b, "%s@\n", rel)
+		default:
+			size := int64(-1)
+			if fi, err := d.Info(); err == nil {
+				size = fi.Size()
+			}
+			fmt.Fprintf(&b, "%s\t%d\n", rel, size)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		b.WriteString("(empty)\n")
+	}
+	if truncated {
+		fmt.Fprintf(&b, "...(truncated at %d entries; narrow path or depth)\n", t.MaxEntries)
+	}
+	return b.String(), nil
+}
@@ -24,3 +24,30 @@ func asInt64(v any) (int64, bool) {
 		return 0, false
 	}
 }
+
+// asStringSlice accepts a JSON array of strings or a single string.
+func asStringSlice(v any) ([]string, bool) {
+	switch x := v.(type) {
+	case []string:
+		return x, true
+	case string:
+		if strings.TrimSpace(x) == "" {
+			return nil, true
+		}
+		return []string{x}, true
+	case []any:
+		out := make([]string, 0, len(x))
+		for _, it := range x {
+			s, ok := it.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	case nil:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
@@ -1,20 +1,30 @@
 package builtin
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/quailyquaily/mistermorph/internal/docextract"
+	"github.com/quailyquaily/mistermorph/internal/strutil"
 )
 
 type ReadFileTool struct {
 	MaxBytes    int64
 	DenyPaths   []string
 	AllowedDirs []string
+	// MaxDocumentBytes caps the size of PDF/DOCX/XLSX/CSV/HTML files that are
+	// loaded for text extraction (default: 32MB).
+	MaxDocumentBytes int64
 }
 
+const defaultReadFileMaxDocumentBytes = 32 * 1024 * 1024
+
 func NewReadFileTool(maxBytes int64) *ReadFileTool {
 	return &ReadFileTool{MaxBytes: maxBytes}
 }
@@ -30,7 +40,7 @@ func NewReadFileToolWithOptions(maxBytes int64, denyPaths []string, allowedDirs
 func (t *ReadFileTool) Name() string { return "read_file" }
 
 func (t *ReadFileTool) Description() string {
-	return "Reads a local text file from disk and returns its content (truncated to a maximum size)."
+	return "Reads a local file and returns its text (truncated to a maximum size). Legacy encodings (GBK, Shift_JIS, latin1, UTF-16...) are converted to UTF-8, and PDF, DOCX, XLSX, CSV/TSV and HTML files are returned as extracted text (including files saved by url_fetch download_path or received via Telegram). Use start_line/end_line or offset to page through large files."
 }
 
 func (t *ReadFileTool) ParameterSchema() string {
@@ -38,6 +48,30 @@ func (t *ReadFileTool) ParameterSchema() string {
 		"type": "object",
 		"properties": map[string]any{
 			"path": map[string]any{"type": "string", "description": "File path to read."},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"description": "First line to return (1-based). For documents, counts lines of the extracted text.",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"description": "Last line to return (inclusive). Default: read until max_bytes.",
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "Byte offset to start reading plain-text files from (ignored when start_line is set).",
+			},
+			"line_numbers": map[string]any{
+				"type":        "boolean",
+				"description": "Prefix each line with its line number.",
+			},
+			"encoding": map[string]any{
+				"type":        "string",
+				"description": "Source text encoding (e.g. \"gbk\", \"shift_jis\", \"latin1\"). Default: auto-detect.",
+			},
+			"raw": map[string]any{
+				"type":        "boolean",
+				"description": "Skip document text extraction and read the file as text.",
+			},
 		},
 		"required": []string{"path"},
 	}
@@ -45,6 +79,18 @@ func (t *ReadFileTool) ParameterSchema() string {
 	return string(b)
 }
 
+type readFileOptions struct {
+	startLine   int64
+	endLine     int64
+	offset      int64
+	lineNumbers bool
+	encoding    string
+}
+
+func (o readFileOptions) byLine() bool {
+	return o.startLine > 0 || o.endLine > 0 || o.lineNumbers
+}
+
 func (t *ReadFileTool) Execute(_ context.Context, params map[string]any) (string, error) {
 	path, _ := params["path"].(string)
 	path = strings.TrimSpace(path)
@@ -52,48 +98,214 @@ func (t *ReadFileTool) Execute(_ context.Context, params map[string]any) (string
 		return "", fmt.Errorf("missing required param: path")
 	}
 
+	var opts readFileOptions
+	opts.startLine, _ = asInt64(params["start_line"])
+	opts.endLine, _ = asInt64(params["end_line"])
+	opts.offset, _ = asInt64(params["offset"])
+	opts.lineNumbers, _ = params["line_numbers"].(bool)
+	opts.encoding, _ = params["encoding"].(string)
+	raw, _ := params["raw"].(bool)
+	if opts.startLine < 0 || opts.endLine < 0 || opts.offset < 0 {
+		return "", fmt.Errorf("start_line, end_line and offset must be >= 0")
+	}
+	if opts.endLine > 0 && opts.startLine > opts.endLine {
+		return "", fmt.Errorf("start_line (%d) is after end_line (%d)", opts.startLine, opts.endLine)
+	}
+	if opts.encoding != "" {
+		if _, err := docextract.LookupEncoding(opts.encoding); err != nil {
+			return "", err
+		}
+	}
+
+	cleaned, err := checkFilePathPolicy("read_file", path, t.DenyPaths, t.AllowedDirs)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(cleaned)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if fi.IsDir() {
+		return "", fmt.Errorf("read_file: %s is a directory (use list_dir)", cleaned)
+	}
+
+	br := bufio.NewReaderSize(f, 64*1024)
+	head, _ := br.Peek(8192)
+
+	if !raw {
+		if format := docextract.Detect(cleaned, head); format != docextract.FormatNone {
+			return t.readDocument(br, fi.Size(), format, opts)
+		}
+	}
+
+	var src io.Reader = br
+	if opts.offset > 0 && !opts.byLine() {
+		if _, err := f.Seek(opts.offset, io.SeekStart); err != nil {
+			return "", err
+		}
+		br.Reset(f)
+		src = br
+	}
+	sample, _ := br.Peek(8192)
+	if opts.encoding == "" && looksBinary(sample) && !isUTF16(sample) {
+		return "", fmt.Errorf("read_file: %s looks like a binary file (%d bytes); no text extractor for this format", cleaned, fi.Size())
+	}
+	dec, encName, err := docextract.NewDecodingReader(src, sample, opts.encoding)
+	if err != nil {
+		return "", err
+	}
+	if opts.byLine() {
+		return t.readLines(dec, opts)
+	}
+
+	limit := t.MaxBytes
+	if limit <= 0 {
+		out, err := io.ReadAll(dec)
+		return string(out), err
+	}
+	out, err := io.ReadAll(io.LimitReader(dec, limit+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(out)) <= limit {
+		return string(out), nil
+	}
+	text := strutil.TruncateUTF8(string(out), int(limit))
+	if encName != "" {
+		// Byte offsets into the source don't line up with the decoded text.
+		return text + fmt.Sprintf("\n...(truncated at %d bytes; source is %s, use start_line to read further)", limit, encName), nil
+	}
+	next := opts.offset + int64(len(text))
+	return text + fmt.Sprintf("\n...(truncated at %d bytes of %d; use offset=%d or start_line to read further)", limit, fi.Size(), next), nil
+}
+
+func (t *ReadFileTool) readDocument(r io.Reader, size int64, format docextract.Format, opts readFileOptions) (string, error) {
+	maxDoc := t.MaxDocumentBytes
+	if maxDoc <= 0 {
+		maxDoc = defaultReadFileMaxDocumentBytes
+	}
+	if size > maxDoc {
+		return "", fmt.Errorf("read_file: %s document too large to extract (%d bytes > %d); pass raw=true to read it as text", format, size, maxDoc)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxDoc))
+	if err != nil {
+		return "", err
+	}
+	text, err := docextract.Extract(format, data)
+	if err != nil {
+		return "", fmt.Errorf("read_file: %w", err)
+	}
+	if !opts.byLine() && opts.offset == 0 && (t.MaxBytes <= 0 || int64(len(text)) <= t.MaxBytes) {
+		return text, nil
+	}
+	if !opts.byLine() {
+		// Offsets address the extracted text for documents.
+		if opts.offset >= int64(len(text)) {
+			return "", nil
+		}
+		text = text[opts.offset:]
+		if t.MaxBytes > 0 && int64(len(text)) > t.MaxBytes {
+			cut := strutil.TruncateUTF8(text, int(t.MaxBytes))
+			return cut + fmt.Sprintf("\n...(truncated: extracted text is %d bytes; use offset=%d or start_line to read further)", len(text)+int(opts.offset), opts.offset+int64(len(cut))), nil
+		}
+		return text, nil
+	}
+	return t.readLines(strings.NewReader(text), opts)
+}
+
+// isUTF16 reports a UTF-16 byte order mark (UTF-16 text is full of NUL bytes).
+func isUTF16(sample []byte) bool {
+	_, name := docextract.DetectEncoding(sample)
+	return strings.HasPrefix(name, "utf-16")
+}
+
+// readLines returns lines [startLine, endLine] of r, bounded by MaxBytes.
+func (t *ReadFileTool) readLines(r io.Reader, opts readFileOptions) (string, error) {
+	start := max(opts.startLine, 1)
+	br := bufio.NewReaderSize(r, 64*1024)
+	var b strings.Builder
+	var lineNo int64
+	for {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", err
+		}
+		lineNo++
+		if lineNo >= start {
+			if opts.endLine > 0 && lineNo > opts.endLine {
+				break
+			}
+			entry := line
+			if opts.lineNumbers {
+				entry = fmt.Sprintf("%6d\t%s", lineNo, line)
+			}
+			if t.MaxBytes > 0 && int64(b.Len()+len(entry)) > t.MaxBytes {
+				if b.Len() == 0 {
+					// A single huge line: return what fits.
+					b.WriteString(strutil.TruncateUTF8(entry, int(t.MaxBytes)))
+					lineNo++
+				}
+				fmt.Fprintf(&b, "\n...(truncated at %d bytes; use start_line=%d to read further)", t.MaxBytes, lineNo)
+				return b.String(), nil
+			}
+			b.WriteString(entry)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if lineNo < start && start > 1 {
+		return "", fmt.Errorf("read_file: start_line %d is past the end of the file (%d lines)", start, lineNo)
+	}
+	return b.String(), nil
+}
+
+// checkFilePathPolicy applies the shared local-file policy (no "..", deny_paths,
+// allowed_dirs and, when allowed_dirs is set, no symlinks) and returns the cleaned path.
+func checkFilePathPolicy(toolName string, path string, denyPaths []string, allowedDirs []string) (string, error) {
 	if containsDotDot(path) {
 		return "", fmt.Errorf("path traversal not allowed: %s", path)
 	}
 
 	path = expandHomePath(path)
 
-	if offending, ok := denyPath(path, t.DenyPaths); ok {
-		return "", fmt.Errorf("read_file denied for path %q (matched %q)", path, offending)
+	if offending, ok := denyPath(path, denyPaths); ok {
+		return "", fmt.Errorf("%s denied for path %q (matched %q)", toolName, path, offending)
 	}
 
 	cleaned := filepath.Clean(path)
 
-	if len(t.AllowedDirs) > 0 {
+	if len(allowedDirs) > 0 {
 		absPath, err := filepath.Abs(cleaned)
 		if err != nil {
 			return "", fmt.Errorf("invalid path: %w", err)
 		}
-		if !isWithinAnyDir(absPath, t.AllowedDirs) {
-			return "", fmt.Errorf("read_file denied: path %q is not within any allowed directory", path)
+		if !isWithinAnyDir(absPath, allowedDirs) {
+			return "", fmt.Errorf("%s denied: path %q is not within any allowed directory", toolName, path)
 		}
 	}
 
 	// When allowed_dirs is set, reject symlinks to prevent allowlist bypass
 	// (a symlink inside an allowed directory could point outside it).
-	if len(t.AllowedDirs) > 0 {
+	if len(allowedDirs) > 0 {
 		fi, err := os.Lstat(cleaned)
 		if err != nil {
 			return "", err
 		}
 		if fi.Mode()&os.ModeSymlink != 0 {
-			return "", fmt.Errorf("read_file denied: refusing symlink %q", cleaned)
+			return "", fmt.Errorf("%s denied: refusing symlink %q", toolName, cleaned)
 		}
 	}
-
-	data, err := os.ReadFile(cleaned)
-	if err != nil {
-		return "", err
-	}
-	if t.MaxBytes > 0 && int64(len(data)) > t.MaxBytes {
-		data = data[:t.MaxBytes]
-	}
-	return string(data), nil
+	return cleaned, nil
 }
 
 // containsDotDot returns true if the path contains a ".." component.
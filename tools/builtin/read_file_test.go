@@ -187,3 +187,155 @@ func TestWriteFileTool_ExpandsTildeInBaseDir(t *testing.T) {
 		t.Fatalf("got %q, want %q", string(got), "ok")
 	}
 }
+
+func TestReadFileTool_LineRanges(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "app.log")
+	var b strings.Builder
+	for i := 1; i <= 300; i++ {
+		b.WriteString("line ")
+		b.WriteString(strings.Repeat("x", i%7))
+		b.WriteString("\n")
+	}
+	if err := os.WriteFile(p, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewReadFileTool(1024)
+
+	out, err := tool.Execute(context.Background(), map[string]any{"path": p, "start_line": 8, "end_line": 9, "line_numbers": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "     8\tline x\n     9\tline xx\n" {
+		t.Fatalf("got %q", out)
+	}
+
+	// Truncation names the next line to read.
+	out, err = tool.Execute(context.Background(), map[string]any{"path": p, "start_line": float64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "...(truncated at 1024 bytes; use start_line=") {
+		t.Fatalf("expected truncation note, got %q", out[max(0, len(out)-120):])
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": p, "start_line": 500}); err == nil {
+		t.Fatal("expected error for start_line past EOF")
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": p, "start_line": 5, "end_line": 2}); err == nil {
+		t.Fatal("expected error for start_line > end_line")
+	}
+}
+
+func TestReadFileTool_Offset(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(p, []byte("0123456789abcdefghij"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewReadFileTool(8)
+
+	out, err := tool.Execute(context.Background(), map[string]any{"path": p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "01234567\n...(truncated") || !strings.Contains(out, "offset=8") {
+		t.Fatalf("got %q", out)
+	}
+	out, err = tool.Execute(context.Background(), map[string]any{"path": p, "offset": 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "ghij" {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestReadFileTool_Encodings(t *testing.T) {
+	dir := t.TempDir()
+	latin := filepath.Join(dir, "latin1.txt")
+	if err := os.WriteFile(latin, []byte("na\xefve caf\xe9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewReadFileTool(1024)
+	out, err := tool.Execute(context.Background(), map[string]any{"path": latin, "encoding": "latin1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "naïve café\n" {
+		t.Fatalf("got %q", out)
+	}
+
+	utf16 := filepath.Join(dir, "utf16.txt")
+	if err := os.WriteFile(utf16, []byte("\xff\xfeo\x00k\x00\n\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = tool.Execute(context.Background(), map[string]any{"path": utf16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "ok\n" {
+		t.Fatalf("got %q", out)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": latin, "encoding": "bogus"}); err == nil {
+		t.Fatal("expected unknown encoding error")
+	}
+
+	bin := filepath.Join(dir, "blob.bin")
+	if err := os.WriteFile(bin, []byte{0x7f, 'E', 'L', 'F', 0, 0, 1, 2}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": bin}); err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Fatalf("expected binary file error, got %v", err)
+	}
+}
+
+func TestReadFileTool_Documents(t *testing.T) {
+	dir := t.TempDir()
+	html := filepath.Join(dir, "page") // no extension: detected by content
+	if err := os.WriteFile(html, []byte("<!doctype html><html><body><script>x()</script><h1>Report</h1><p>Q3 revenue</p></body></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewReadFileTool(1024)
+	out, err := tool.Execute(context.Background(), map[string]any{"path": html})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "Report\n\nQ3 revenue\n" {
+		t.Fatalf("got %q", out)
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]any{"path": html, "start_line": 3, "line_numbers": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "     3\tQ3 revenue\n" {
+		t.Fatalf("got %q", out)
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]any{"path": html, "raw": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "<script>") {
+		t.Fatalf("raw=true should return the source, got %q", out)
+	}
+
+	csvPath := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(csvPath, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tool.MaxDocumentBytes = 4
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": csvPath}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Fatalf("expected document size error, got %v", err)
+	}
+	tool.MaxDocumentBytes = 0
+	out, err = tool.Execute(context.Background(), map[string]any{"path": csvPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "a | b\n1 | 2\n" {
+		t.Fatalf("got %q", out)
+	}
+}
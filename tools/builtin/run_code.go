@@ -0,0 +1,314 @@
+package builtin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// RunCodeTool executes short Python or JavaScript programs in a working
+// directory under file_cache_dir. JavaScript runs in-process (goja) and can
+// only touch files under file_cache_dir; Python runs as a subprocess with a
+// scrubbed environment inside the bash sandbox, or on the host only when
+// AllowHostPython is set.
+type RunCodeTool struct {
+	BaseDir        string // file_cache_dir
+	Python         string // interpreter; empty disables python
+	Timeout        time.Duration
+	MaxOutputBytes int
+	// Sandbox, when enabled, runs Python in user/mount/network namespaces with
+	// BaseDir as the only writable directory.
+	Sandbox *BashSandbox
+	// AllowHostPython lets Python run without Sandbox, with the agent user's
+	// full file access.
+	AllowHostPython bool
+	// JSMaxMemoryMB interrupts JavaScript whose heap grows by more than this
+	// (0 = unlimited). goja runs in-process, so this protects the agent itself.
+	JSMaxMemoryMB int
+}
+
+func NewRunCodeTool(baseDir, python string, timeout time.Duration, maxOutputBytes int) *RunCodeTool {
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = 64 * 1024
+	}
+	return &RunCodeTool{
+		BaseDir:        strings.TrimSpace(baseDir),
+		Python:         strings.TrimSpace(python),
+		Timeout:        timeout,
+		MaxOutputBytes: maxOutputBytes,
+		JSMaxMemoryMB:  256,
+	}
+}
+
+const runCodeMaxReportedFiles = 50
+
+func (t *RunCodeTool) Name() string { return "run_code" }
+
+func (t *RunCodeTool) Description() string {
+	desc := "Runs a short program for data wrangling or calculations and returns stdout/stderr plus the files it created. " +
+		"Each agent run gets its own working directory under file_cache_dir (files persist between calls in the same run). "
+	if t.pythonEnabled() {
+		desc += "python: a python3 script (stdin is the optional input as JSON). "
+	}
+	desc += "javascript: ES5.1+ with console.log, a global `input`, and fs.readFile/writeFile/listDir/exists for paths under file_cache_dir (relative to the working directory); no network or modules."
+	return desc
+}
+
+// pythonEnabled reports whether Python is configured and may run: inside the
+// sandbox, or on the host when explicitly allowed.
+func (t *RunCodeTool) pythonEnabled() bool {
+	if t.Python == "" {
+		return false
+	}
+	return (t.Sandbox != nil && t.Sandbox.Enabled) || t.AllowHostPython
+}
+
+func (t *RunCodeTool) languages() []string {
+	if t.pythonEnabled() {
+		return []string{"python", "javascript"}
+	}
+	return []string{"javascript"}
+}
+
+func (t *RunCodeTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"language": map[string]any{
+				"type":        "string",
+				"enum":        t.languages(),
+				"description": "Language of code.",
+			},
+			"code": map[string]any{
+				"type":        "string",
+				"description": "Program source.",
+			},
+			"input": map[string]any{
+				"description": "Optional JSON value passed to the program (python: stdin; javascript: global `input`).",
+			},
+			"timeout_seconds": map[string]any{
+				"type":        "number",
+				"description": fmt.Sprintf("Optional timeout in seconds (max %d).", int(t.Timeout/time.Second)),
+			},
+		},
+		"required": []string{"language", "code"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *RunCodeTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	lang, _ := params["language"].(string)
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		return "", fmt.Errorf("missing required param: language")
+	}
+	code, _ := params["code"].(string)
+	if strings.TrimSpace(code) == "" {
+		return "", fmt.Errorf("missing required param: code")
+	}
+	var input []byte
+	if v, ok := params["input"]; ok && v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid param: input: %w", err)
+		}
+		input = b
+	}
+	timeout := t.Timeout
+	if secs, ok := asFloat64(params["timeout_seconds"]); ok && secs > 0 {
+		timeout = min(timeout, time.Duration(secs*float64(time.Second)))
+	}
+
+	baseAbs, workDir, err := t.workDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	before := snapshotFiles(workDir)
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{Limit: t.MaxOutputBytes}
+	stderr := &limitedBuffer{Limit: t.MaxOutputBytes}
+	var exitCode int
+	switch lang {
+	case "python", "py", "python3":
+		if t.Python == "" {
+			return "", fmt.Errorf("python is not enabled for run_code (configure tools.run_code.python)")
+		}
+		if !t.pythonEnabled() {
+			return "", fmt.Errorf("python requires tools.run_code.sandbox.enabled (or tools.run_code.allow_host_python)")
+		}
+		exitCode, err = t.runPython(runCtx, workDir, code, input, stdout, stderr)
+	case "javascript", "js":
+		exitCode, err = runJavaScript(runCtx, baseAbs, workDir, code, input, stdout, stderr, uint64(max(t.JSMaxMemoryMB, 0))<<20)
+	default:
+		return "", fmt.Errorf("unsupported language: %s (use %s)", lang, strings.Join(t.languages(), " or "))
+	}
+	if err != nil && runCtx.Err() == nil {
+		return "", err
+	}
+	out := fmt.Sprintf("workdir: %s\n", workDir) + formatBashOutput(exitCode, stdout, stderr) + formatNewFiles(workDir, before)
+	if err != nil {
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return out, fmt.Errorf("run_code timed out after %s", timeout)
+		}
+		return out, err
+	}
+	if exitCode != 0 {
+		return out, fmt.Errorf("%s exited with code %d", lang, exitCode)
+	}
+	return out, nil
+}
+
+var runCodeDirRe = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// workDir returns file_cache_dir and the per-run working directory below it,
+// creating both as needed.
+func (t *RunCodeTool) workDir(ctx context.Context) (string, string, error) {
+	id, _ := tools.RunIDFromContext(ctx)
+	id = strings.Trim(runCodeDirRe.ReplaceAllString(id, "_"), "._")
+	if id == "" {
+		var b [8]byte
+		_, _ = rand.Read(b[:])
+		id = hex.EncodeToString(b[:])
+	}
+	baseAbs, dir, err := resolveWritePath(t.BaseDir, filepath.Join("run_code", id))
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".tmp"), 0o700); err != nil {
+		return "", "", err
+	}
+	return baseAbs, dir, nil
+}
+
+// runCodeEnvKeys are passed through to Python; everything else (API keys,
+// tokens, proxy settings) is dropped.
+var runCodeEnvKeys = []string{"PATH", "HOME", "LANG", "LC_ALL", "LC_CTYPE", "TZ", "VIRTUAL_ENV", "PYTHONPATH"}
+
+func runCodeEnv(workDir string) []string {
+	var env []string
+	for _, k := range runCodeEnvKeys {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	return append(env,
+		"TMPDIR="+filepath.Join(workDir, ".tmp"),
+		"PYTHONIOENCODING=utf-8",
+		"PYTHONDONTWRITEBYTECODE=1",
+		"MPLBACKEND=Agg",
+	)
+}
+
+func (t *RunCodeTool) runPython(ctx context.Context, workDir, code string, input []byte, stdout, stderr *limitedBuffer) (int, error) {
+	script := filepath.Join(workDir, ".tmp", "main.py")
+	if err := os.WriteFile(script, []byte(code), 0o600); err != nil {
+		return 0, err
+	}
+	defer os.Remove(script)
+
+	var cmd *exec.Cmd
+	if t.Sandbox != nil && t.Sandbox.Enabled {
+		sb := *t.Sandbox
+		sb.Workspace = t.BaseDir
+		c, err := sb.commandEnv(ctx, "exec "+shellQuote(t.Python)+" -u "+shellQuote(script), workDir, runCodeEnv(workDir))
+		if err != nil {
+			return 0, err
+		}
+		cmd = c
+	} else {
+		cmd = exec.CommandContext(ctx, t.Python, "-u", script)
+		cmd.Dir = workDir
+		cmd.Env = runCodeEnv(workDir)
+	}
+	// Kill the whole process group on timeout so child processes don't linger.
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		killProcessGroup(cmd)
+		return nil
+	}
+	cmd.WaitDelay = 2 * time.Second
+	if input != nil {
+		cmd.Stdin = strings.NewReader(string(input))
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	if ee, ok := err.(*exec.ExitError); ok {
+		return ee.ExitCode(), nil
+	}
+	return 0, err
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func snapshotFiles(dir string) map[string]fileStamp {
+	out := map[string]fileStamp{}
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".tmp" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if fi, err := d.Info(); err == nil {
+			out[p] = fileStamp{size: fi.Size(), modTime: fi.ModTime()}
+		}
+		return nil
+	})
+	return out
+}
+
+// formatNewFiles lists files in dir that were created or modified since before.
+func formatNewFiles(dir string, before map[string]fileStamp) string {
+	var changed []string
+	after := snapshotFiles(dir)
+	for p, st := range after {
+		if old, ok := before[p]; !ok || old != st {
+			changed = append(changed, p)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+	sort.Strings(changed)
+	var b strings.Builder
+	b.WriteString("\n\nfiles:\n")
+	for i, p := range changed {
+		if i == runCodeMaxReportedFiles {
+			fmt.Fprintf(&b, "...(%d more)\n", len(changed)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s (%d bytes)\n", p, after[p].size)
+	}
+	return b.String()
+}
@@ -0,0 +1,269 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// runCodeJSMaxFileBytes bounds fs.readFile so a script can't pull huge files into memory.
+const runCodeJSMaxFileBytes = 32 * 1024 * 1024
+
+// jsHeapCheckInterval is how often the heap watchdog samples memory.
+const jsHeapCheckInterval = 10 * time.Millisecond
+
+// runJavaScript evaluates code in a fresh goja runtime. A thrown exception is
+// reported on stderr with exit code 1, like an uncaught error in node. When
+// maxHeapBytes > 0 the script is interrupted once the heap grows that much.
+func runJavaScript(ctx context.Context, baseDir, workDir, code string, input []byte, stdout, stderr io.Writer, maxHeapBytes uint64) (int, error) {
+	vm := goja.New()
+	vm.SetMaxCallStackSize(2048)
+	stop := context.AfterFunc(ctx, func() { vm.Interrupt(ctx.Err()) })
+	defer stop()
+	stopWatch := watchJSHeap(vm, maxHeapBytes)
+	defer stopWatch()
+
+	js := &jsEnv{vm: vm, baseDir: baseDir, workDir: workDir}
+	console := vm.NewObject()
+	_ = console.Set("log", js.printer(stdout))
+	_ = console.Set("info", js.printer(stdout))
+	_ = console.Set("warn", js.printer(stderr))
+	_ = console.Set("error", js.printer(stderr))
+	_ = vm.Set("console", console)
+	_ = vm.Set("print", js.printer(stdout))
+
+	fsObj := vm.NewObject()
+	_ = fsObj.Set("readFile", js.readFile)
+	_ = fsObj.Set("writeFile", js.writeFile)
+	_ = fsObj.Set("appendFile", js.appendFile)
+	_ = fsObj.Set("listDir", js.listDir)
+	_ = fsObj.Set("exists", js.exists)
+	_ = vm.Set("fs", fsObj)
+
+	if input != nil {
+		var v any
+		if err := json.Unmarshal(input, &v); err != nil {
+			return 0, err
+		}
+		_ = vm.Set("input", v)
+	} else {
+		_ = vm.Set("input", goja.Null())
+	}
+
+	res, err := vm.RunString(code)
+	if err != nil {
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			if memErr, ok := interrupted.Value().(error); ok && errors.Is(memErr, errJSMemoryLimit) {
+				return -1, memErr
+			}
+			return -1, ctx.Err()
+		}
+		var exc *goja.Exception
+		if errors.As(err, &exc) {
+			fmt.Fprintln(stderr, exc.String())
+			return 1, nil
+		}
+		// Syntax errors.
+		fmt.Fprintln(stderr, err.Error())
+		return 1, nil
+	}
+	// Like a REPL, print the value of the last expression.
+	if res != nil && !goja.IsUndefined(res) && !goja.IsNull(res) {
+		fmt.Fprintln(stdout, js.format(res))
+	}
+	return 0, nil
+}
+
+var errJSMemoryLimit = errors.New("javascript memory limit exceeded")
+
+// watchJSHeap interrupts vm when the Go heap grows more than limit bytes over
+// its size at start. goja has no per-runtime accounting, so this watches the
+// whole process; a GC runs before giving up so garbage is not counted. A
+// single native call that allocates a lot at once (e.g. "x".repeat(n)) can
+// still overshoot before the next check.
+func watchJSHeap(vm *goja.Runtime, limit uint64) (stop func()) {
+	if limit == 0 {
+		return func() {}
+	}
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	heap := func() uint64 {
+		metrics.Read(sample)
+		return sample[0].Value.Uint64()
+	}
+	base := heap()
+	done := make(chan struct{})
+	go func() {
+		tick := time.NewTicker(jsHeapCheckInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+			}
+			if heap() <= base+limit {
+				continue
+			}
+			runtime.GC()
+			if heap() > base+limit {
+				vm.Interrupt(fmt.Errorf("%w (%d MB)", errJSMemoryLimit, limit>>20))
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+type jsEnv struct {
+	vm      *goja.Runtime
+	baseDir string
+	workDir string
+}
+
+func (e *jsEnv) printer(w io.Writer) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, len(call.Arguments))
+		for i, a := range call.Arguments {
+			parts[i] = e.format(a)
+		}
+		fmt.Fprintln(w, strings.Join(parts, " "))
+		return goja.Undefined()
+	}
+}
+
+// format renders strings as-is and everything else as JSON, falling back to
+// the JS string conversion for values JSON can't represent.
+func (e *jsEnv) format(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if s, ok := v.Export().(string); ok {
+		return s
+	}
+	if _, isFn := goja.AssertFunction(v); !isFn {
+		if b, err := json.Marshal(v.Export()); err == nil {
+			return string(b)
+		}
+	}
+	return v.String()
+}
+
+func (e *jsEnv) throw(err error) {
+	panic(e.vm.NewGoError(err))
+}
+
+// resolve maps a script path (relative to the working directory) to a path
+// under baseDir, refusing anything that leaves it, including through symlinks.
+func (e *jsEnv) resolve(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		e.throw(fmt.Errorf("path is required"))
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(e.workDir, p)
+	}
+	p = filepath.Clean(p)
+	if p != e.baseDir && !isWithinDir(e.baseDir, p) {
+		e.throw(fmt.Errorf("path is outside file_cache_dir: %s", p))
+	}
+	// Resolve symlinks on the longest existing prefix.
+	existing := p
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err == nil {
+		realBase, err := filepath.EvalSymlinks(e.baseDir)
+		if err == nil && real != realBase && !isWithinDir(realBase, real) {
+			e.throw(fmt.Errorf("path is outside file_cache_dir: %s", p))
+		}
+	}
+	return p
+}
+
+func (e *jsEnv) readFile(path string) string {
+	p := e.resolve(path)
+	fi, err := os.Stat(p)
+	if err != nil {
+		e.throw(err)
+	}
+	if fi.Size() > runCodeJSMaxFileBytes {
+		e.throw(fmt.Errorf("%s is too large (%d bytes)", path, fi.Size()))
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		e.throw(err)
+	}
+	return string(b)
+}
+
+func (e *jsEnv) writeFile(path, content string) {
+	e.write(path, content, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+}
+
+func (e *jsEnv) appendFile(path, content string) {
+	e.write(path, content, os.O_CREATE|os.O_WRONLY|os.O_APPEND)
+}
+
+func (e *jsEnv) write(path, content string, flag int) {
+	p := e.resolve(path)
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		e.throw(err)
+	}
+	f, err := os.OpenFile(p, flag, 0o600)
+	if err != nil {
+		e.throw(err)
+	}
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		e.throw(err)
+	}
+}
+
+func (e *jsEnv) listDir(call goja.FunctionCall) goja.Value {
+	path := "."
+	if len(call.Arguments) > 0 && !goja.IsUndefined(call.Arguments[0]) {
+		path = call.Arguments[0].String()
+	}
+	entries, err := os.ReadDir(e.resolve(path))
+	if err != nil {
+		e.throw(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, ent := range entries {
+		name := ent.Name()
+		if ent.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return e.vm.ToValue(names)
+}
+
+func (e *jsEnv) exists(path string) bool {
+	_, err := os.Stat(e.resolve(path))
+	return err == nil
+}
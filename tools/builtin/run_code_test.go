@@ -0,0 +1,111 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+func TestRunCodeTool_JavaScript(t *testing.T) {
+	base := t.TempDir()
+	writeFile(t, filepath.Join(base, "data.csv"), "a,b\n1,2\n3,4\n")
+	tool := NewRunCodeTool(base, "", 5*time.Second, 0)
+	ctx := tools.WithRunID(context.Background(), "run/1")
+
+	out, err := tool.Execute(ctx, map[string]any{
+		"language": "javascript",
+		"code": `
+var rows = fs.readFile("../../data.csv").trim().split("\n").slice(1);
+var sum = 0;
+rows.forEach(function (r) { sum += Number(r.split(",")[1]); });
+fs.writeFile("out/sum.txt", String(sum * input.factor));
+console.log("rows", rows.length, {ok: true});
+sum`,
+		"input": map[string]any{"factor": 10},
+	})
+	if err != nil {
+		t.Fatalf("err=%v out=%s", err, out)
+	}
+	workDir := filepath.Join(base, "run_code", "run_1")
+	for _, want := range []string{"workdir: " + workDir, `rows 2 {"ok":true}`, "\n6\n", filepath.Join(workDir, "out", "sum.txt") + " (2 bytes)"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if b, _ := os.ReadFile(filepath.Join(workDir, "out", "sum.txt")); string(b) != "60" {
+		t.Fatalf("sum.txt=%q", b)
+	}
+
+	// Files left from the previous call are not reported again.
+	out, err = tool.Execute(ctx, map[string]any{"language": "js", "code": `fs.listDir("out")`})
+	if err != nil || strings.Contains(out, "files:") || !strings.Contains(out, `["sum.txt"]`) {
+		t.Fatalf("err=%v out=%s", err, out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"language": "javascript", "code": `fs.readFile("/etc/passwd")`})
+	if err == nil || !strings.Contains(out, "outside file_cache_dir") {
+		t.Fatalf("expected path escape to fail, err=%v out=%s", err, out)
+	}
+
+	_, err = tool.Execute(ctx, map[string]any{"language": "javascript", "code": `while (true) {}`, "timeout_seconds": 0.2})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+
+	tool.JSMaxMemoryMB = 32
+	_, err = tool.Execute(ctx, map[string]any{"language": "javascript", "code": `var a = []; while (true) { a.push(new Array(1024).fill(a.length)); }`})
+	if err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Fatalf("expected memory limit error, got %v", err)
+	}
+	_, err = tool.Execute(ctx, map[string]any{"language": "javascript", "code": `var s = "x"; while (true) { s += s; }`})
+	if err == nil || !strings.Contains(err.Error(), "memory limit") {
+		t.Fatalf("expected memory limit error, got %v", err)
+	}
+
+	if _, err := tool.Execute(ctx, map[string]any{"language": "python", "code": "print(1)"}); err == nil {
+		t.Fatal("expected python to be unavailable")
+	}
+}
+
+func TestRunCodeTool_Python(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+	t.Setenv("MISTER_MORPH_LLM_API_KEY", "sk-secret")
+	base := t.TempDir()
+	tool := NewRunCodeTool(base, python, 5*time.Second, 0)
+	ctx := context.Background()
+
+	if _, err := tool.Execute(ctx, map[string]any{"language": "python", "code": "print(1)"}); err == nil || !strings.Contains(err.Error(), "sandbox") {
+		t.Fatalf("expected python without a sandbox to be refused, got %v", err)
+	}
+	tool.AllowHostPython = true
+
+	out, err := tool.Execute(ctx, map[string]any{
+		"language": "python",
+		"code": `import json, os, sys
+data = json.load(sys.stdin)
+open("report.txt", "w").write(str(sum(data)))
+print(os.environ.get("MISTER_MORPH_LLM_API_KEY", "no-key"))
+`,
+		"input": []any{1, 2, 3},
+	})
+	if err != nil {
+		t.Fatalf("err=%v out=%s", err, out)
+	}
+	if !strings.Contains(out, "no-key") || !strings.Contains(out, "report.txt (1 bytes)") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"language": "python", "code": "import sys\nsys.exit(3)"})
+	if err == nil || !strings.Contains(out, "exit_code: 3") {
+		t.Fatalf("err=%v out=%s", err, out)
+	}
+}
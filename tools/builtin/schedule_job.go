@@ -43,6 +43,8 @@ func (t *ScheduleJobTool) ParameterSchema() string {
     "interval_seconds": { "type": "integer", "description": "Fixed interval schedule in seconds (alternative to schedule). Note: repeats forever unless run_once=true." },
     "run_once": { "type": "boolean", "description": "If true, disable the job after its next scheduled enqueue (one-shot execution)." },
     "notify_telegram_chat_id": { "type": "integer", "description": "Optional Telegram chat_id to notify with the run result (best-effort; requires runtime support)." },
+    "notify_email": { "type": "string", "description": "Optional comma-separated email recipients for the run result (requires send_email to be configured)." },
+    "notify_webhook": { "type": "string", "description": "Optional webhook name (or allowlisted URL) to POST the run result to (requires send_webhook)." },
     "model": { "type": "string", "description": "Optional model override." },
     "timeout_seconds": { "type": "integer", "description": "Optional per-run timeout override (seconds)." },
     "overlap_policy": { "type": "string", "description": "Overlap policy: forbid|queue|replace (default forbid)." }
@@ -90,6 +92,8 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 	}
 
 	notifyTelegramChatID := getInt64(params, "notify_telegram_chat_id")
+	notifyEmail := strings.TrimSpace(getString(params, "notify_email"))
+	notifyWebhook := strings.TrimSpace(getString(params, "notify_webhook"))
 
 	model := strings.TrimSpace(getString(params, "model"))
 	timeoutSeconds := getInt64(params, "timeout_seconds")
@@ -135,6 +139,16 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 		} else {
 			j.NotifyTelegramChatID = nil
 		}
+		if notifyEmail != "" {
+			j.NotifyEmail = &notifyEmail
+		} else {
+			j.NotifyEmail = nil
+		}
+		if notifyWebhook != "" {
+			j.NotifyWebhook = &notifyWebhook
+		} else {
+			j.NotifyWebhook = nil
+		}
 	}
 
 	isCreate := errors.Is(err, gorm.ErrRecordNotFound)
@@ -164,6 +178,8 @@ func (t *ScheduleJobTool) Execute(ctx context.Context, params map[string]any) (s
 			}
 			return *job.NotifyTelegramChatID
 		}(),
+		"notify_email":   job.NotifyEmail,
+		"notify_webhook": job.NotifyWebhook,
 		"updated_at_utc": func() string {
 			if job.UpdatedAt == 0 {
 				return ""
@@ -174,6 +174,12 @@ func (t *SearchJobsTool) Execute(ctx context.Context, params map[string]any) (st
 		if j.NotifyTelegramChatID != nil {
 			item["notify_telegram_chat_id"] = *j.NotifyTelegramChatID
 		}
+		if j.NotifyEmail != nil {
+			item["notify_email"] = *j.NotifyEmail
+		}
+		if j.NotifyWebhook != nil {
+			item["notify_webhook"] = *j.NotifyWebhook
+		}
 		item["updated_at_utc"] = time.Unix(j.UpdatedAt, 0).UTC().Format(time.RFC3339)
 		item["task_preview"] = truncate(j.Task, 200)
 		out = append(out, item)
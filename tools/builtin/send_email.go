@@ -0,0 +1,356 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SendEmailTool sends plain-text email through one configured SMTP server.
+// Recipients must match AllowedRecipients; SMTP credentials come from an
+// auth profile with a "send_email" binding.
+type SendEmailTool struct {
+	// Server is smtp://host[:port] (STARTTLS when offered, default port 587)
+	// or smtps://host[:port] (implicit TLS, default port 465).
+	Server string
+	From   string
+	// AllowedRecipients lists addresses ("ops@example.com") or domains
+	// ("@example.com"). Empty means no recipient is allowed.
+	AllowedRecipients []string
+	AuthProfile       string
+	Auth              *URLFetchAuth
+	Timeout           time.Duration
+	MaxBodyBytes      int
+	// TLSConfig overrides the client TLS config (e.g. a private CA).
+	TLSConfig *tls.Config
+}
+
+func NewSendEmailTool(server, from string, allowedRecipients []string, timeout time.Duration) *SendEmailTool {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &SendEmailTool{
+		Server:            strings.TrimSpace(server),
+		From:              strings.TrimSpace(from),
+		AllowedRecipients: allowedRecipients,
+		Timeout:           timeout,
+		MaxBodyBytes:      256 * 1024,
+	}
+}
+
+// EmailMessage is a plain-text email sent by SendEmailTool.Send.
+type EmailMessage struct {
+	To      []string
+	Cc      []string
+	Subject string
+	Body    string
+}
+
+func (t *SendEmailTool) Name() string { return "send_email" }
+
+func (t *SendEmailTool) Description() string {
+	return "Sends a plain-text email via the configured SMTP server. Only allowlisted recipients can be used: " + strings.Join(t.AllowedRecipients, ", ") + "."
+}
+
+func (t *SendEmailTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"to": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Recipient addresses.",
+			},
+			"cc": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional CC addresses.",
+			},
+			"subject": map[string]any{
+				"type":        "string",
+				"description": "Subject line.",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Plain-text body.",
+			},
+		},
+		"required": []string{"to", "subject", "body"},
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *SendEmailTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	to, ok := asStringSlice(params["to"])
+	if !ok || len(to) == 0 {
+		return "", fmt.Errorf("missing required param: to")
+	}
+	cc, ok := asStringSlice(params["cc"])
+	if !ok {
+		return "", fmt.Errorf("invalid param: cc must be an array of addresses")
+	}
+	subject, _ := params["subject"].(string)
+	if strings.TrimSpace(subject) == "" {
+		return "", fmt.Errorf("missing required param: subject")
+	}
+	body, _ := params["body"].(string)
+	if strings.TrimSpace(body) == "" {
+		return "", fmt.Errorf("missing required param: body")
+	}
+	msgID, err := t.Send(ctx, EmailMessage{To: to, Cc: cc, Subject: subject, Body: body})
+	if err != nil {
+		return "", err
+	}
+	b, _ := json.Marshal(map[string]any{
+		"ok":         true,
+		"message_id": msgID,
+		"recipients": len(to) + len(cc),
+	})
+	return string(b), nil
+}
+
+// Send delivers m and returns its Message-ID.
+func (t *SendEmailTool) Send(ctx context.Context, m EmailMessage) (string, error) {
+	from, err := mail.ParseAddress(t.From)
+	if err != nil {
+		return "", fmt.Errorf("send_email: invalid from address (configure tools.send_email.from): %w", err)
+	}
+	var to, cc []*mail.Address
+	for _, list := range []struct {
+		raw []string
+		dst *[]*mail.Address
+	}{{m.To, &to}, {m.Cc, &cc}} {
+		for _, raw := range list.raw {
+			addr, err := mail.ParseAddress(strings.TrimSpace(raw))
+			if err != nil {
+				return "", fmt.Errorf("invalid recipient %q: %w", raw, err)
+			}
+			if !t.recipientAllowed(addr.Address) {
+				return "", fmt.Errorf("recipient %q is not allowed (configure tools.send_email.allowed_recipients)", addr.Address)
+			}
+			*list.dst = append(*list.dst, addr)
+		}
+	}
+	if len(to) == 0 {
+		return "", fmt.Errorf("missing required param: to")
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return "", fmt.Errorf("subject must be a single line")
+	}
+	if t.MaxBodyBytes > 0 && len(m.Body) > t.MaxBodyBytes {
+		return "", fmt.Errorf("body too large (%d bytes > %d)", len(m.Body), t.MaxBodyBytes)
+	}
+
+	u, err := url.Parse(t.Server)
+	if err != nil || (u.Scheme != "smtp" && u.Scheme != "smtps") || u.Hostname() == "" {
+		return "", fmt.Errorf("send_email: invalid server %q (want smtp://host:port or smtps://host:port)", t.Server)
+	}
+	var auth smtp.Auth
+	if strings.TrimSpace(t.AuthProfile) != "" {
+		if auth, err = t.resolveAuth(ctx, strings.TrimSpace(t.AuthProfile), u); err != nil {
+			return "", err
+		}
+	}
+
+	msgID := newMessageID(from.Address)
+	raw, err := buildEmail(from, to, cc, m.Subject, m.Body, msgID)
+	if err != nil {
+		return "", err
+	}
+	rcpts := make([]string, 0, len(to)+len(cc))
+	for _, a := range append(append([]*mail.Address(nil), to...), cc...) {
+		rcpts = append(rcpts, a.Address)
+	}
+	if err := t.deliver(ctx, u, auth, from.Address, rcpts, raw); err != nil {
+		return "", fmt.Errorf("send_email: %w", err)
+	}
+	return msgID, nil
+}
+
+func (t *SendEmailTool) recipientAllowed(addr string) bool {
+	addr = strings.ToLower(addr)
+	for _, a := range t.AllowedRecipients {
+		a = strings.ToLower(strings.TrimSpace(a))
+		switch {
+		case a == "":
+		case strings.HasPrefix(a, "@"):
+			if strings.HasSuffix(addr, a) {
+				return true
+			}
+		case a == addr:
+			return true
+		}
+	}
+	return false
+}
+
+func (t *SendEmailTool) resolveAuth(ctx context.Context, profileID string, u *url.URL) (smtp.Auth, error) {
+	if t.Auth == nil || !t.Auth.Enabled {
+		return nil, fmt.Errorf("send_email auth_profile requires secrets.enabled=true")
+	}
+	if t.Auth.AllowProfiles == nil || !t.Auth.AllowProfiles[profileID] {
+		return nil, fmt.Errorf("auth_profile %q is not allowed (fail-closed)", profileID)
+	}
+	if t.Auth.Profiles == nil || t.Auth.Resolver == nil {
+		return nil, fmt.Errorf("auth_profile is enabled but profile store or resolver is not configured")
+	}
+	p, ok := t.Auth.Profiles.Get(profileID)
+	if !ok {
+		return nil, fmt.Errorf("auth_profile not found: %q", profileID)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid auth_profile %q: %w", profileID, err)
+	}
+	if err := p.IsURLAllowed(u, ""); err != nil {
+		return nil, err
+	}
+	binding, ok := p.Bindings[t.Name()]
+	if !ok {
+		return nil, fmt.Errorf("auth_profile %q has no binding for tool %q", profileID, t.Name())
+	}
+	if !strings.EqualFold(strings.TrimSpace(binding.Inject.Location), "smtp") {
+		return nil, fmt.Errorf("auth_profile %q: send_email binding needs inject.location=smtp", profileID)
+	}
+	user := strings.TrimSpace(p.Credential.Username)
+	if user == "" {
+		return nil, fmt.Errorf("auth_profile %q: credential.username is required for smtp", profileID)
+	}
+	sec, err := t.Auth.Resolver.Resolve(ctx, p.Credential.SecretRef)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(strings.TrimSpace(binding.Inject.Format), "cram-md5") {
+		return smtp.CRAMMD5Auth(user, sec), nil
+	}
+	// PlainAuth refuses to send credentials without TLS (except to localhost).
+	return smtp.PlainAuth("", user, sec, u.Hostname()), nil
+}
+
+func (t *SendEmailTool) deliver(ctx context.Context, u *url.URL, auth smtp.Auth, from string, rcpts []string, msg []byte) error {
+	host := u.Hostname()
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"smtp": "587", "smtps": "465"}[u.Scheme]
+	}
+	tlsCfg := &tls.Config{ServerName: host}
+	if t.TLSConfig != nil {
+		tlsCfg = t.TLSConfig.Clone()
+		if tlsCfg.ServerName == "" {
+			tlsCfg.ServerName = host
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: t.Timeout}
+	addr := net.JoinHostPort(host, port)
+	var (
+		conn net.Conn
+		err  error
+	)
+	if u.Scheme == "smtps" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsCfg}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(t.Timeout))
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if u.Scheme == "smtp" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsCfg); err != nil {
+				return err
+			}
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("server does not support AUTH")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, r := range rcpts {
+		if err := c.Rcpt(r); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func newMessageID(from string) string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 && i+1 < len(from) {
+		domain = from[i+1:]
+	}
+	return "<" + hex.EncodeToString(b[:]) + "@" + domain + ">"
+}
+
+func buildEmail(from *mail.Address, to, cc []*mail.Address, subject, body, msgID string) ([]byte, error) {
+	joinAddrs := func(list []*mail.Address) string {
+		s := make([]string, len(list))
+		for i, a := range list {
+			s[i] = a.String()
+		}
+		return strings.Join(s, ", ")
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", joinAddrs(to))
+	if len(cc) > 0 {
+		fmt.Fprintf(&b, "Cc: %s\r\n", joinAddrs(cc))
+	}
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", msgID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	b.WriteString("\r\n")
+	return b.Bytes(), nil
+}
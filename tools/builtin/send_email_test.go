@@ -0,0 +1,170 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/secrets"
+)
+
+// smtpStub is a minimal SMTP server that records one session per connection.
+type smtpStub struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	auth  string
+	from  string
+	rcpts []string
+	data  string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStub{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStub) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+	reply("220 stub ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250-stub")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			parts := strings.Fields(line)
+			if len(parts) == 3 {
+				b, _ := base64.StdEncoding.DecodeString(parts[2])
+				s.auth = string(b)
+			}
+			reply("235 ok")
+		case "MAIL":
+			s.from = line
+			reply("250 ok")
+		case "RCPT":
+			s.rcpts = append(s.rcpts, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.data = b.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("250 ok")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestSendEmailTool_Send(t *testing.T) {
+	stub := newSMTPStub(t)
+	server := "smtp://" + stub.ln.Addr().String()
+	t.Setenv("TEST_SMTP_PASSWORD", "hunter2")
+
+	denyPrivate := false
+	profile := secrets.AuthProfile{
+		ID:         "mail",
+		Credential: secrets.Credential{Kind: "password", Username: "bot@example.com", SecretRef: "TEST_SMTP_PASSWORD"},
+		Allow:      secrets.Allow{URLPrefixes: []string{server}, DenyPrivateIPs: &denyPrivate},
+		Bindings:   map[string]secrets.ToolBinding{"send_email": {Inject: secrets.Inject{Location: "smtp"}}},
+	}
+	if err := profile.Validate(); err != nil {
+		t.Fatalf("smtp profile should validate: %v", err)
+	}
+
+	tool := NewSendEmailTool(server, "Morph <bot@example.com>", []string{"ops@example.com", "@team.example.org"}, 5*time.Second)
+	tool.AuthProfile = "mail"
+	tool.Auth = &URLFetchAuth{
+		Enabled:       true,
+		AllowProfiles: map[string]bool{"mail": true},
+		Profiles:      secrets.NewProfileStore(map[string]secrets.AuthProfile{"mail": profile}),
+		Resolver:      &secrets.EnvResolver{},
+	}
+
+	out, err := tool.Execute(context.Background(), map[string]any{
+		"to":      []any{"ops@example.com"},
+		"cc":      "Ana <ana@team.example.org>",
+		"subject": "Daily report ✓",
+		"body":    "All good.\nSee you tomorrow.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"ok":true`) || !strings.Contains(out, `"recipients":2`) {
+		t.Fatalf("unexpected output: %s", out)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if stub.auth != "\x00bot@example.com\x00hunter2" {
+		t.Fatalf("auth=%q", stub.auth)
+	}
+	if stub.from != "MAIL FROM:<bot@example.com>" {
+		t.Fatalf("from=%q", stub.from)
+	}
+	if len(stub.rcpts) != 2 || !strings.Contains(stub.rcpts[1], "<ana@team.example.org>") {
+		t.Fatalf("rcpts=%v", stub.rcpts)
+	}
+	for _, want := range []string{"To: <ops@example.com>\r\n", "Cc: \"Ana\" <ana@team.example.org>\r\n", "Subject: =?utf-8?q?Daily_report_=E2=9C=93?=\r\n", "All good.\r\nSee you tomorrow."} {
+		if !strings.Contains(stub.data, want) {
+			t.Fatalf("missing %q in message:\n%s", want, stub.data)
+		}
+	}
+}
+
+func TestSendEmailTool_Rejects(t *testing.T) {
+	tool := NewSendEmailTool("smtp://127.0.0.1:1", "bot@example.com", []string{"ops@example.com", "@example.org"}, time.Second)
+	ctx := context.Background()
+	cases := []map[string]any{
+		{"to": []any{"eve@evil.com"}, "subject": "hi", "body": "x"},
+		{"to": []any{"x@sub.evil-example.org"}, "subject": "hi", "body": "x"},
+		{"to": []any{"ops@example.com"}, "subject": "hi\r\nBcc: eve@evil.com", "body": "x"},
+		{"to": []any{}, "subject": "hi", "body": "x"},
+	}
+	for _, params := range cases {
+		if _, err := tool.Execute(ctx, params); err == nil || strings.Contains(err.Error(), "connect") {
+			t.Fatalf("expected validation error for %v, got %v", params, err)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/guard"
+)
+
+// WebhookTarget is a named webhook from tools.send_webhook.webhooks.
+type WebhookTarget struct {
+	URL string `mapstructure:"url"`
+	// Template is a text/template producing the JSON body. It sees .Title,
+	// .Message and .Data; the json function encodes a value as JSON.
+	// Empty sends {"title":...,"message":...,"data":...}.
+	Template    string            `mapstructure:"template"`
+	Headers     map[string]string `mapstructure:"headers"`
+	AuthProfile string            `mapstructure:"auth_profile"`
+}
+
+// WebhookPayload is the content of one webhook call.
+type WebhookPayload struct {
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message,omitempty"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// SendWebhookTool POSTs JSON to named webhooks, or to ad-hoc URLs that match
+// AllowedURLPrefixes. Redirects are never followed.
+type SendWebhookTool struct {
+	Webhooks           map[string]WebhookTarget
+	AllowedURLPrefixes []string
+	Auth               *URLFetchAuth
+	Timeout            time.Duration
+	UserAgent          string
+	HTTPClient         *http.Client
+}
+
+func NewSendWebhookTool(webhooks map[string]WebhookTarget, allowedURLPrefixes []string, timeout time.Duration, userAgent string) *SendWebhookTool {
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &SendWebhookTool{
+		Webhooks:           webhooks,
+		AllowedURLPrefixes: allowedURLPrefixes,
+		Timeout:            timeout,
+		UserAgent:          userAgent,
+	}
+}
+
+const webhookMaxResponseBytes = 4 * 1024
+
+func (t *SendWebhookTool) Name() string { return "send_webhook" }
+
+func (t *SendWebhookTool) Description() string {
+	desc := "Sends a notification as a JSON POST to a configured webhook (or an allowlisted URL) and returns the HTTP status."
+	if names := t.webhookNames(); len(names) > 0 {
+		desc += " Webhooks: " + strings.Join(names, ", ") + "."
+	}
+	return desc
+}
+
+func (t *SendWebhookTool) webhookNames() []string {
+	names := make([]string, 0, len(t.Webhooks))
+	for name := range t.Webhooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *SendWebhookTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"webhook": map[string]any{
+				"type":        "string",
+				"description": "Name of a configured webhook.",
+			},
+			"url": map[string]any{
+				"type":        "string",
+				"description": "Webhook URL (instead of webhook); must match tools.send_webhook.allowed_url_prefixes.",
+			},
+			"title": map[string]any{
+				"type":        "string",
+				"description": "Optional short title.",
+			},
+			"message": map[string]any{
+				"type":        "string",
+				"description": "Notification text.",
+			},
+			"data": map[string]any{
+				"type":        "object",
+				"description": "Optional structured data to include.",
+			},
+		},
+		"required": []string{"message"},
+	}
+	if names := t.webhookNames(); len(names) > 0 {
+		s["properties"].(map[string]any)["webhook"].(map[string]any)["enum"] = names
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *SendWebhookTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	target, _ := params["webhook"].(string)
+	if strings.TrimSpace(target) == "" {
+		target, _ = params["url"].(string)
+	}
+	if strings.TrimSpace(target) == "" {
+		return "", fmt.Errorf("missing required param: webhook or url")
+	}
+	message, _ := params["message"].(string)
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("missing required param: message")
+	}
+	title, _ := params["title"].(string)
+	status, body, err := t.Send(ctx, target, WebhookPayload{Title: title, Message: message, Data: params["data"]})
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "status: %d\n", status)
+	if body != "" {
+		fmt.Fprintf(&b, "response:\n%s\n", body)
+	}
+	if status >= 300 {
+		return b.String(), fmt.Errorf("webhook returned HTTP %d", status)
+	}
+	return b.String(), nil
+}
+
+// Send posts p to target, which is a configured webhook name or an
+// allowlisted URL. It returns the response status and the start of its body.
+func (t *SendWebhookTool) Send(ctx context.Context, target string, p WebhookPayload) (int, string, error) {
+	target = strings.TrimSpace(target)
+	wh, ok := t.Webhooks[target]
+	if !ok {
+		if !strings.Contains(target, "://") {
+			return 0, "", fmt.Errorf("unknown webhook %q", target)
+		}
+		if !guard.URLAllowedByPrefixes(target, t.AllowedURLPrefixes) {
+			return 0, "", fmt.Errorf("webhook url is not allowed (configure tools.send_webhook.allowed_url_prefixes)")
+		}
+		wh = WebhookTarget{URL: target}
+	}
+	u, err := url.Parse(strings.TrimSpace(wh.URL))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return 0, "", fmt.Errorf("invalid webhook url: %q", wh.URL)
+	}
+	body, err := renderWebhookBody(wh.Template, p)
+	if err != nil {
+		return 0, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.UserAgent != "" {
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+	if id := strings.TrimSpace(wh.AuthProfile); id != "" {
+		name, value, err := resolveToolAuthHeader(ctx, t.Auth, t.Name(), id, req)
+		if err != nil {
+			return 0, "", err
+		}
+		req.Header.Set(name, value)
+	}
+
+	client := t.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := noRedirect.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	rb, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxResponseBytes))
+	return resp.StatusCode, strings.TrimSpace(string(bytes.ToValidUTF8(rb, nil))), nil
+}
+
+func renderWebhookBody(tmpl string, p WebhookPayload) ([]byte, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return json.Marshal(p)
+	}
+	tp, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	var b bytes.Buffer
+	if err := tp.Execute(&b, p); err != nil {
+		return nil, fmt.Errorf("webhook template: %w", err)
+	}
+	if !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("webhook template did not produce valid JSON (use {{json .Message}} for strings)")
+	}
+	return b.Bytes(), nil
+}
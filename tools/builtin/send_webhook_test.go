@@ -0,0 +1,79 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendWebhookTool(t *testing.T) {
+	var got []map[string]any
+	var headers []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/hooks/a", http.StatusFound)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		var m map[string]any
+		_ = json.Unmarshal(b, &m)
+		got = append(got, m)
+		headers = append(headers, r.Header.Clone())
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	tool := NewSendWebhookTool(map[string]WebhookTarget{
+		"chat": {
+			URL:      srv.URL + "/hooks/chat",
+			Template: `{"text": {{json (printf "*%s*\n%s" .Title .Message)}}, "n": {{json .Data.count}}}`,
+			Headers:  map[string]string{"X-Token": "abc"},
+		},
+	}, []string{srv.URL + "/hooks/"}, 5*time.Second, "morph-test")
+	ctx := context.Background()
+
+	out, err := tool.Execute(ctx, map[string]any{"webhook": "chat", "title": "Build", "message": `done "ok"`, "data": map[string]any{"count": 3}})
+	if err != nil || !strings.Contains(out, "status: 200") {
+		t.Fatalf("err=%v out=%s", err, out)
+	}
+	if got[0]["text"] != "*Build*\ndone \"ok\"" || got[0]["n"] != float64(3) {
+		t.Fatalf("unexpected body: %v", got[0])
+	}
+	if headers[0].Get("X-Token") != "abc" || headers[0].Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected headers: %v", headers[0])
+	}
+
+	if _, err := tool.Execute(ctx, map[string]any{"url": srv.URL + "/hooks/adhoc", "message": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if got[1]["message"] != "hi" {
+		t.Fatalf("default payload: %v", got[1])
+	}
+
+	for _, params := range []map[string]any{
+		{"url": srv.URL + "/other", "message": "hi"},
+		{"webhook": "nope", "message": "hi"},
+		{"webhook": "chat"},
+	} {
+		if _, err := tool.Execute(ctx, params); err == nil {
+			t.Fatalf("expected error for %v", params)
+		}
+	}
+
+	tool.AllowedURLPrefixes = append(tool.AllowedURLPrefixes, srv.URL+"/redirect")
+	out, err = tool.Execute(ctx, map[string]any{"url": srv.URL + "/redirect", "message": "hi"})
+	if err == nil || !strings.Contains(out, "status: 302") || len(got) != 2 {
+		t.Fatalf("redirect must not be followed: err=%v out=%s", err, out)
+	}
+}
+
+func TestRenderWebhookBody_InvalidJSON(t *testing.T) {
+	if _, err := renderWebhookBody(`{"text": {{.Message}}}`, WebhookPayload{Message: "a b"}); err == nil {
+		t.Fatal("expected invalid JSON error")
+	}
+}
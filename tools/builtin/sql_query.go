@@ -0,0 +1,476 @@
+package builtin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/glebarez/go-sqlite" // pure-Go "sqlite" database/sql driver, also used by db/
+	"github.com/quailyquaily/mistermorph/internal/docextract"
+	"github.com/quailyquaily/mistermorph/internal/strutil"
+)
+
+// SQLQueryTool runs read-only SQL against configured SQLite files, or against
+// CSV/TSV files loaded into a throwaway in-memory database.
+type SQLQueryTool struct {
+	// Databases maps a name the model can use to a SQLite file path (from config).
+	Databases    map[string]string
+	Policy       FSPolicy // applies to csv paths
+	MaxRows      int
+	Timeout      time.Duration
+	MaxCSVBytes  int64
+	MaxCellChars int
+}
+
+func NewSQLQueryTool(databases map[string]string, policy FSPolicy, maxRows int, timeout time.Duration, maxCSVBytes int64) *SQLQueryTool {
+	if maxRows <= 0 {
+		maxRows = 200
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if maxCSVBytes <= 0 {
+		maxCSVBytes = 64 * 1024 * 1024
+	}
+	return &SQLQueryTool{
+		Databases:    databases,
+		Policy:       policy,
+		MaxRows:      maxRows,
+		Timeout:      timeout,
+		MaxCSVBytes:  maxCSVBytes,
+		MaxCellChars: 200,
+	}
+}
+
+func (t *SQLQueryTool) Name() string { return "sql_query" }
+
+func (t *SQLQueryTool) Description() string {
+	desc := "Runs a read-only SQL query (SQLite dialect; SELECT/WITH/VALUES/EXPLAIN only) and returns the result as a table. Query a configured SQLite database by name, or pass csv paths to load CSV/TSV files as tables (the table name is the file name; column names come from the header row). List tables with: SELECT name, sql FROM sqlite_master."
+	if names := t.databaseNames(); len(names) > 0 {
+		desc += " Databases: " + strings.Join(names, ", ") + "."
+	}
+	return desc
+}
+
+func (t *SQLQueryTool) ParameterSchema() string {
+	s := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"description": "A single SELECT statement.",
+			},
+			"database": map[string]any{
+				"type":        "string",
+				"description": "Name of a configured SQLite database.",
+				"enum":        t.databaseNames(),
+			},
+			"csv": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "CSV/TSV file paths to load as tables (instead of database).",
+			},
+			"max_rows": map[string]any{
+				"type":        "integer",
+				"description": fmt.Sprintf("Max rows to return (default/max %d).", t.MaxRows),
+			},
+		},
+		"required": []string{"query"},
+	}
+	if len(t.Databases) == 0 {
+		delete(s["properties"].(map[string]any), "database")
+	}
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+func (t *SQLQueryTool) databaseNames() []string {
+	names := make([]string, 0, len(t.Databases))
+	for name := range t.Databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *SQLQueryTool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	query, _ := params["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return "", fmt.Errorf("missing required param: query")
+	}
+	query, err := checkReadOnlySQL(query)
+	if err != nil {
+		return "", err
+	}
+	maxRows := t.MaxRows
+	if v, ok := asInt64(params["max_rows"]); ok && v > 0 && int(v) < maxRows {
+		maxRows = int(v)
+	}
+	name, _ := params["database"].(string)
+	name = strings.TrimSpace(name)
+	csvPaths, ok := asStringSlice(params["csv"])
+	if !ok {
+		return "", fmt.Errorf("invalid param: csv must be an array of file paths")
+	}
+	if (name == "") == (len(csvPaths) == 0) {
+		return "", fmt.Errorf("pass exactly one of database or csv")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	var (
+		db     *sql.DB
+		header strings.Builder
+	)
+	if name != "" {
+		path, ok := t.Databases[name]
+		if !ok {
+			return "", fmt.Errorf("unknown database %q (configured: %s)", name, strings.Join(t.databaseNames(), ", "))
+		}
+		if db, err = openReadOnlySQLite(path); err != nil {
+			return "", err
+		}
+	} else {
+		if db, err = sql.Open("sqlite", "file::memory:"); err != nil {
+			return "", err
+		}
+		// Each connection to :memory: is a separate database.
+		db.SetMaxOpenConns(1)
+		for _, p := range csvPaths {
+			table, cols, err := t.loadCSV(ctx, db, p)
+			if err != nil {
+				db.Close()
+				return "", err
+			}
+			fmt.Fprintf(&header, "table %s(%s)\n", table, strings.Join(cols, ", "))
+		}
+		if _, err := db.ExecContext(ctx, "PRAGMA query_only = 1"); err != nil {
+			db.Close()
+			return "", err
+		}
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return "", t.queryError(ctx, err)
+	}
+	defer rows.Close()
+	table, err := t.formatRows(rows, maxRows)
+	if err != nil {
+		return "", t.queryError(ctx, err)
+	}
+	return header.String() + table, nil
+}
+
+func (t *SQLQueryTool) queryError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("query timed out after %s", t.Timeout)
+	}
+	return fmt.Errorf("query failed: %w", err)
+}
+
+// openReadOnlySQLite opens path read-only; query_only additionally rejects
+// writes that a read-only file handle would not catch (e.g. temp tables).
+func openReadOnlySQLite(path string) (*sql.DB, error) {
+	abs, err := filepath.Abs(expandHomePath(strings.TrimSpace(path)))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return nil, err
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	u.RawQuery = "mode=ro&_pragma=query_only(1)&_pragma=busy_timeout(2000)"
+	return sql.Open("sqlite", u.String())
+}
+
+var (
+	sqlLeadingKeywordRe = regexp.MustCompile(`^(?i)(select|with|values|explain)\b`)
+	sqlIdentRe          = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// checkReadOnlySQL strips comments and a trailing ";", and accepts exactly one
+// query statement. The database connection is read-only regardless; this
+// check exists to give a clear error.
+func checkReadOnlySQL(q string) (string, error) {
+	var b strings.Builder
+	inStr := byte(0)
+	ended := false
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		switch {
+		case ended && c != '-' && c != '/' && strings.IndexByte(" \t\r\n;", c) < 0:
+			return "", fmt.Errorf("only a single SQL statement is allowed")
+		case inStr != 0:
+			b.WriteByte(c)
+			if c == inStr {
+				inStr = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			inStr = c
+			b.WriteByte(c)
+		case c == '-' && i+1 < len(q) && q[i+1] == '-':
+			for i < len(q) && q[i] != '\n' {
+				i++
+			}
+			b.WriteByte(' ')
+		case c == '/' && i+1 < len(q) && q[i+1] == '*':
+			end := strings.Index(q[i+2:], "*/")
+			if end < 0 {
+				i = len(q)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == ';':
+			ended = true
+		case ended:
+		default:
+			b.WriteByte(c)
+		}
+	}
+	out := strings.TrimSpace(b.String())
+	if !sqlLeadingKeywordRe.MatchString(out) {
+		return "", fmt.Errorf("only read-only queries (SELECT, WITH, VALUES, EXPLAIN) are allowed")
+	}
+	return out, nil
+}
+
+// loadCSV creates a table named after the file and inserts every record,
+// typing columns INTEGER/REAL when all their non-empty values parse as such.
+func (t *SQLQueryTool) loadCSV(ctx context.Context, db *sql.DB, raw string) (string, []string, error) {
+	path, err := checkFilePathPolicy("sql_query", strings.TrimSpace(raw), t.Policy.DenyPaths, t.Policy.AllowedDirs)
+	if err != nil {
+		return "", nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err == nil && fi.Size() > t.MaxCSVBytes {
+		return "", nil, fmt.Errorf("%s is too large to load (%d bytes > %d)", raw, fi.Size(), t.MaxCSVBytes)
+	}
+	sample := make([]byte, 8192)
+	n, _ := io.ReadFull(f, sample)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	dec, _, err := docextract.NewDecodingReader(f, sample[:n], "")
+	if err != nil {
+		return "", nil, err
+	}
+	r := csv.NewReader(dec)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsv", ".tab":
+		r.Comma = '\t'
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", raw, err)
+	}
+	if len(records) == 0 {
+		return "", nil, fmt.Errorf("%s: empty file", raw)
+	}
+
+	table := sqlIdent(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), "t")
+	cols := csvColumns(records[0])
+	data := records[1:]
+	types := make([]string, len(cols))
+	for i := range cols {
+		types[i] = csvColumnType(data, i)
+	}
+	defs := make([]string, len(cols))
+	for i, c := range cols {
+		defs[i] = fmt.Sprintf("%q %s", c, types[i])
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %q (%s)", table, strings.Join(defs, ", "))); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", raw, err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %q VALUES (%s)", table, strings.TrimSuffix(strings.Repeat("?,", len(cols)), ",")))
+	if err != nil {
+		return "", nil, err
+	}
+	defer stmt.Close()
+	args := make([]any, len(cols))
+	for _, rec := range data {
+		for i := range cols {
+			args[i] = csvValue(rec, i, types[i])
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return "", nil, fmt.Errorf("%s: %w", raw, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", nil, err
+	}
+	for i := range cols {
+		cols[i] += " " + types[i]
+	}
+	return table, cols, nil
+}
+
+// sqlIdent turns s into a plain identifier ([A-Za-z0-9_], not starting with a digit).
+func sqlIdent(s, fallback string) string {
+	s = strings.Trim(sqlIdentRe.ReplaceAllString(strings.TrimSpace(s), "_"), "_")
+	if s == "" {
+		return fallback
+	}
+	if s[0] >= '0' && s[0] <= '9' {
+		s = "_" + s
+	}
+	return strings.ToLower(s)
+}
+
+func csvColumns(header []string) []string {
+	cols := make([]string, len(header))
+	seen := map[string]int{}
+	for i, h := range header {
+		c := sqlIdent(h, "col_"+strconv.Itoa(i+1))
+		if n := seen[c]; n > 0 {
+			seen[c]++
+			c = c + "_" + strconv.Itoa(n+1)
+		} else {
+			seen[c] = 1
+		}
+		cols[i] = c
+	}
+	return cols
+}
+
+func csvColumnType(rows [][]string, col int) string {
+	typ := "INTEGER"
+	seen := false
+	for _, rec := range rows {
+		if col >= len(rec) {
+			continue
+		}
+		v := strings.TrimSpace(rec[col])
+		if v == "" {
+			continue
+		}
+		seen = true
+		if typ == "INTEGER" {
+			if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+				continue
+			}
+			typ = "REAL"
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "TEXT"
+		}
+	}
+	if !seen {
+		return "TEXT"
+	}
+	return typ
+}
+
+func csvValue(rec []string, col int, typ string) any {
+	if col >= len(rec) {
+		return nil
+	}
+	v := strings.TrimSpace(rec[col])
+	switch typ {
+	case "INTEGER":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+		return nil
+	case "REAL":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+		return nil
+	}
+	return rec[col]
+}
+
+// formatRows renders up to maxRows rows as a Markdown table.
+func (t *SQLQueryTool) formatRows(rows *sql.Rows, maxRows int) (string, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(cols, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(cols)) + "\n")
+	vals := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	n := 0
+	truncated := false
+	for rows.Next() {
+		if n >= maxRows {
+			truncated = true
+			break
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", err
+		}
+		cells := make([]string, len(cols))
+		for i, v := range vals {
+			cells[i] = t.formatCell(v)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if truncated {
+		fmt.Fprintf(&b, "(%d rows shown; more rows available, add LIMIT/OFFSET or aggregate)\n", n)
+	} else {
+		fmt.Fprintf(&b, "(%d rows)\n", n)
+	}
+	return b.String(), nil
+}
+
+func (t *SQLQueryTool) formatCell(v any) string {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(x) {
+			return fmt.Sprintf("<blob %d bytes>", len(x))
+		}
+		s = string(x)
+	case float64:
+		s = strconv.FormatFloat(x, 'g', -1, 64)
+	case time.Time:
+		s = x.Format(time.RFC3339)
+	default:
+		s = fmt.Sprint(x)
+	}
+	s = strings.NewReplacer("\r\n", `\n`, "\n", `\n`, "|", `\|`).Replace(s)
+	if t.MaxCellChars > 0 && len(s) > t.MaxCellChars {
+		s = strutil.TruncateUTF8(s, t.MaxCellChars) + "..."
+	}
+	return s
+}
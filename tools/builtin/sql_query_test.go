@@ -0,0 +1,124 @@
+package builtin
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestSQLite(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "shop.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT, total REAL)",
+		"INSERT INTO orders (customer, total) VALUES ('ada', 12.5), ('bob', 3), ('ada', 7.25), ('cy|x', NULL)",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestSQLQueryTool_Database(t *testing.T) {
+	tool := NewSQLQueryTool(map[string]string{"shop": newTestSQLite(t)}, FSPolicy{}, 2, 5*time.Second, 0)
+	ctx := context.Background()
+
+	out, err := tool.Execute(ctx, map[string]any{
+		"database": "shop",
+		"query":    "SELECT customer, SUM(total) AS spent FROM orders GROUP BY customer ORDER BY customer; -- done",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "| customer | spent |\n| --- | --- |\n| ada | 19.75 |\n| bob | 3 |\n(2 rows shown; more rows available"
+	if !strings.HasPrefix(out, want) {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"database": "shop", "query": "select customer, total from orders where id = 4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `| cy\|x | NULL |`) || !strings.Contains(out, "(1 rows)") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	for _, q := range []string{
+		"DELETE FROM orders",
+		"SELECT 1; DROP TABLE orders",
+		"ATTACH DATABASE '/tmp/x.db' AS x",
+		"/* select */ UPDATE orders SET total = 0",
+	} {
+		if _, err := tool.Execute(ctx, map[string]any{"database": "shop", "query": q}); err == nil {
+			t.Fatalf("expected %q to be rejected", q)
+		}
+	}
+	// A CTE that passes the keyword check still cannot write.
+	if _, err := tool.Execute(ctx, map[string]any{"database": "shop", "query": "WITH x AS (SELECT 1) DELETE FROM orders"}); err == nil {
+		t.Fatal("expected write through WITH to fail")
+	}
+	if _, err := tool.Execute(ctx, map[string]any{"database": "other", "query": "SELECT 1"}); err == nil {
+		t.Fatal("expected unknown database error")
+	}
+}
+
+func TestSQLQueryTool_CSV(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "sales 2024.csv"), "Region,Units,Price,Note\nnorth,3,1.5,a\nsouth,5,2,\"b, c\"\nnorth,,4,\n")
+	writeFile(t, filepath.Join(dir, "regions.tsv"), "region\tmanager\nnorth\tAda\nsouth\tBob\n")
+	writeFile(t, filepath.Join(dir, "secret.csv"), "a\n1\n")
+	tool := NewSQLQueryTool(nil, FSPolicy{DenyPaths: []string{"secret.csv"}, AllowedDirs: []string{dir}}, 0, 5*time.Second, 0)
+	ctx := context.Background()
+
+	out, err := tool.Execute(ctx, map[string]any{
+		"csv": []any{filepath.Join(dir, "sales 2024.csv"), filepath.Join(dir, "regions.tsv")},
+		"query": `SELECT r.manager, SUM(s.units) AS units, SUM(s.units * s.price) AS revenue
+			FROM sales_2024 s JOIN regions r ON r.region = s.region
+			GROUP BY r.manager ORDER BY r.manager`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"table sales_2024(region TEXT, units INTEGER, price REAL, note TEXT)",
+		"table regions(region TEXT, manager TEXT)",
+		"| Ada | 3 | 4.5 |",
+		"| Bob | 5 | 10 |",
+		"(2 rows)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	if _, err := tool.Execute(ctx, map[string]any{"csv": []any{filepath.Join(dir, "secret.csv")}, "query": "SELECT * FROM secret"}); err == nil {
+		t.Fatal("expected deny_paths to block csv")
+	}
+	if _, err := tool.Execute(ctx, map[string]any{"csv": []any{filepath.Join(dir, "regions.tsv")}, "query": "WITH x AS (SELECT 1) INSERT INTO regions VALUES ('e', 'f')"}); err == nil {
+		t.Fatal("expected in-memory database to be query-only")
+	}
+}
+
+func TestCheckReadOnlySQL(t *testing.T) {
+	ok := []string{"select 1", "  -- hi\nSELECT ';' ;", "with a as (select 1) select * from a", "EXPLAIN QUERY PLAN SELECT 1", "select 1; /* trailing */"}
+	for _, q := range ok {
+		if _, err := checkReadOnlySQL(q); err != nil {
+			t.Errorf("%q: %v", q, err)
+		}
+	}
+	bad := []string{"", "pragma writable_schema=1", "select 1; select 2", "insert into t values (1)", "selectx"}
+	for _, q := range bad {
+		if _, err := checkReadOnlySQL(q); err == nil {
+			t.Errorf("%q: expected error", q)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
@@ -14,8 +15,11 @@ import (
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/quailyquaily/mistermorph/guard"
+	"github.com/quailyquaily/mistermorph/internal/docextract"
+	"github.com/quailyquaily/mistermorph/internal/strutil"
 	"github.com/quailyquaily/mistermorph/secrets"
 )
 
@@ -26,6 +30,44 @@ type URLFetchAuth struct {
 	Resolver      secrets.Resolver
 }
 
+// resolveToolAuthHeader returns the header that carries the credential of
+// profileID for toolName's request (web_search, send_webhook), after the same
+// allowlist checks url_fetch uses.
+func resolveToolAuthHeader(ctx context.Context, auth *URLFetchAuth, toolName, profileID string, req *http.Request) (string, string, error) {
+	if auth == nil || !auth.Enabled {
+		return "", "", fmt.Errorf("%s auth_profile requires secrets.enabled=true", toolName)
+	}
+	if auth.AllowProfiles == nil || !auth.AllowProfiles[profileID] {
+		return "", "", fmt.Errorf("auth_profile %q is not allowed (fail-closed)", profileID)
+	}
+	if auth.Profiles == nil || auth.Resolver == nil {
+		return "", "", fmt.Errorf("auth_profile is enabled but profile store or resolver is not configured")
+	}
+	p, ok := auth.Profiles.Get(profileID)
+	if !ok {
+		return "", "", fmt.Errorf("auth_profile not found: %q", profileID)
+	}
+	if err := p.Validate(); err != nil {
+		return "", "", fmt.Errorf("invalid auth_profile %q: %w", profileID, err)
+	}
+	if err := p.IsURLAllowed(req.URL, req.Method); err != nil {
+		return "", "", err
+	}
+	binding, ok := p.Bindings[toolName]
+	if !ok {
+		return "", "", fmt.Errorf("auth_profile %q has no binding for tool %q", profileID, toolName)
+	}
+	sec, err := auth.Resolver.Resolve(ctx, p.Credential.SecretRef)
+	if err != nil {
+		return "", "", err
+	}
+	value, err := formatInjectedSecret(binding.Inject.Format, sec)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(binding.Inject.Name), value, nil
+}
+
 type URLFetchTool struct {
 	Enabled        bool
 	Timeout        time.Duration
@@ -35,8 +77,20 @@ type URLFetchTool struct {
 	AllowScheme    map[string]bool
 	Auth         *URLFetchAuth
 	FileCacheDir string
+	// DefaultFormat is how HTML responses are returned when the call has no
+	// format param: "markdown" (default), "text" or "raw".
+	DefaultFormat string
+	// PageBytes caps the body returned per call; use offset to read further.
+	PageBytes int64
+	// MaxDownloadBytes caps bodies streamed to disk (download_path or binary
+	// responses); 0 falls back to MaxBytes.
+	MaxDownloadBytes int64
+	// DownloadTimeout replaces Timeout for calls with download_path.
+	DownloadTimeout time.Duration
 }
 
+const defaultURLFetchPageBytes = 64 * 1024
+
 func NewURLFetchTool(enabled bool, timeout time.Duration, maxBytes int64, userAgent string, fileCacheDir string) *URLFetchTool {
 	return NewURLFetchToolWithAuth(enabled, timeout, maxBytes, userAgent, fileCacheDir, nil)
 }
@@ -72,7 +126,7 @@ func NewURLFetchToolWithAuth(enabled bool, timeout time.Duration, maxBytes int64
 func (t *URLFetchTool) Name() string { return "url_fetch" }
 
 func (t *URLFetchTool) Description() string {
-	return "Fetches an HTTP(S) URL (GET/POST/PUT/PATCH/DELETE) and returns the response body (truncated)."
+	return "Fetches an HTTP(S) URL (GET/POST/PUT/PATCH/DELETE) and returns the response body. HTML pages are reduced to their main content as Markdown by default (format=text for plain text, format=raw for the original HTML). Long GET bodies are returned in pages; pass the reported next_offset as offset to continue."
 }
 
 func (t *URLFetchTool) ParameterSchema() string {
@@ -105,7 +159,15 @@ func (t *URLFetchTool) ParameterSchema() string {
 			},
 			"download_path": map[string]any{
 				"type":        "string",
-				"description": "Optional: if set, saves the raw response body to this path (under file_cache_dir) and returns JSON metadata instead of including the body in the output. Recommended for PDFs/binary.",
+				"description": "Optional: if set, streams the raw response body to this path (under file_cache_dir) and returns JSON metadata (content_type, bytes, sha256) instead of the body. Recommended for PDFs/binary and large files; binary responses without download_path are saved under downloads/ automatically.",
+			},
+			"resume": map[string]any{
+				"type":        "boolean",
+				"description": "With download_path: continue an interrupted download of the same URL using an HTTP range request (GET only).",
+			},
+			"checksum": map[string]any{
+				"type":        "string",
+				"description": "With download_path: expected digest as <algo>:<hex> (sha256, sha512, sha1, md5). The file is discarded on mismatch.",
 			},
 			"download_mkdirs": map[string]any{
 				"type":        "boolean",
@@ -117,7 +179,16 @@ func (t *URLFetchTool) ParameterSchema() string {
 			},
 			"max_bytes": map[string]any{
 				"type":        "integer",
-				"description": "Optional max response bytes to read (truncates beyond this).",
+				"description": "Optional max response bytes to read (truncates beyond this). With download_path, the download size limit (capped by tools.url_fetch.max_download_bytes).",
+			},
+			"format": map[string]any{
+				"type":        "string",
+				"description": "How to return HTML responses: markdown (main content, links kept; default), text (main content, plain) or raw (original HTML). Non-HTML bodies are always returned as-is.",
+				"enum":        []string{"markdown", "text", "raw"},
+			},
+			"offset": map[string]any{
+				"type":        "integer",
+				"description": "Byte offset into the returned (converted) body, for reading long pages in chunks. Use next_offset from the previous call. GET only: each page fetches the URL again.",
 			},
 		},
 		"required": []string{"url"},
@@ -149,21 +220,8 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 	authProfileID = strings.TrimSpace(authProfileID)
 
 	netPol, hasNetPol := guard.NetworkPolicyFromContext(ctx)
-	if hasNetPol {
-		if len(netPol.AllowedURLPrefixes) == 0 {
-			return "", fmt.Errorf("url_fetch is blocked by guard (no allowed_url_prefixes configured)")
-		}
-		if !guard.URLAllowedByPrefixes(u.String(), netPol.AllowedURLPrefixes) {
-			return "", fmt.Errorf("url is not allowed by guard")
-		}
-		if err := netPol.CheckHost(u.Hostname()); err != nil {
-			return "", fmt.Errorf("host blocked by guard: %w", err)
-		}
-	} else {
-		// Fallback SSRF protection when Guard is not enabled / no policy in context.
-		if err := guard.ResolveAndCheckHost(u.Hostname(), true, nil); err != nil {
-			return "", err
-		}
+	if err := checkFetchURL(ctx, t.Name(), u); err != nil {
+		return "", err
 	}
 
 	downloadPath, _ := params["download_path"].(string)
@@ -191,6 +249,9 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 	}
 
 	timeout := t.Timeout
+	if downloadPath != "" && t.DownloadTimeout > 0 {
+		timeout = t.DownloadTimeout
+	}
 	if v, ok := params["timeout_seconds"]; ok {
 		if secs, ok := asFloat64(v); ok && secs > 0 {
 			timeout = time.Duration(secs * float64(time.Second))
@@ -198,12 +259,75 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 	}
 
 	maxBytes := t.MaxBytes
+	downloadLimit := t.MaxDownloadBytes
+	if downloadLimit <= 0 {
+		downloadLimit = t.MaxBytes
+	}
 	if v, ok := params["max_bytes"]; ok {
 		if n, ok := asInt64(v); ok && n > 0 {
 			maxBytes = n
+			if t.MaxDownloadBytes <= 0 || n < t.MaxDownloadBytes {
+				downloadLimit = n
+			}
 		}
 	}
 
+	resume, _ := params["resume"].(bool)
+	checksum, _ := params["checksum"].(string)
+	var download *urlFetchDownload
+	if downloadPath != "" {
+		algo, sum, err := parseChecksum(checksum)
+		if err != nil {
+			return "", err
+		}
+		if resume && method != http.MethodGet {
+			return "", fmt.Errorf("resume is only supported for GET")
+		}
+		_, resolvedPath, err := resolveWritePath(t.FileCacheDir, downloadPath)
+		if err != nil {
+			return "", err
+		}
+		if downloadMkdirs {
+			dir := filepath.Dir(resolvedPath)
+			if dir != "" && dir != "." {
+				if err := os.MkdirAll(dir, 0o700); err != nil {
+					return "", err
+				}
+			}
+		}
+		download = &urlFetchDownload{path: downloadPath, absPath: resolvedPath, limit: downloadLimit, resume: resume, algo: algo, sum: sum}
+	} else if resume || strings.TrimSpace(checksum) != "" {
+		return "", fmt.Errorf("resume and checksum require download_path")
+	}
+
+	format := strings.ToLower(strings.TrimSpace(t.DefaultFormat))
+	if v, ok := params["format"].(string); ok && strings.TrimSpace(v) != "" {
+		format = strings.ToLower(strings.TrimSpace(v))
+	}
+	switch format {
+	case "":
+		format = "markdown"
+	case "markdown", "text", "raw":
+	default:
+		return "", fmt.Errorf("invalid param: format must be markdown, text or raw")
+	}
+	var offset int64
+	if v, ok := asInt64(params["offset"]); ok {
+		if v < 0 {
+			return "", fmt.Errorf("invalid param: offset must be >= 0")
+		}
+		offset = v
+	}
+	// Each page is a new request; repeating a POST/PUT/PATCH/DELETE would
+	// repeat its side effects.
+	if offset > 0 && method != http.MethodGet {
+		return "", fmt.Errorf("offset is only supported for GET (use download_path to save the full %s response)", method)
+	}
+	pageBytes := t.PageBytes
+	if pageBytes <= 0 {
+		pageBytes = defaultURLFetchPageBytes
+	}
+
 	reqCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
@@ -352,6 +476,12 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 	if authProfileID != "" {
 		req.Header.Set(injectHeaderName, injectHeaderVal)
 	}
+	if download != nil && download.resume {
+		if req.Header.Get("Range") != "" {
+			return "", fmt.Errorf("header \"Range\" cannot be combined with resume")
+		}
+		download.prepareResume(req)
+	}
 
 	var client http.Client
 	if t.HTTPClient != nil {
@@ -378,15 +508,7 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 			return nil
 		}
 		if !profile.Allow.AllowProxy {
-			if client.Transport == nil {
-				tr := cloneDefaultTransport()
-				tr.Proxy = nil
-				client.Transport = tr
-			} else if tr, ok := client.Transport.(*http.Transport); ok && tr != nil {
-				cp := tr.Clone()
-				cp.Proxy = nil
-				client.Transport = cp
-			}
+			disableProxy(&client)
 		}
 	}
 	if authProfileID == "" && hasNetPol {
@@ -411,15 +533,7 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 			return nil
 		}
 		if !netPol.AllowProxy {
-			if client.Transport == nil {
-				tr := cloneDefaultTransport()
-				tr.Proxy = nil
-				client.Transport = tr
-			} else if tr, ok := client.Transport.(*http.Transport); ok && tr != nil {
-				cp := tr.Clone()
-				cp.Proxy = nil
-				client.Transport = cp
-			}
+			disableProxy(&client)
 		}
 	}
 
@@ -429,9 +543,20 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 	}
 	defer resp.Body.Close()
 
+	ct := resp.Header.Get("Content-Type")
+	respBody, peek := peekBody(resp.Body, 512)
+	if download == nil && resp.StatusCode >= 200 && resp.StatusCode < 300 && isBinaryResponse(ct, peek) {
+		// Binary bodies are useless inline; stream them to file_cache_dir instead.
+		if download, err = t.autoDownload(resp, u, downloadLimit); err != nil {
+			return "", err
+		}
+	}
+	if download != nil {
+		return t.finishDownload(resp, respBody, u, method, download)
+	}
+
 	var truncated bool
-	limitReader := io.LimitReader(resp.Body, maxBytes+1)
-	body, err := io.ReadAll(limitReader)
+	body, err := io.ReadAll(io.LimitReader(respBody, maxBytes+1))
 	if err != nil {
 		return "", err
 	}
@@ -440,49 +565,39 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 		truncated = true
 	}
 
-	ct := resp.Header.Get("Content-Type")
-
-	if downloadPath != "" {
-		if truncated {
-			return "", fmt.Errorf("download truncated (max_bytes=%d); increase tools.url_fetch.max_bytes or pass a larger max_bytes", maxBytes)
-		}
-		_, resolvedPath, err := resolveWritePath(t.FileCacheDir, downloadPath)
+	var title string
+	var bodyStr string
+	if format != "raw" && isHTMLResponse(ct, body) {
+		page, _, err := docextract.DecodeText(body, contentTypeCharset(ct))
 		if err != nil {
-			return "", err
-		}
-		if downloadMkdirs {
-			dir := filepath.Dir(resolvedPath)
-			if dir != "" && dir != "." {
-				if err := os.MkdirAll(dir, 0o700); err != nil {
-					return "", err
-				}
+			page = string(bytes.ToValidUTF8(body, []byte("\uFFFD")))
+		}
+		if format == "text" {
+			title, bodyStr = docextract.ReadableText(page)
+		} else {
+			base := u
+			if resp.Request != nil && resp.Request.URL != nil {
+				base = resp.Request.URL // after redirects
 			}
+			title, bodyStr = docextract.ReadableMarkdown(page, base)
 		}
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			if err := os.WriteFile(resolvedPath, body, 0o644); err != nil {
-				return "", err
-			}
-		}
-
-		out, _ := json.MarshalIndent(map[string]any{
-			"url":          sanitizeOutputURL(u.String()),
-			"method":       method,
-			"status":       resp.StatusCode,
-			"content_type": ct,
-			"bytes":        len(body),
-			"path":         downloadPath,
-			"abs_path":     resolvedPath,
-		}, "", "  ")
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return string(out), fmt.Errorf("non-2xx status: %d", resp.StatusCode)
-		}
-		return string(out), nil
+	} else {
+		format = "raw"
+		bodyStr = string(bytes.ToValidUTF8(body, []byte("\n[non-utf8 body]\n")))
 	}
-
-	bodyStr := string(bytes.ToValidUTF8(body, []byte("\n[non-utf8 body]\n")))
 	bodyStr = redactResponseBody(bodyStr)
 
+	total := len(bodyStr)
+	if offset > int64(total) {
+		offset = int64(total)
+	}
+	start := int(offset)
+	for start < total && !utf8.RuneStart(bodyStr[start]) {
+		start++
+	}
+	chunk := strutil.TruncateUTF8(bodyStr[start:], int(pageBytes))
+	nextOffset := start + len(chunk)
+
 	var b strings.Builder
 	fmt.Fprintf(&b, "url: %s\n", sanitizeOutputURL(u.String()))
 	fmt.Fprintf(&b, "method: %s\n", method)
@@ -491,8 +606,21 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 		fmt.Fprintf(&b, "content_type: %s\n", ct)
 	}
 	fmt.Fprintf(&b, "truncated: %t\n", truncated)
+	if format != "raw" {
+		fmt.Fprintf(&b, "format: %s\n", format)
+		if title != "" {
+			fmt.Fprintf(&b, "title: %s\n", title)
+		}
+	}
+	if start > 0 || nextOffset < total {
+		fmt.Fprintf(&b, "offset: %d\n", start)
+		fmt.Fprintf(&b, "total_bytes: %d\n", total)
+		if nextOffset < total {
+			fmt.Fprintf(&b, "next_offset: %d\n", nextOffset)
+		}
+	}
 	b.WriteString("body:\n")
-	b.WriteString(bodyStr)
+	b.WriteString(chunk)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return b.String(), fmt.Errorf("non-2xx status: %d", resp.StatusCode)
@@ -500,6 +628,27 @@ func (t *URLFetchTool) Execute(ctx context.Context, params map[string]any) (stri
 	return b.String(), nil
 }
 
+// isHTMLResponse reports an HTML body by Content-Type, or by sniffing when the
+// server sent a generic type.
+func isHTMLResponse(contentType string, body []byte) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch mt {
+	case "text/html", "application/xhtml+xml":
+		return true
+	case "", "text/plain", "application/octet-stream":
+		return docextract.Detect("", body[:min(len(body), 1024)]) == docextract.FormatHTML
+	}
+	return false
+}
+
+func contentTypeCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
 func formatInjectedSecret(format string, secret string) (string, error) {
 	secret = strings.TrimSpace(secret)
 	if secret == "" {
@@ -535,6 +684,39 @@ func canonicalOrigin(u *url.URL) string {
 	return scheme + "://" + host + ":" + port
 }
 
+// checkFetchURL applies the guard network policy from ctx to u (allowlisted
+// prefixes and private hosts), or the fallback SSRF check when guard is off.
+func checkFetchURL(ctx context.Context, toolName string, u *url.URL) error {
+	netPol, ok := guard.NetworkPolicyFromContext(ctx)
+	if !ok {
+		// Fallback SSRF protection when Guard is not enabled / no policy in context.
+		return guard.ResolveAndCheckHost(u.Hostname(), true, nil)
+	}
+	if len(netPol.AllowedURLPrefixes) == 0 {
+		return fmt.Errorf("%s is blocked by guard (no allowed_url_prefixes configured)", toolName)
+	}
+	if !guard.URLAllowedByPrefixes(u.String(), netPol.AllowedURLPrefixes) {
+		return fmt.Errorf("url is not allowed by guard")
+	}
+	if err := netPol.CheckHost(u.Hostname()); err != nil {
+		return fmt.Errorf("host blocked by guard: %w", err)
+	}
+	return nil
+}
+
+// disableProxy makes client connect directly, ignoring proxy env vars.
+func disableProxy(client *http.Client) {
+	if client.Transport == nil {
+		tr := cloneDefaultTransport()
+		tr.Proxy = nil
+		client.Transport = tr
+	} else if tr, ok := client.Transport.(*http.Transport); ok && tr != nil {
+		cp := tr.Clone()
+		cp.Proxy = nil
+		client.Transport = cp
+	}
+}
+
 func cloneDefaultTransport() *http.Transport {
 	if dt, ok := http.DefaultTransport.(*http.Transport); ok && dt != nil {
 		return dt.Clone()
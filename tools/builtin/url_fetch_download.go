@@ -0,0 +1,383 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// urlFetchDownload describes where a url_fetch response body is streamed.
+// The body goes to <abs>.part first and is renamed into place only when it is
+// complete (and matches the checksum), so a failed transfer never leaves a
+// truncated file under the final name.
+type urlFetchDownload struct {
+	path    string // as reported back (relative to file_cache_dir)
+	absPath string
+	limit   int64
+	resume  bool
+	offset  int64 // bytes already in the .part file when resuming
+	algo    string
+	sum     string // expected checksum (hex), empty when not verified
+	auto    bool   // saved because the response was binary, not because download_path was set
+}
+
+// urlFetchPartMeta is stored next to a .part file so a resumed request only
+// continues a transfer of the same URL, and only if the server still serves
+// the same representation (If-Range).
+type urlFetchPartMeta struct {
+	URLSHA256    string `json:"url_sha256"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func (d *urlFetchDownload) partPath() string { return d.absPath + ".part" }
+func (d *urlFetchDownload) metaPath() string { return d.absPath + ".part.json" }
+
+func (d *urlFetchDownload) removePart() {
+	_ = os.Remove(d.partPath())
+	_ = os.Remove(d.metaPath())
+}
+
+// parseChecksum accepts "<algo>:<hex>" (sha256, sha512, sha1, md5) or bare
+// hex, whose algorithm is inferred from its length.
+func parseChecksum(raw string) (string, string, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		return "", "", nil
+	}
+	algo, sum, ok := strings.Cut(raw, ":")
+	if !ok {
+		sum = raw
+		switch len(sum) {
+		case 32:
+			algo = "md5"
+		case 40:
+			algo = "sha1"
+		case 64:
+			algo = "sha256"
+		case 128:
+			algo = "sha512"
+		default:
+			return "", "", fmt.Errorf("invalid param: checksum must be <algo>:<hex> (sha256, sha512, sha1, md5)")
+		}
+	}
+	algo = strings.ReplaceAll(algo, "-", "")
+	h := newChecksumHash(algo)
+	if h == nil {
+		return "", "", fmt.Errorf("unsupported checksum algorithm: %s (use sha256, sha512, sha1 or md5)", algo)
+	}
+	if b, err := hex.DecodeString(sum); err != nil || len(b) != h.Size() {
+		return "", "", fmt.Errorf("invalid param: checksum is not a %s hex digest", algo)
+	}
+	return algo, sum, nil
+}
+
+func newChecksumHash(algo string) hash.Hash {
+	switch algo {
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	case "sha1":
+		return sha1.New()
+	case "md5":
+		return md5.New()
+	}
+	return nil
+}
+
+func urlSHA256(u *url.URL) string {
+	sum := sha256.Sum256([]byte(u.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// prepareResume sets Range (and If-Range) on req when a .part file from an
+// earlier transfer of the same URL exists. Otherwise the download starts over.
+func (d *urlFetchDownload) prepareResume(req *http.Request) {
+	fi, err := os.Stat(d.partPath())
+	if err != nil || fi.Size() == 0 || fi.Size() > d.limit {
+		return
+	}
+	b, err := os.ReadFile(d.metaPath())
+	if err != nil {
+		return
+	}
+	var meta urlFetchPartMeta
+	if json.Unmarshal(b, &meta) != nil || meta.URLSHA256 != urlSHA256(req.URL) {
+		return
+	}
+	d.offset = fi.Size()
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+	switch {
+	case meta.ETag != "" && !strings.HasPrefix(meta.ETag, "W/"):
+		req.Header.Set("If-Range", meta.ETag)
+	case meta.LastModified != "":
+		req.Header.Set("If-Range", meta.LastModified)
+	}
+}
+
+var contentRangeRe = regexp.MustCompile(`^bytes\s+(?:(\d+)-\d+|\*)/(\d+|\*)$`)
+
+// parseContentRange returns the first byte and the complete length from a
+// Content-Range header; either is -1 when absent.
+func parseContentRange(v string) (int64, int64) {
+	m := contentRangeRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return -1, -1
+	}
+	start, total := int64(-1), int64(-1)
+	if m[1] != "" {
+		start, _ = strconv.ParseInt(m[1], 10, 64)
+	}
+	if m[2] != "*" {
+		total, _ = strconv.ParseInt(m[2], 10, 64)
+	}
+	return start, total
+}
+
+// finishDownload streams resp.Body into d and returns the JSON observation.
+func (t *URLFetchTool) finishDownload(resp *http.Response, body io.Reader, u *url.URL, method string, d *urlFetchDownload) (string, error) {
+	ct := resp.Header.Get("Content-Type")
+	info := map[string]any{
+		"url":          sanitizeOutputURL(u.String()),
+		"method":       method,
+		"status":       resp.StatusCode,
+		"content_type": ct,
+		"bytes":        0,
+		"path":         d.path,
+		"abs_path":     d.absPath,
+	}
+	if d.auto {
+		info["note"] = "binary response saved to file_cache_dir instead of being returned inline"
+	}
+	render := func() string {
+		out, _ := json.MarshalIndent(info, "", "  ")
+		return string(out)
+	}
+
+	complete := false
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && d.offset > 0:
+		if start, _ := parseContentRange(resp.Header.Get("Content-Range")); start != d.offset {
+			d.removePart()
+			return render(), fmt.Errorf("server returned an unexpected range (%q); partial download discarded, retry to start over", resp.Header.Get("Content-Range"))
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && d.offset > 0:
+		if _, total := parseContentRange(resp.Header.Get("Content-Range")); total != d.offset {
+			d.removePart()
+			return render(), fmt.Errorf("server rejected the resume range; partial download discarded, retry to start over")
+		}
+		complete = true // the .part file already holds the whole body
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// A full response (e.g. the server ignored Range or If-Range failed): start over.
+		d.offset = 0
+	default:
+		return render(), fmt.Errorf("non-2xx status: %d", resp.StatusCode)
+	}
+
+	if !complete && resp.ContentLength >= 0 && d.offset+resp.ContentLength > d.limit {
+		return render(), fmt.Errorf("download too large (%d bytes > max %d); raise tools.url_fetch.max_download_bytes", d.offset+resp.ContentLength, d.limit)
+	}
+
+	flags := os.O_RDWR | os.O_CREATE
+	if d.offset == 0 {
+		flags |= os.O_TRUNC
+		meta := urlFetchPartMeta{
+			URLSHA256:    urlSHA256(u),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		b, _ := json.Marshal(meta)
+		if err := os.WriteFile(d.metaPath(), b, 0o600); err != nil {
+			return "", err
+		}
+	}
+	f, err := os.OpenFile(d.partPath(), flags, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	sha := sha256.New()
+	var verify hash.Hash
+	hashes := []io.Writer{sha}
+	if d.algo != "" && d.algo != "sha256" {
+		verify = newChecksumHash(d.algo)
+		hashes = append(hashes, verify)
+	}
+	hw := io.MultiWriter(hashes...)
+	// Hash what an earlier attempt already wrote; this also leaves f positioned at the end.
+	if _, err := io.Copy(hw, f); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	var n int64
+	if !complete {
+		n, err = io.Copy(io.MultiWriter(f, hw), io.LimitReader(body, d.limit-d.offset+1))
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	total := d.offset + n
+	info["bytes"] = total
+	if total > d.limit {
+		d.removePart()
+		info["bytes"] = d.limit
+		return render(), fmt.Errorf("download truncated (max %d bytes); raise tools.url_fetch.max_download_bytes or pass a larger max_bytes", d.limit)
+	}
+	if err != nil {
+		return render(), fmt.Errorf("download interrupted after %d bytes: %w (retry with resume=true to continue)", total, err)
+	}
+
+	shaHex := hex.EncodeToString(sha.Sum(nil))
+	info["sha256"] = shaHex
+	if d.offset > 0 {
+		info["resumed_from"] = d.offset
+	}
+	if d.algo != "" {
+		got := shaHex
+		if verify != nil {
+			got = hex.EncodeToString(verify.Sum(nil))
+		}
+		if got != d.sum {
+			d.removePart()
+			return render(), fmt.Errorf("checksum mismatch: %s is %s, expected %s; download discarded", d.algo, got, d.sum)
+		}
+		info["checksum_verified"] = d.algo
+	}
+	if err := os.Rename(d.partPath(), d.absPath); err != nil {
+		return "", err
+	}
+	_ = os.Remove(d.metaPath())
+	return render(), nil
+}
+
+// binaryMediaTypes are saved to a file rather than returned inline.
+var binaryMediaPrefixes = []string{"image/", "audio/", "video/", "font/"}
+
+var binaryMediaTypes = map[string]bool{
+	"application/pdf":              true,
+	"application/zip":              true,
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/x-tar":            true,
+	"application/x-bzip2":          true,
+	"application/x-xz":             true,
+	"application/x-7z-compressed":  true,
+	"application/vnd.rar":          true,
+	"application/x-rar-compressed": true,
+	"application/msword":           true,
+	"application/vnd.ms-excel":     true,
+	"application/x-sqlite3":        true,
+	"application/vnd.sqlite3":      true,
+	"application/wasm":             true,
+	"application/x-executable":     true,
+}
+
+// isBinaryResponse reports whether a response should be routed to disk: a
+// binary media type, or a generic type whose first bytes are not text.
+func isBinaryResponse(contentType string, peek []byte) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	mt = strings.ToLower(mt)
+	if strings.HasSuffix(mt, "+xml") || strings.HasSuffix(mt, "+json") {
+		return false
+	}
+	if binaryMediaTypes[mt] || strings.HasPrefix(mt, "application/vnd.openxmlformats-officedocument.") || strings.HasPrefix(mt, "application/vnd.oasis.opendocument.") {
+		return true
+	}
+	for _, p := range binaryMediaPrefixes {
+		if strings.HasPrefix(mt, p) {
+			return true
+		}
+	}
+	if mt != "" && mt != "application/octet-stream" {
+		return false
+	}
+	if len(peek) == 0 {
+		return false
+	}
+	if bytes.IndexByte(peek, 0) >= 0 {
+		return true
+	}
+	// Allow a multi-byte rune cut off at the end of the peeked prefix.
+	for i := 0; i < utf8.UTFMax && len(peek) > 0; i++ {
+		if utf8.Valid(peek) {
+			return false
+		}
+		peek = peek[:len(peek)-1]
+	}
+	return true
+}
+
+var downloadNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// autoDownload picks a new file under file_cache_dir/downloads for a binary
+// response, named after Content-Disposition or the URL path.
+func (t *URLFetchTool) autoDownload(resp *http.Response, u *url.URL, limit int64) (*urlFetchDownload, error) {
+	name := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = filepath.Base(params["filename"])
+	}
+	if name == "" || name == "." || name == "/" {
+		name = path.Base(u.Path)
+	}
+	name = strings.Trim(downloadNameRe.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		name = "download"
+	}
+	if path.Ext(name) == "" {
+		mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		if exts, _ := mime.ExtensionsByType(mt); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 0; i < 1000; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		rel := path.Join("downloads", candidate)
+		_, abs, err := resolveWritePath(t.FileCacheDir, rel)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(abs); errors.Is(err, os.ErrNotExist) {
+			if _, err := os.Lstat(abs + ".part"); !errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(abs), 0o700); err != nil {
+				return nil, err
+			}
+			return &urlFetchDownload{path: rel, absPath: abs, limit: limit, auto: true}, nil
+		}
+	}
+	return nil, fmt.Errorf("could not pick a download file name for %q", name)
+}
+
+// peekBody returns a reader equivalent to body plus up to n of its first bytes.
+func peekBody(body io.Reader, n int) (io.Reader, []byte) {
+	br := bufio.NewReaderSize(body, n)
+	peek, _ := br.Peek(n)
+	return br, peek
+}
@@ -0,0 +1,190 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingReader returns data and then a transport error, like a dropped connection.
+type failingReader struct{ r io.Reader }
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestURLFetchTool_DownloadResumeAndChecksum(t *testing.T) {
+	cacheDir := t.TempDir()
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 64)
+	sum := sha256.Sum256(payload)
+	var ranges []string
+
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		ranges = append(ranges, r.Header.Get("Range")+"|"+r.Header.Get("If-Range"))
+		h := make(http.Header)
+		h.Set("Content-Type", "application/octet-stream")
+		h.Set("ETag", `"v1"`)
+		if len(ranges) == 1 {
+			return &http.Response{StatusCode: 200, Header: h, ContentLength: -1, Body: io.NopCloser(&failingReader{bytes.NewReader(payload[:300])}), Request: r}, nil
+		}
+		var start int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(payload)-1, len(payload)))
+		return &http.Response{StatusCode: 206, Header: h, ContentLength: int64(len(payload) - start), Body: io.NopCloser(bytes.NewReader(payload[start:])), Request: r}, nil
+	})
+	tool := NewURLFetchTool(true, 2*time.Second, 16, "test-agent", cacheDir)
+	tool.MaxDownloadBytes = 1 << 20
+	tool.HTTPClient = &http.Client{Transport: rt}
+	params := map[string]any{
+		"url":           "https://example.test/data.bin",
+		"download_path": "data.bin",
+		"resume":        true,
+		"checksum":      "sha256:" + hex.EncodeToString(sum[:]),
+	}
+
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "resume=true") {
+		t.Fatalf("expected interrupted download error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "data.bin")); err == nil {
+		t.Fatalf("partial download must not be saved under the final name")
+	}
+
+	out, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("resume: %v (out=%s)", err, out)
+	}
+	if ranges[1] != `bytes=300-|"v1"` {
+		t.Fatalf("unexpected resume request headers: %q", ranges[1])
+	}
+	var info map[string]any
+	if err := json.Unmarshal([]byte(out), &info); err != nil {
+		t.Fatalf("expected JSON output, got %q", out)
+	}
+	if info["bytes"] != float64(len(payload)) || info["resumed_from"] != float64(300) || info["checksum_verified"] != "sha256" || info["content_type"] != "application/octet-stream" {
+		t.Fatalf("unexpected metadata: %s", out)
+	}
+	got, _ := os.ReadFile(filepath.Join(cacheDir, "data.bin"))
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("resumed file differs from payload")
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "data.bin.part")); err == nil {
+		t.Fatalf("expected .part file to be renamed")
+	}
+}
+
+func TestURLFetchTool_DownloadChecksumMismatch(t *testing.T) {
+	cacheDir := t.TempDir()
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("hello")), Request: r}, nil
+	})
+	tool := NewURLFetchTool(true, 2*time.Second, 1024, "test-agent", cacheDir)
+	tool.HTTPClient = &http.Client{Transport: rt}
+
+	_, err := tool.Execute(context.Background(), map[string]any{
+		"url":           "https://example.test/hello.txt",
+		"download_path": "hello.txt",
+		"checksum":      "md5:00000000000000000000000000000000",
+	})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	entries, _ := os.ReadDir(cacheDir)
+	if len(entries) != 0 {
+		t.Fatalf("expected no files left behind, got %v", entries)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"url": "https://example.test/x", "checksum": "sha256:00"}); err == nil {
+		t.Fatalf("expected checksum without download_path to fail")
+	}
+}
+
+func TestURLFetchTool_DownloadLimit(t *testing.T) {
+	cacheDir := t.TempDir()
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Header: make(http.Header), ContentLength: 4096, Body: io.NopCloser(bytes.NewReader(make([]byte, 4096))), Request: r}, nil
+	})
+	tool := NewURLFetchTool(true, 2*time.Second, 16, "test-agent", cacheDir)
+	tool.MaxDownloadBytes = 1024
+	tool.HTTPClient = &http.Client{Transport: rt}
+
+	// max_bytes can lower the download limit but not raise it above max_download_bytes.
+	_, err := tool.Execute(context.Background(), map[string]any{
+		"url":           "https://example.test/big.bin",
+		"download_path": "big.bin",
+		"max_bytes":     1 << 30,
+	})
+	if err == nil || !strings.Contains(err.Error(), "max 1024") {
+		t.Fatalf("expected size limit error, got %v", err)
+	}
+}
+
+func TestURLFetchTool_BinaryResponseSavedToFile(t *testing.T) {
+	cacheDir := t.TempDir()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		h := make(http.Header)
+		h.Set("Content-Type", "image/png")
+		return &http.Response{StatusCode: 200, Header: h, Body: io.NopCloser(bytes.NewReader(png)), Request: r}, nil
+	})
+	tool := NewURLFetchTool(true, 2*time.Second, 1024, "test-agent", cacheDir)
+	tool.HTTPClient = &http.Client{Transport: rt}
+
+	for _, want := range []string{"downloads/logo.png", "downloads/logo-1.png"} {
+		out, err := tool.Execute(context.Background(), map[string]any{"url": "https://example.test/img/logo.png?size=2"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var info map[string]any
+		if err := json.Unmarshal([]byte(out), &info); err != nil {
+			t.Fatalf("expected JSON output, got %q", out)
+		}
+		if info["path"] != want || info["content_type"] != "image/png" {
+			t.Fatalf("unexpected metadata: %s", out)
+		}
+		got, _ := os.ReadFile(filepath.Join(cacheDir, filepath.FromSlash(want)))
+		if !bytes.Equal(got, png) {
+			t.Fatalf("saved bytes mismatch for %s", want)
+		}
+	}
+}
+
+func TestIsBinaryResponse(t *testing.T) {
+	cases := []struct {
+		ct   string
+		peek string
+		want bool
+	}{
+		{"application/pdf", "%PDF-1.7", true},
+		{"image/svg+xml", "<svg/>", false},
+		{"application/json", "{}", false},
+		{"application/octet-stream", "plain text 日本", false},
+		{"application/octet-stream", "PK\x03\x04\x00", true},
+		{"", "\xff\xfe\xfd\xfc\xfb", true},
+		{"text/plain", "\x00\x01", false},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "PK", true},
+	}
+	for _, c := range cases {
+		if got := isBinaryResponse(c.ct, []byte(c.peek)); got != c.want {
+			t.Fatalf("isBinaryResponse(%q, %q) = %v, want %v", c.ct, c.peek, got, c.want)
+		}
+	}
+	// A multi-byte rune cut off at the end of the peek is still text.
+	if isBinaryResponse("", []byte("abc日")[:5]) {
+		t.Fatalf("truncated UTF-8 treated as binary")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -320,3 +321,93 @@ func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
 	}
 	return f(r)
 }
+
+func TestURLFetchTool_HTMLFormats(t *testing.T) {
+	page := `<html><head><title>Docs</title><style>body{}</style></head><body>
+<nav><a href="/">Home</a></nav>
+<main><h1>Install</h1><p>Run the <a href="/dl">installer</a>, then restart the service, and check the logs.</p></main>
+</body></html>`
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		h := make(http.Header)
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		return &http.Response{StatusCode: 200, Header: h, Body: io.NopCloser(strings.NewReader(page)), Request: r}, nil
+	})
+	tool := NewURLFetchTool(true, 2*time.Second, 1024*1024, "test-agent", t.TempDir())
+	tool.HTTPClient = &http.Client{Transport: rt}
+
+	out, err := tool.Execute(context.Background(), map[string]any{"url": "https://example.test/guide"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"format: markdown\n", "title: Docs\n", "body:\n# Install\n\nRun the [installer](https://example.test/dl), then"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Home") || strings.Contains(out, "body{}") {
+		t.Fatalf("boilerplate leaked:\n%s", out)
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]any{"url": "https://example.test/guide", "format": "text"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Run the installer, then") {
+		t.Fatalf("text format:\n%s", out)
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]any{"url": "https://example.test/guide", "format": "raw"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "<nav>") || strings.Contains(out, "format:") {
+		t.Fatalf("raw format:\n%s", out)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"url": "https://example.test/guide", "format": "pdf"}); err == nil {
+		t.Fatal("expected error for invalid format")
+	}
+}
+
+func TestURLFetchTool_Pagination(t *testing.T) {
+	body := strings.Repeat("a", 40) + "é" + strings.Repeat("b", 40)
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		h := make(http.Header)
+		h.Set("Content-Type", "text/plain")
+		return &http.Response{StatusCode: 200, Header: h, Body: io.NopCloser(strings.NewReader(body)), Request: r}, nil
+	})
+	tool := NewURLFetchTool(true, 2*time.Second, 1024, "test-agent", t.TempDir())
+	tool.HTTPClient = &http.Client{Transport: rt}
+	tool.PageBytes = 41
+
+	var got strings.Builder
+	offset := 0
+	for i := 0; i < 5; i++ {
+		out, err := tool.Execute(context.Background(), map[string]any{"url": "https://example.test/log", "offset": offset})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, chunk, _ := strings.Cut(out, "body:\n")
+		got.WriteString(chunk)
+		idx := strings.Index(out, "next_offset: ")
+		if idx < 0 {
+			break
+		}
+		var next int
+		if _, err := fmt.Sscanf(out[idx:], "next_offset: %d", &next); err != nil {
+			t.Fatal(err)
+		}
+		if next <= offset {
+			t.Fatalf("next_offset did not advance: %d", next)
+		}
+		offset = next
+	}
+	if got.String() != body {
+		t.Fatalf("reassembled body = %q, want %q", got.String(), body)
+	}
+
+	// Paging re-sends the request, so it is refused for methods with side effects.
+	if _, err := tool.Execute(context.Background(), map[string]any{"url": "https://example.test/log", "method": "POST", "body": "x", "offset": 41}); err == nil || !strings.Contains(err.Error(), "only supported for GET") {
+		t.Fatalf("expected offset with POST to be rejected, got %v", err)
+	}
+}
@@ -22,6 +22,14 @@ type WebSearchTool struct {
 	UserAgent      string
 	MaxBodyBytes   int64
 	AllowRedirects bool
+	// Backend is the search provider (default: DuckDuckGo HTML at BaseURL).
+	Backend WebSearchBackend
+	// AuthProfile names the auth profile whose "web_search" binding injects the
+	// provider API key (e.g. Brave's X-Subscription-Token). Auth supplies the
+	// profile store and resolver shared with url_fetch.
+	AuthProfile string
+	Auth        *URLFetchAuth
+	HTTPClient  *http.Client
 }
 
 func NewWebSearchTool(enabled bool, baseURL string, timeout time.Duration, maxResults int, userAgent string) *WebSearchTool {
@@ -74,12 +82,6 @@ func (t *WebSearchTool) ParameterSchema() string {
 	return string(b)
 }
 
-type webSearchResult struct {
-	Title   string `json:"title"`
-	URL     string `json:"url"`
-	Snippet string `json:"snippet,omitempty"`
-}
-
 func (t *WebSearchTool) Execute(ctx context.Context, params map[string]any) (string, error) {
 	if !t.Enabled {
 		return "", fmt.Errorf("web_search tool is disabled (enable via config: tools.web_search.enabled=true)")
@@ -101,30 +103,41 @@ func (t *WebSearchTool) Execute(ctx context.Context, params map[string]any) (str
 		maxResults = 20
 	}
 
-	base, err := url.Parse(t.BaseURL)
+	backend := t.Backend
+	if backend == nil {
+		backend = &duckDuckGoBackend{baseURL: t.BaseURL}
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, t.Timeout)
+	defer cancel()
+
+	req, err := backend.NewRequest(reqCtx, q, maxResults)
 	if err != nil {
-		return "", fmt.Errorf("invalid base_url: %w", err)
+		return "", err
 	}
-	u := *base
-	qs := u.Query()
-	qs.Set("q", q)
-	u.RawQuery = qs.Encode()
+	req.Header.Set("User-Agent", t.UserAgent)
 
 	httpClient := &http.Client{Timeout: t.Timeout}
+	if t.HTTPClient != nil {
+		c := *t.HTTPClient
+		httpClient = &c
+	}
 	if !t.AllowRedirects {
 		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}
 	}
-
-	reqCtx, cancel := context.WithTimeout(ctx, t.Timeout)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return "", err
+	if profileID := strings.TrimSpace(t.AuthProfile); profileID != "" {
+		name, value, err := resolveToolAuthHeader(reqCtx, t.Auth, t.Name(), profileID, req)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set(name, value)
+		// Never replay the API key to another host.
+		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
 	}
-	req.Header.Set("User-Agent", t.UserAgent)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -142,13 +155,15 @@ func (t *WebSearchTool) Execute(ctx context.Context, params map[string]any) (str
 		return "", err
 	}
 
-	results, err := parseDuckDuckGoHTML(body, maxResults)
+	// Ask backends for extra results so duplicates don't shrink the list.
+	results, err := backend.Parse(body, maxResults*2)
 	if err != nil {
 		return "", err
 	}
+	results = dedupSearchResults(results, maxResults)
 
 	out := map[string]any{
-		"engine":       "duckduckgo_html",
+		"engine":       backend.Name(),
 		"query":        q,
 		"result_count": len(results),
 		"results":      results,
@@ -157,13 +172,13 @@ func (t *WebSearchTool) Execute(ctx context.Context, params map[string]any) (str
 	return string(b), nil
 }
 
-func parseDuckDuckGoHTML(htmlBytes []byte, maxResults int) ([]webSearchResult, error) {
+func parseDuckDuckGoHTML(htmlBytes []byte, maxResults int) ([]WebSearchResult, error) {
 	root, err := html.Parse(bytes.NewReader(htmlBytes))
 	if err != nil {
 		return nil, err
 	}
 
-	var out []webSearchResult
+	var out []WebSearchResult
 
 	var walk func(n *html.Node)
 	walk = func(n *html.Node) {
@@ -176,7 +191,7 @@ func parseDuckDuckGoHTML(htmlBytes []byte, maxResults int) ([]webSearchResult, e
 			href := attr(n, "href")
 			title := strings.TrimSpace(textContent(n))
 			if href != "" && title != "" {
-				res := webSearchResult{
+				res := WebSearchResult{
 					Title: title,
 					URL:   normalizeDuckDuckGoResultURL(href),
 				}
@@ -0,0 +1,355 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WebSearchBackend adapts one search provider to the web_search tool. The tool
+// owns transport concerns (User-Agent, credential injection, redirects, status
+// and size checks); backends only build the request and parse the response.
+type WebSearchBackend interface {
+	Name() string
+	NewRequest(ctx context.Context, q string, maxResults int) (*http.Request, error)
+	Parse(body []byte, maxResults int) ([]WebSearchResult, error)
+}
+
+type WebSearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// WebSearchJSONConfig describes a generic JSON search API (provider "json").
+//
+// In URL, {query} is replaced with the URL-escaped query and {max_results}
+// with the result count; in Body, {query} is JSON-string-escaped.
+// ResultsPath is a dotted path to the result array ("" for a top-level array);
+// the *Field values are dotted paths inside each result.
+type WebSearchJSONConfig struct {
+	Method       string            `mapstructure:"method"`
+	URL          string            `mapstructure:"url"`
+	Body         string            `mapstructure:"body"`
+	Headers      map[string]string `mapstructure:"headers"`
+	ResultsPath  string            `mapstructure:"results_path"`
+	TitleField   string            `mapstructure:"title_field"`
+	URLField     string            `mapstructure:"url_field"`
+	SnippetField string            `mapstructure:"snippet_field"`
+}
+
+// NewWebSearchBackend returns the backend for provider: duckduckgo (default),
+// searxng, brave, bing or json. baseURL overrides the provider endpoint and is
+// required for searxng.
+func NewWebSearchBackend(provider string, baseURL string, jsonCfg WebSearchJSONConfig) (WebSearchBackend, error) {
+	baseURL = strings.TrimSpace(baseURL)
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "", "duckduckgo", "ddg":
+		if baseURL == "" {
+			baseURL = "https://duckduckgo.com/html/"
+		}
+		return &duckDuckGoBackend{baseURL: baseURL}, nil
+	case "searxng", "searx":
+		if baseURL == "" {
+			return nil, fmt.Errorf("web_search provider searxng requires base_url (the instance URL)")
+		}
+		return &searxngBackend{baseURL: baseURL}, nil
+	case "brave":
+		if baseURL == "" {
+			baseURL = "https://api.search.brave.com/res/v1/web/search"
+		}
+		return &braveBackend{baseURL: baseURL}, nil
+	case "bing":
+		if baseURL == "" {
+			baseURL = "https://api.bing.microsoft.com/v7.0/search"
+		}
+		return &bingBackend{baseURL: baseURL}, nil
+	case "json":
+		if baseURL != "" && strings.TrimSpace(jsonCfg.URL) == "" {
+			jsonCfg.URL = baseURL
+		}
+		if strings.TrimSpace(jsonCfg.URL) == "" {
+			return nil, fmt.Errorf("web_search provider json requires json.url")
+		}
+		if !strings.Contains(jsonCfg.URL, "{query}") && !strings.Contains(jsonCfg.Body, "{query}") {
+			return nil, fmt.Errorf("web_search provider json: url or body must contain {query}")
+		}
+		if strings.TrimSpace(jsonCfg.URLField) == "" {
+			jsonCfg.URLField = "url"
+		}
+		if strings.TrimSpace(jsonCfg.TitleField) == "" {
+			jsonCfg.TitleField = "title"
+		}
+		return &jsonTemplateBackend{cfg: jsonCfg}, nil
+	default:
+		return nil, fmt.Errorf("unknown web_search provider: %q (supported: duckduckgo, searxng, brave, bing, json)", provider)
+	}
+}
+
+// getWithQuery builds a GET request for base with extra query params set.
+func getWithQuery(ctx context.Context, base string, params map[string]string) (*http.Request, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base_url: %w", err)
+	}
+	qs := u.Query()
+	for k, v := range params {
+		qs.Set(k, v)
+	}
+	u.RawQuery = qs.Encode()
+	return http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+}
+
+type duckDuckGoBackend struct{ baseURL string }
+
+func (b *duckDuckGoBackend) Name() string { return "duckduckgo_html" }
+
+func (b *duckDuckGoBackend) NewRequest(ctx context.Context, q string, _ int) (*http.Request, error) {
+	return getWithQuery(ctx, b.baseURL, map[string]string{"q": q})
+}
+
+func (b *duckDuckGoBackend) Parse(body []byte, maxResults int) ([]WebSearchResult, error) {
+	return parseDuckDuckGoHTML(body, maxResults)
+}
+
+type searxngBackend struct{ baseURL string }
+
+func (b *searxngBackend) Name() string { return "searxng" }
+
+func (b *searxngBackend) NewRequest(ctx context.Context, q string, _ int) (*http.Request, error) {
+	base := strings.TrimRight(b.baseURL, "/")
+	if !strings.HasSuffix(base, "/search") {
+		base += "/search"
+	}
+	return getWithQuery(ctx, base, map[string]string{"q": q, "format": "json"})
+}
+
+func (b *searxngBackend) Parse(body []byte, maxResults int) ([]WebSearchResult, error) {
+	var resp struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("searxng: invalid JSON response (is format=json enabled on the instance?): %w", err)
+	}
+	var out []WebSearchResult
+	for _, r := range resp.Results {
+		out = append(out, WebSearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return out, nil
+}
+
+type braveBackend struct{ baseURL string }
+
+func (b *braveBackend) Name() string { return "brave" }
+
+func (b *braveBackend) NewRequest(ctx context.Context, q string, maxResults int) (*http.Request, error) {
+	req, err := getWithQuery(ctx, b.baseURL, map[string]string{"q": q, "count": strconv.Itoa(maxResults)})
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
+
+func (b *braveBackend) Parse(body []byte, _ int) ([]WebSearchResult, error) {
+	var resp struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("brave: invalid JSON response: %w", err)
+	}
+	var out []WebSearchResult
+	for _, r := range resp.Web.Results {
+		out = append(out, WebSearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return out, nil
+}
+
+type bingBackend struct{ baseURL string }
+
+func (b *bingBackend) Name() string { return "bing" }
+
+func (b *bingBackend) NewRequest(ctx context.Context, q string, maxResults int) (*http.Request, error) {
+	return getWithQuery(ctx, b.baseURL, map[string]string{"q": q, "count": strconv.Itoa(maxResults)})
+}
+
+func (b *bingBackend) Parse(body []byte, _ int) ([]WebSearchResult, error) {
+	var resp struct {
+		WebPages struct {
+			Value []struct {
+				Name    string `json:"name"`
+				URL     string `json:"url"`
+				Snippet string `json:"snippet"`
+			} `json:"value"`
+		} `json:"webPages"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("bing: invalid JSON response: %w", err)
+	}
+	var out []WebSearchResult
+	for _, r := range resp.WebPages.Value {
+		out = append(out, WebSearchResult{Title: r.Name, URL: r.URL, Snippet: r.Snippet})
+	}
+	return out, nil
+}
+
+type jsonTemplateBackend struct{ cfg WebSearchJSONConfig }
+
+func (b *jsonTemplateBackend) Name() string { return "json" }
+
+func (b *jsonTemplateBackend) NewRequest(ctx context.Context, q string, maxResults int) (*http.Request, error) {
+	n := strconv.Itoa(maxResults)
+	rawURL := strings.NewReplacer("{query}", url.QueryEscape(q), "{max_results}", n).Replace(b.cfg.URL)
+	method := strings.ToUpper(strings.TrimSpace(b.cfg.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body *bytes.Reader
+	if b.cfg.Body != "" {
+		quoted, _ := json.Marshal(q)
+		escaped := string(quoted[1 : len(quoted)-1])
+		body = bytes.NewReader([]byte(strings.NewReplacer("{query}", escaped, "{max_results}", n).Replace(b.cfg.Body)))
+	}
+	var req *http.Request
+	var err error
+	if body != nil {
+		req, err = http.NewRequestWithContext(ctx, method, rawURL, body)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, rawURL, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range b.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (b *jsonTemplateBackend) Parse(body []byte, _ int) ([]WebSearchResult, error) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("json: invalid JSON response: %w", err)
+	}
+	items, ok := jsonPath(doc, b.cfg.ResultsPath).([]any)
+	if !ok {
+		return nil, fmt.Errorf("json: results_path %q is not an array in the response", b.cfg.ResultsPath)
+	}
+	var out []WebSearchResult
+	for _, it := range items {
+		out = append(out, WebSearchResult{
+			Title:   jsonString(jsonPath(it, b.cfg.TitleField)),
+			URL:     jsonString(jsonPath(it, b.cfg.URLField)),
+			Snippet: jsonString(jsonPath(it, b.cfg.SnippetField)),
+		})
+	}
+	return out, nil
+}
+
+// jsonPath walks a dotted path ("data.items.0.url") through decoded JSON.
+func jsonPath(v any, path string) any {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return v
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch x := v.(type) {
+		case map[string]any:
+			v = x[part]
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(x) {
+				return nil
+			}
+			v = x[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func jsonString(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+var snippetTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// cleanSearchText strips highlight markup (<strong>, <b>) and entities that
+// search APIs embed in titles and snippets.
+func cleanSearchText(s string) string {
+	s = snippetTagRe.ReplaceAllString(s, "")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// dedupSearchResults drops results without a URL and results whose URL only
+// differs by scheme, "www.", fragment, trailing slash or utm_* tracking params.
+func dedupSearchResults(in []WebSearchResult, maxResults int) []WebSearchResult {
+	seen := make(map[string]bool, len(in))
+	out := make([]WebSearchResult, 0, min(len(in), maxResults))
+	for _, r := range in {
+		r.URL = strings.TrimSpace(r.URL)
+		if r.URL == "" {
+			continue
+		}
+		key := searchResultKey(r.URL)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		r.Title = cleanSearchText(r.Title)
+		r.Snippet = cleanSearchText(r.Snippet)
+		out = append(out, r)
+		if len(out) >= maxResults {
+			break
+		}
+	}
+	return out
+}
+
+func searchResultKey(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	q := u.Query()
+	for k := range q {
+		if strings.HasPrefix(strings.ToLower(k), "utm_") {
+			q.Del(k)
+		}
+	}
+	key := host + strings.TrimRight(u.EscapedPath(), "/")
+	if enc := q.Encode(); enc != "" {
+		key += "?" + enc
+	}
+	return key
+}
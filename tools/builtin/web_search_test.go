@@ -0,0 +1,186 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/secrets"
+)
+
+type webSearchOutput struct {
+	Engine  string            `json:"engine"`
+	Results []WebSearchResult `json:"results"`
+}
+
+func runWebSearch(t *testing.T, tool *WebSearchTool, params map[string]any) webSearchOutput {
+	t.Helper()
+	out, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	var got webSearchOutput
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("invalid output %q: %v", out, err)
+	}
+	return got
+}
+
+func newTestWebSearchTool(t *testing.T, provider, baseURL string, jsonCfg WebSearchJSONConfig) *WebSearchTool {
+	t.Helper()
+	backend, err := NewWebSearchBackend(provider, baseURL, jsonCfg)
+	if err != nil {
+		t.Fatalf("NewWebSearchBackend: %v", err)
+	}
+	tool := NewWebSearchTool(true, "", 2*time.Second, 5, "test-agent")
+	tool.Backend = backend
+	return tool
+}
+
+func TestWebSearch_SearxNG(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" || r.URL.Query().Get("q") != "go generics" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		io.WriteString(w, `{"results":[
+			{"title":"Tutorial","url":"https://go.dev/doc/tutorial/generics","content":"An <b>intro</b> to generics"},
+			{"title":"Tutorial (dup)","url":"https://www.go.dev/doc/tutorial/generics/?utm_source=x#top"},
+			{"title":"Spec","url":"https://go.dev/ref/spec"},
+			{"title":"No URL","url":""}
+		]}`)
+	}))
+	defer srv.Close()
+
+	got := runWebSearch(t, newTestWebSearchTool(t, "searxng", srv.URL, WebSearchJSONConfig{}), map[string]any{"q": "go generics"})
+	if got.Engine != "searxng" || len(got.Results) != 2 {
+		t.Fatalf("got %+v", got)
+	}
+	if got.Results[0].Snippet != "An intro to generics" || got.Results[1].URL != "https://go.dev/ref/spec" {
+		t.Fatalf("got %+v", got.Results)
+	}
+}
+
+func TestWebSearch_BraveWithAuthProfile(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "brave_key")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Subscription-Token") != "brave_key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("count") != "3" {
+			t.Errorf("count = %q", r.URL.Query().Get("count"))
+		}
+		io.WriteString(w, `{"web":{"results":[{"title":"A &amp; B","url":"https://a.example/","description":"first"}]}}`)
+	}))
+	defer srv.Close()
+
+	deny := false
+	profile := testProfileForURL(t, "brave", srv.URL, secrets.ToolBinding{})
+	profile.Allow.DenyPrivateIPs = &deny
+	profile.Bindings = map[string]secrets.ToolBinding{
+		"web_search": {Inject: secrets.Inject{Location: "header", Name: "X-Subscription-Token"}},
+	}
+	auth := &URLFetchAuth{
+		Enabled:       true,
+		AllowProfiles: map[string]bool{"brave": true},
+		Profiles:      secrets.NewProfileStore(map[string]secrets.AuthProfile{"brave": profile}),
+		Resolver:      &secrets.EnvResolver{},
+	}
+
+	tool := newTestWebSearchTool(t, "brave", srv.URL+"/res/v1/web/search", WebSearchJSONConfig{})
+	tool.AuthProfile = "brave"
+	tool.Auth = auth
+	got := runWebSearch(t, tool, map[string]any{"q": "x", "max_results": 3})
+	if got.Engine != "brave" || len(got.Results) != 1 || got.Results[0].Title != "A & B" {
+		t.Fatalf("got %+v", got)
+	}
+
+	// Without an allowlisted profile the key is never sent.
+	auth.AllowProfiles = map[string]bool{}
+	if _, err := tool.Execute(context.Background(), map[string]any{"q": "x"}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected allowlist error, got %v", err)
+	}
+}
+
+func TestWebSearch_Bing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"webPages":{"value":[{"name":"Bing result","url":"https://b.example/x","snippet":"s"}]}}`)
+	}))
+	defer srv.Close()
+
+	got := runWebSearch(t, newTestWebSearchTool(t, "bing", srv.URL, WebSearchJSONConfig{}), map[string]any{"q": "x"})
+	if got.Engine != "bing" || len(got.Results) != 1 || got.Results[0].Title != "Bing result" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestWebSearch_JSONTemplate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || string(body) != `{"query":"say \"hi\"","limit":2}` {
+			t.Errorf("unexpected request %s %s", r.Method, body)
+		}
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Client") != "morph" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+		io.WriteString(w, `{"data":{"hits":[
+			{"meta":{"name":"One"},"link":"https://one.example/"},
+			{"meta":{"name":"Two"},"link":"https://two.example/","summary":"second"},
+			{"meta":{"name":"Three"},"link":"https://three.example/"}
+		]}}`)
+	}))
+	defer srv.Close()
+
+	cfg := WebSearchJSONConfig{
+		Method:       "POST",
+		URL:          srv.URL + "/api",
+		Body:         `{"query":"{query}","limit":{max_results}}`,
+		Headers:      map[string]string{"X-Client": "morph"},
+		ResultsPath:  "data.hits",
+		TitleField:   "meta.name",
+		URLField:     "link",
+		SnippetField: "summary",
+	}
+	got := runWebSearch(t, newTestWebSearchTool(t, "json", "", cfg), map[string]any{"q": `say "hi"`, "max_results": 2})
+	if len(got.Results) != 2 || got.Results[1].Title != "Two" || got.Results[1].Snippet != "second" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestWebSearch_DuckDuckGoDefault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<html><body>
+<a class="result__a" href="/l/?uddg=https%3A%2F%2Fexample.com%2Fpage">Example</a>
+<a class="result__a" href="https://example.com/page/">Example again</a>
+</body></html>`)
+	}))
+	defer srv.Close()
+
+	got := runWebSearch(t, NewWebSearchTool(true, srv.URL+"/html/", 2*time.Second, 5, "test-agent"), map[string]any{"q": "x"})
+	if got.Engine != "duckduckgo_html" || len(got.Results) != 1 || got.Results[0].URL != "https://example.com/page" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestNewWebSearchBackend_Errors(t *testing.T) {
+	cases := []struct {
+		provider string
+		base     string
+		cfg      WebSearchJSONConfig
+	}{
+		{"searxng", "", WebSearchJSONConfig{}},
+		{"json", "", WebSearchJSONConfig{}},
+		{"json", "", WebSearchJSONConfig{URL: "https://x.example/search"}},
+		{"altavista", "", WebSearchJSONConfig{}},
+	}
+	for _, tc := range cases {
+		if _, err := NewWebSearchBackend(tc.provider, tc.base, tc.cfg); err == nil {
+			t.Errorf("NewWebSearchBackend(%q, %q, %+v): expected error", tc.provider, tc.base, tc.cfg)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package builtin
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Shared helpers for the read-only workspace tools (list_dir, glob, grep).
+// They reuse read_file's policy: deny_paths, allowed_dirs and no symlink
+// traversal. Walks never follow symlinks and always skip .git.
+
+// FSPolicy is the read_file path policy shared by the workspace tools.
+type FSPolicy struct {
+	DenyPaths   []string
+	AllowedDirs []string
+}
+
+// resolveRoot validates a user-supplied directory. An empty path means the
+// first allowed dir, or the current directory when allowed_dirs is unset.
+func (p FSPolicy) resolveRoot(toolName string, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		raw = "."
+		if len(p.AllowedDirs) > 0 {
+			raw = strings.TrimSpace(p.AllowedDirs[0])
+		}
+	}
+	if containsDotDot(raw) {
+		return "", fmt.Errorf("path traversal not allowed: %s", raw)
+	}
+	abs, err := filepath.Abs(expandHomePath(raw))
+	if err != nil {
+		return "", err
+	}
+	// An allowed dir itself is a valid root; everything else goes through the read_file policy.
+	if !p.isAllowedDir(abs) {
+		cleaned, err := checkFilePathPolicy(toolName, raw, p.DenyPaths, p.AllowedDirs)
+		if err != nil {
+			return "", err
+		}
+		if abs, err = filepath.Abs(cleaned); err != nil {
+			return "", err
+		}
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !fi.IsDir() {
+		return "", fmt.Errorf("%s: not a directory: %s", toolName, raw)
+	}
+	return abs, nil
+}
+
+// resolveCachePath resolves a file to read for tools that work on
+// file_cache_dir contents (json_query, file_archive): relative paths resolve
+// under baseDir, which is always allowed in addition to allowed_dirs.
+func (p FSPolicy) resolveCachePath(toolName, raw, baseDir string) (string, error) {
+	raw = expandHomePath(strings.TrimSpace(raw))
+	// Checked before joining, which would clean the ".." away.
+	if containsDotDot(raw) {
+		return "", fmt.Errorf("path traversal not allowed: %s", raw)
+	}
+	base := ""
+	if strings.TrimSpace(baseDir) != "" {
+		if abs, err := filepath.Abs(expandHomePath(strings.TrimSpace(baseDir))); err == nil {
+			base = abs
+		}
+	}
+	if !filepath.IsAbs(raw) && base != "" {
+		raw = filepath.Join(base, raw)
+	}
+	allowed := p.AllowedDirs
+	if len(allowed) > 0 && base != "" {
+		allowed = append(append([]string(nil), allowed...), base)
+	}
+	return checkFilePathPolicy(toolName, raw, p.DenyPaths, allowed)
+}
+
+func (p FSPolicy) isAllowedDir(abs string) bool {
+	for _, d := range p.AllowedDirs {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		if dAbs, err := filepath.Abs(expandHomePath(d)); err == nil && filepath.Clean(dAbs) == filepath.Clean(abs) {
+			return true
+		}
+	}
+	return false
+}
+
+type walkOptions struct {
+	MaxDepth      int // 0 = unlimited; 1 = direct children only
+	IncludeHidden bool
+	NoIgnore      bool // do not apply .gitignore rules
+}
+
+var errStopWalk = errors.New("stop walk")
+
+// walkWorkspace walks root and calls fn with slash-separated paths relative to root.
+// Returning errStopWalk from fn ends the walk without error.
+func walkWorkspace(root string, policy FSPolicy, opts walkOptions, fn func(rel string, d fs.DirEntry) error) error {
+	ign := &ignoreMatcher{}
+	if !opts.NoIgnore {
+		ign.load(root, "")
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			// Unreadable entries are skipped rather than failing the whole walk.
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		name := d.Name()
+
+		if d.IsDir() && name == ".git" {
+			return fs.SkipDir
+		}
+		if !opts.IncludeHidden && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if _, denied := denyPath(p, policy.DenyPaths); denied {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ign.ignored(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		depth := strings.Count(rel, "/") + 1
+		if err := fn(rel, d); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+				return fs.SkipDir
+			}
+			if !opts.NoIgnore {
+				ign.load(p, rel)
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errStopWalk) {
+		return nil
+	}
+	return err
+}
+
+// ignoreMatcher implements the commonly used subset of .gitignore semantics:
+// comments, negation (!), directory-only patterns (trailing /), anchored
+// patterns (containing /) and ** wildcards. Later rules win.
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	base     string // directory of the .gitignore, relative to the walk root ("" = root)
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func (m *ignoreMatcher) load(absDir, relDir string) {
+	f, err := os.Open(filepath.Join(absDir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	m.parse(f, relDir)
+}
+
+func (m *ignoreMatcher) parse(r io.Reader, relDir string) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: relDir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		m.rules = append(m.rules, rule)
+	}
+}
+
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = rel[len(r.base)+1:]
+		}
+		var ok bool
+		if r.anchored {
+			ok = matchGlobPath(r.pattern, sub)
+		} else {
+			ok, _ = path.Match(r.pattern, path.Base(sub))
+		}
+		if ok {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchGlobPath matches a slash-separated path against a glob where "**"
+// matches zero or more whole path segments.
+func matchGlobPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, parts []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			if len(rest) == 0 {
+				return true
+			}
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], parts[0]); !ok {
+			return false
+		}
+		pat, parts = pat[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// looksBinary reports whether the sample contains a NUL byte, the same
+// heuristic git and grep use.
+func looksBinary(sample []byte) bool {
+	return bytes.IndexByte(sample, 0) >= 0
+}
@@ -0,0 +1,176 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newWorkspaceFixture(t *testing.T) (string, FSPolicy) {
+	t.Helper()
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":        "build/\n*.log\n!keep.log\n/docs/draft-*.md\n",
+		"main.go":           "package main\n\nfunc main() {\n\t// TODO: wire config\n}\n",
+		"pkg/util/util.go":  "package util\n\n// TODO: remove\nfunc Helper() {}\n",
+		"pkg/util/util.txt": "todo in text\n",
+		"build/out.go":      "// TODO: generated\n",
+		"debug.log":         "TODO log\n",
+		"keep.log":          "TODO keep\n",
+		"docs/guide.md":     "# Guide\n",
+		"docs/draft-1.md":   "TODO draft\n",
+		".env":              "TOKEN=TODO\n",
+		"config.yaml":       "secret: TODO\n",
+		"bin/blob.dat":      "TODO\x00binary",
+	})
+	return root, FSPolicy{DenyPaths: []string{"config.yaml"}, AllowedDirs: []string{root}}
+}
+
+func TestListDirTool(t *testing.T) {
+	root, policy := newWorkspaceFixture(t)
+	tool := NewListDirTool(policy, 0)
+
+	out, err := tool.Execute(context.Background(), map[string]any{})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, want := range []string{"main.go\t", "pkg/\n", "docs/\n", "keep.log\t"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"build/", "debug.log", ".env", "config.yaml", "pkg/util"} {
+		if strings.Contains(out, unwanted) {
+			t.Fatalf("unexpected %q in:\n%s", unwanted, out)
+		}
+	}
+
+	out, err = tool.Execute(context.Background(), map[string]any{"path": root, "depth": 3, "include_hidden": true, "include_ignored": true})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, want := range []string{"pkg/util/util.go", "build/out.go", ".env", "debug.log"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "config.yaml") {
+		t.Fatalf("deny_paths must always apply:\n%s", out)
+	}
+
+	small := NewListDirTool(policy, 2)
+	out, _ = small.Execute(context.Background(), map[string]any{})
+	if !strings.Contains(out, "truncated at 2 entries") {
+		t.Fatalf("expected truncation:\n%s", out)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"path": t.TempDir()}); err == nil {
+		t.Fatalf("expected path outside allowed_dirs to be denied")
+	}
+}
+
+func TestGlobTool(t *testing.T) {
+	root, policy := newWorkspaceFixture(t)
+	tool := NewGlobTool(policy, 0)
+
+	out, err := tool.Execute(context.Background(), map[string]any{"pattern": "**/*.go", "path": root})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !strings.Contains(out, "matches: 2\nmain.go\npkg/util/util.go\n") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	out, _ = tool.Execute(context.Background(), map[string]any{"pattern": "*.md"})
+	if !strings.Contains(out, "docs/guide.md") || strings.Contains(out, "draft-1.md") {
+		t.Fatalf("anchored gitignore pattern not applied:\n%s", out)
+	}
+
+	out, _ = tool.Execute(context.Background(), map[string]any{"pattern": "pkg/*/*.txt"})
+	if !strings.Contains(out, "pkg/util/util.txt") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]any{"pattern": "[bad"}); err == nil {
+		t.Fatalf("expected invalid pattern error")
+	}
+}
+
+func TestGrepTool(t *testing.T) {
+	root, policy := newWorkspaceFixture(t)
+	tool := NewGrepTool(policy, 0, 0)
+
+	out, err := tool.Execute(context.Background(), map[string]any{"pattern": "TODO"})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for _, want := range []string{"main.go:4:\t// TODO: wire config", "pkg/util/util.go:3:// TODO: remove", "keep.log:1:TODO keep", "skipped: 1 binary"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"build/out.go", "debug.log", "draft-1.md", ".env", "config.yaml", "util.txt"} {
+		if strings.Contains(out, unwanted) {
+			t.Fatalf("unexpected %q in:\n%s", unwanted, out)
+		}
+	}
+
+	out, _ = tool.Execute(context.Background(), map[string]any{"pattern": "todo", "ignore_case": true, "glob": "*.txt"})
+	if !strings.Contains(out, "matches: 1 in 1 files") || !strings.Contains(out, "pkg/util/util.txt:1:todo in text") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	out, _ = tool.Execute(context.Background(), map[string]any{"pattern": "Helper() {", "fixed_string": true, "context": 1, "path": filepath.Join(root, "pkg/util/util.go")})
+	if !strings.Contains(out, "util.go-3-// TODO: remove\nutil.go:4:func Helper() {}\n--\n") {
+		t.Fatalf("unexpected context output:\n%s", out)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{"pattern": "x", "path": filepath.Join(root, "config.yaml")}); err == nil {
+		t.Fatalf("expected denied file to be rejected")
+	}
+
+	capped := NewGrepTool(policy, 1, 0)
+	out, _ = capped.Execute(context.Background(), map[string]any{"pattern": "TODO"})
+	if !strings.Contains(out, "truncated at 1 matches") {
+		t.Fatalf("expected truncation:\n%s", out)
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	m := &ignoreMatcher{}
+	m.parse(strings.NewReader("# comment\nnode_modules/\n**/tmp/*.txt\n*.o\n!main.o\n"), "")
+	m.parse(strings.NewReader("local.cfg\n"), "sub")
+	cases := []struct {
+		rel  string
+		dir  bool
+		want bool
+	}{
+		{"node_modules", true, true},
+		{"node_modules", false, false},
+		{"a/b/tmp/x.txt", false, true},
+		{"tmp/x.txt", false, true},
+		{"lib.o", false, true},
+		{"main.o", false, false},
+		{"sub/local.cfg", false, true},
+		{"local.cfg", false, false},
+	}
+	for _, tc := range cases {
+		if got := m.ignored(tc.rel, tc.dir); got != tc.want {
+			t.Errorf("ignored(%q, dir=%v)=%v, want %v", tc.rel, tc.dir, got, tc.want)
+		}
+	}
+}
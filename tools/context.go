@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"strings"
+)
+
+type ctxKeyRunID struct{}
+
+// WithRunID tags ctx with the agent run that is executing a tool call.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, ctxKeyRunID{}, strings.TrimSpace(runID))
+}
+
+// RunIDFromContext returns the run ID set by WithRunID, if any.
+func RunIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, _ := ctx.Value(ctxKeyRunID{}).(string)
+	return id, id != ""
+}
+
+// RunFinisher is implemented by tools that keep per-run state (e.g. bash sessions).
+// The agent calls FinishRun once a run completes; runs paused for approval are
+// not finished until they are resumed and complete.
+type RunFinisher interface {
+	FinishRun(runID string)
+}
+
+// FinishRun notifies every RunFinisher in r that runID has completed.
+func (r *Registry) FinishRun(runID string) {
+	if r == nil || strings.TrimSpace(runID) == "" {
+		return
+	}
+	for _, t := range r.tools {
+		if f, ok := t.(RunFinisher); ok {
+			f.FinishRun(runID)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+type finisherTool struct {
+	Tool
+	finished []string
+}
+
+func (f *finisherTool) Name() string           { return "finisher" }
+func (f *finisherTool) FinishRun(runID string) { f.finished = append(f.finished, runID) }
+
+func TestRunIDContextAndFinishRun(t *testing.T) {
+	if _, ok := RunIDFromContext(context.Background()); ok {
+		t.Fatalf("expected no run id")
+	}
+	ctx := WithRunID(context.Background(), " abc ")
+	if id, ok := RunIDFromContext(ctx); !ok || id != "abc" {
+		t.Fatalf("RunIDFromContext=%q,%v", id, ok)
+	}
+
+	r := NewRegistry()
+	f := &finisherTool{}
+	r.Register(f)
+	r.FinishRun("abc")
+	r.FinishRun("")
+	if len(f.finished) != 1 || f.finished[0] != "abc" {
+		t.Fatalf("finished=%v", f.finished)
+	}
+}
@@ -0,0 +1,314 @@
+// Package plugin runs out-of-process tools that speak a small JSON-lines
+// protocol over stdin/stdout. See docs/plugins.md for the wire format.
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/internal/strutil"
+)
+
+// Config configures one plugin executable under `tools.plugins`.
+type Config struct {
+	Name    string            `mapstructure:"name"`
+	Enabled *bool             `mapstructure:"enabled"`
+	Command string            `mapstructure:"command"`
+	Args    []string          `mapstructure:"args"`
+	Env     map[string]string `mapstructure:"env"`
+	Dir     string            `mapstructure:"dir"`
+
+	// Timeout bounds each execute call; the plugin is killed and restarted on expiry.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// StartTimeout bounds process start + describe.
+	StartTimeout time.Duration `mapstructure:"start_timeout"`
+	// MaxOutputBytes truncates tool output returned to the agent.
+	MaxOutputBytes int `mapstructure:"max_output_bytes"`
+	// MaxRestarts limits automatic restarts within RestartWindow (0 = default).
+	MaxRestarts   int           `mapstructure:"max_restarts"`
+	RestartWindow time.Duration `mapstructure:"restart_window"`
+}
+
+func (c Config) IsEnabled() bool { return c.Enabled == nil || *c.Enabled }
+
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	if c.StartTimeout <= 0 {
+		c.StartTimeout = 10 * time.Second
+	}
+	if c.MaxOutputBytes <= 0 {
+		c.MaxOutputBytes = 256 * 1024
+	}
+	if c.MaxRestarts <= 0 {
+		c.MaxRestarts = 5
+	}
+	if c.RestartWindow <= 0 {
+		c.RestartWindow = 5 * time.Minute
+	}
+	return c
+}
+
+// ToolSpec is a tool declared by a plugin in its describe response.
+type ToolSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type request struct {
+	ID     int64  `json:"id"`
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+type response struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type executeResult struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Plugin supervises one plugin process. Calls are serialized; a crashed or
+// timed-out process is restarted lazily on the next call.
+type Plugin struct {
+	cfg Config
+	log *slog.Logger
+
+	mu       sync.Mutex
+	proc     *process
+	nextID   int64
+	restarts []time.Time
+	tools    []ToolSpec
+}
+
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan []byte
+	done  chan struct{}
+}
+
+// Start launches the plugin and fetches its tool list.
+func Start(ctx context.Context, cfg Config, log *slog.Logger) (*Plugin, error) {
+	cfg = cfg.withDefaults()
+	if strings.TrimSpace(cfg.Name) == "" {
+		return nil, fmt.Errorf("plugin: missing name")
+	}
+	if strings.TrimSpace(cfg.Command) == "" {
+		return nil, fmt.Errorf("plugin %q: missing command", cfg.Name)
+	}
+	if log == nil {
+		log = slog.Default()
+	}
+	p := &Plugin{cfg: cfg, log: log}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.startLocked(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Plugin) Name() string { return p.cfg.Name }
+
+// Tools returns the tool specs declared by the plugin at startup.
+func (p *Plugin) Tools() []ToolSpec {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ToolSpec{}, p.tools...)
+}
+
+func (p *Plugin) startLocked(ctx context.Context) error {
+	cmd := exec.Command(p.cfg.Command, p.cfg.Args...)
+	cmd.Dir = p.cfg.Dir
+	cmd.Env = os.Environ()
+	for k, v := range p.cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("plugin %q: start: %w", p.cfg.Name, err)
+	}
+	proc := &process{cmd: cmd, stdin: stdin, lines: make(chan []byte, 1), done: make(chan struct{})}
+	// A response line may carry up to MaxOutputBytes of (JSON-escaped) output.
+	maxLine := p.cfg.MaxOutputBytes*6 + 64*1024
+	go func() {
+		defer close(proc.done)
+		sc := bufio.NewScanner(stdout)
+		sc.Buffer(make([]byte, 64*1024), maxLine)
+		for sc.Scan() {
+			proc.lines <- append([]byte(nil), sc.Bytes()...)
+		}
+		_ = cmd.Wait()
+	}()
+	p.proc = proc
+
+	sctx, cancel := context.WithTimeout(ctx, p.cfg.StartTimeout)
+	defer cancel()
+	raw, err := p.callLocked(sctx, "describe", nil)
+	if err != nil {
+		p.killLocked()
+		return fmt.Errorf("plugin %q: describe: %w", p.cfg.Name, err)
+	}
+	var desc struct {
+		Tools []ToolSpec `json:"tools"`
+	}
+	if err := json.Unmarshal(raw, &desc); err != nil {
+		p.killLocked()
+		return fmt.Errorf("plugin %q: invalid describe result: %w", p.cfg.Name, err)
+	}
+	p.tools = desc.Tools
+	return nil
+}
+
+func (p *Plugin) callLocked(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	proc := p.proc
+	if proc == nil {
+		return nil, fmt.Errorf("plugin not running")
+	}
+	p.nextID++
+	id := p.nextID
+	b, err := json.Marshal(request{ID: id, Method: method, Params: params})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := proc.stdin.Write(append(b, '\n')); err != nil {
+		p.killLocked()
+		return nil, fmt.Errorf("plugin write failed: %w", err)
+	}
+	for {
+		select {
+		case line := <-proc.lines:
+			var resp response
+			if err := json.Unmarshal(line, &resp); err != nil {
+				// Stray output on stdout; ignore it.
+				continue
+			}
+			if resp.ID != id {
+				continue
+			}
+			if resp.Error != "" {
+				return nil, fmt.Errorf("%s", resp.Error)
+			}
+			return resp.Result, nil
+		case <-proc.done:
+			p.killLocked()
+			return nil, fmt.Errorf("plugin exited")
+		case <-ctx.Done():
+			// The response stream is now out of sync; kill so the next call restarts cleanly.
+			p.killLocked()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *Plugin) killLocked() {
+	proc := p.proc
+	p.proc = nil
+	if proc == nil {
+		return
+	}
+	_ = proc.stdin.Close()
+	if proc.cmd.Process != nil {
+		_ = proc.cmd.Process.Kill()
+	}
+	// Drain so the reader goroutine can exit.
+	go func() {
+		for {
+			select {
+			case <-proc.lines:
+			case <-proc.done:
+				return
+			}
+		}
+	}()
+}
+
+func (p *Plugin) ensureRunningLocked(ctx context.Context) error {
+	if p.proc != nil {
+		select {
+		case <-p.proc.done:
+			p.killLocked()
+		default:
+			return nil
+		}
+	}
+	now := time.Now()
+	kept := p.restarts[:0]
+	for _, t := range p.restarts {
+		if now.Sub(t) < p.cfg.RestartWindow {
+			kept = append(kept, t)
+		}
+	}
+	p.restarts = kept
+	if len(p.restarts) >= p.cfg.MaxRestarts {
+		return fmt.Errorf("plugin %q: too many restarts (%d within %s)", p.cfg.Name, len(p.restarts), p.cfg.RestartWindow)
+	}
+	p.restarts = append(p.restarts, now)
+	p.log.Warn("plugin_restart", "plugin", p.cfg.Name, "restarts", len(p.restarts))
+	return p.startLocked(ctx)
+}
+
+// Execute runs one tool call with the configured timeout and output limit.
+func (p *Plugin) Execute(ctx context.Context, tool string, params map[string]any) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.ensureRunningLocked(ctx); err != nil {
+		return "", err
+	}
+	cctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+	if params == nil {
+		params = map[string]any{}
+	}
+	raw, err := p.callLocked(cctx, "execute", map[string]any{"tool": tool, "params": params})
+	if err != nil {
+		if cctx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			return "", fmt.Errorf("plugin %q: %s timed out after %s", p.cfg.Name, tool, p.cfg.Timeout)
+		}
+		return "", fmt.Errorf("plugin %q: %s: %w", p.cfg.Name, tool, err)
+	}
+	var res executeResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return "", fmt.Errorf("plugin %q: invalid execute result: %w", p.cfg.Name, err)
+	}
+	out := res.Output
+	if len(out) > p.cfg.MaxOutputBytes {
+		out = strutil.TruncateUTF8(out, p.cfg.MaxOutputBytes) + "\n...(truncated)"
+	}
+	if res.Error != "" {
+		return out, fmt.Errorf("%s", res.Error)
+	}
+	return out, nil
+}
+
+// Close stops the plugin process.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.killLocked()
+	return nil
+}
@@ -0,0 +1,155 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// TestHelperPlugin is not a real test: when GO_WANT_HELPER_PLUGIN=1 the test
+// binary acts as a plugin process speaking the JSON-lines protocol.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PLUGIN") != "1" {
+		return
+	}
+	sc := bufio.NewScanner(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+	reply := func(id int64, result any, errMsg string) {
+		m := map[string]any{"id": id}
+		if errMsg != "" {
+			m["error"] = errMsg
+		} else {
+			m["result"] = result
+		}
+		b, _ := json.Marshal(m)
+		_, _ = out.Write(append(b, '\n'))
+		_ = out.Flush()
+	}
+	fmt.Fprintln(out, "plugin starting (noise that must be ignored)")
+	_ = out.Flush()
+	for sc.Scan() {
+		var req struct {
+			ID     int64  `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Tool   string         `json:"tool"`
+				Params map[string]any `json:"params"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			continue
+		}
+		switch req.Method {
+		case "describe":
+			reply(req.ID, map[string]any{"tools": []any{
+				map[string]any{"name": "shout", "description": "Uppercase text.", "parameters": map[string]any{"type": "object", "properties": map[string]any{"text": map[string]any{"type": "string"}}}},
+				map[string]any{"name": "nap"},
+				map[string]any{"name": "crash"},
+				map[string]any{"name": "big"},
+			}}, "")
+		case "execute":
+			switch req.Params.Tool {
+			case "shout":
+				reply(req.ID, map[string]any{"output": strings.ToUpper(fmt.Sprint(req.Params.Params["text"]))}, "")
+			case "nap":
+				time.Sleep(2 * time.Second)
+				reply(req.ID, map[string]any{"output": "rested"}, "")
+			case "crash":
+				os.Exit(3)
+			case "big":
+				reply(req.ID, map[string]any{"output": strings.Repeat("x", 5000)}, "")
+			default:
+				reply(req.ID, nil, "unknown tool")
+			}
+		}
+	}
+	os.Exit(0)
+}
+
+func helperConfig(t *testing.T) Config {
+	t.Helper()
+	return Config{
+		Name:           "helper",
+		Command:        os.Args[0],
+		Args:           []string{"-test.run=TestHelperPlugin"},
+		Env:            map[string]string{"GO_WANT_HELPER_PLUGIN": "1"},
+		Timeout:        300 * time.Millisecond,
+		MaxOutputBytes: 1000,
+	}
+}
+
+func TestLoad_RegistersAndExecutes(t *testing.T) {
+	r := tools.NewRegistry()
+	ps := Load(context.Background(), r, []Config{helperConfig(t)}, nil)
+	if len(ps) != 1 {
+		t.Fatalf("expected plugin to start")
+	}
+	defer ps[0].Close()
+
+	shout, ok := r.Get("shout")
+	if !ok {
+		t.Fatalf("shout not registered; have %s", r.ToolNames())
+	}
+	if !strings.Contains(shout.ParameterSchema(), `"text"`) {
+		t.Fatalf("schema not propagated: %s", shout.ParameterSchema())
+	}
+	out, err := shout.Execute(context.Background(), map[string]any{"text": "hi"})
+	if err != nil || out != "HI" {
+		t.Fatalf("Execute=%q,%v", out, err)
+	}
+
+	big, _ := r.Get("big")
+	out, err = big.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("big: %v", err)
+	}
+	if !strings.HasSuffix(out, "...(truncated)") || len(out) > 1100 {
+		t.Fatalf("output not truncated: len=%d", len(out))
+	}
+}
+
+func TestPlugin_TimeoutAndCrashRestart(t *testing.T) {
+	p, err := Start(context.Background(), helperConfig(t), nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Close()
+
+	if _, err := p.Execute(context.Background(), "nap", nil); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	// After a timeout the plugin is restarted transparently.
+	if out, err := p.Execute(context.Background(), "shout", map[string]any{"text": "ok"}); err != nil || out != "OK" {
+		t.Fatalf("after timeout: %q,%v", out, err)
+	}
+
+	if _, err := p.Execute(context.Background(), "crash", nil); err == nil {
+		t.Fatalf("expected crash error")
+	}
+	if out, err := p.Execute(context.Background(), "shout", map[string]any{"text": "back"}); err != nil || out != "BACK" {
+		t.Fatalf("after crash: %q,%v", out, err)
+	}
+}
+
+func TestPlugin_RestartLimit(t *testing.T) {
+	cfg := helperConfig(t)
+	cfg.MaxRestarts = 1
+	p, err := Start(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Close()
+
+	_, _ = p.Execute(context.Background(), "crash", nil)
+	_, _ = p.Execute(context.Background(), "crash", nil) // uses the one allowed restart
+	if _, err := p.Execute(context.Background(), "shout", nil); err == nil || !strings.Contains(err.Error(), "too many restarts") {
+		t.Fatalf("expected restart limit error, got %v", err)
+	}
+}
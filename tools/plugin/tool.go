@@ -0,0 +1,81 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/quailyquaily/mistermorph/tools"
+)
+
+// Tool adapts one plugin-declared tool to tools.Tool.
+type Tool struct {
+	plugin *Plugin
+	spec   ToolSpec
+	schema string
+}
+
+func (t *Tool) Name() string            { return t.spec.Name }
+func (t *Tool) Description() string     { return t.spec.Description }
+func (t *Tool) ParameterSchema() string { return t.schema }
+
+func (t *Tool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	return t.plugin.Execute(ctx, t.spec.Name, params)
+}
+
+// AsTools returns the plugin's declared tools as tools.Tool values.
+func (p *Plugin) AsTools() []tools.Tool {
+	var out []tools.Tool
+	for _, spec := range p.Tools() {
+		spec.Name = strings.TrimSpace(spec.Name)
+		if spec.Name == "" {
+			continue
+		}
+		schema := `{"type":"object","properties":{}}`
+		if len(spec.Parameters) > 0 {
+			var v any
+			if err := json.Unmarshal(spec.Parameters, &v); err == nil {
+				b, _ := json.MarshalIndent(v, "", "  ")
+				schema = string(b)
+			}
+		}
+		if strings.TrimSpace(spec.Description) == "" {
+			spec.Description = "Plugin tool provided by " + p.Name() + "."
+		}
+		out = append(out, &Tool{plugin: p, spec: spec, schema: schema})
+	}
+	return out
+}
+
+// Load starts every enabled plugin and registers its tools into r. Plugins
+// that fail to start are logged and skipped; plugin tools never replace
+// already-registered tools.
+func Load(ctx context.Context, r *tools.Registry, cfgs []Config, log *slog.Logger) []*Plugin {
+	if log == nil {
+		log = slog.Default()
+	}
+	var out []*Plugin
+	for _, cfg := range cfgs {
+		if !cfg.IsEnabled() {
+			continue
+		}
+		p, err := Start(ctx, cfg, log)
+		if err != nil {
+			log.Warn("plugin_start_error", "plugin", cfg.Name, "error", err.Error())
+			continue
+		}
+		var names []string
+		for _, t := range p.AsTools() {
+			if _, exists := r.Get(t.Name()); exists {
+				log.Warn("plugin_tool_name_conflict", "plugin", p.Name(), "tool", t.Name())
+				continue
+			}
+			r.Register(t)
+			names = append(names, t.Name())
+		}
+		log.Info("plugin_loaded", "plugin", p.Name(), "tools", names)
+		out = append(out, p)
+	}
+	return out
+}
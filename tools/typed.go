@@ -0,0 +1,318 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TypedFunc is the handler signature used by NewTyped. P must be a struct type
+// (or a pointer to one); its exported fields describe the tool parameters.
+type TypedFunc[P any] func(ctx context.Context, params P) (string, error)
+
+// NewTyped builds a Tool whose JSON schema is derived from the fields of P and
+// whose params are decoded into P before fn is called.
+//
+// Field tags:
+//   - json:"name[,omitempty]"  parameter name; omitempty (or a pointer field) marks it optional.
+//   - desc:"..."               parameter description.
+//   - enum:"a|b|c"             allowed values for string and number fields.
+//
+// Decoding is lenient about numbers sent as strings (common with LLM output),
+// but rejects missing required params and values outside enum.
+func NewTyped[P any](name, description string, fn TypedFunc[P]) Tool {
+	if fn == nil {
+		panic("tools.NewTyped: nil handler")
+	}
+	t := reflect.TypeOf((*P)(nil)).Elem()
+	st := t
+	if st.Kind() == reflect.Pointer {
+		st = st.Elem()
+	}
+	if st.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("tools.NewTyped: params type must be a struct, got %s", t))
+	}
+	fields := typedFields(st)
+	schema := map[string]any{
+		"type":       "object",
+		"properties": typedProperties(fields),
+	}
+	if req := typedRequired(fields); len(req) > 0 {
+		schema["required"] = req
+	}
+	b, _ := json.MarshalIndent(schema, "", "  ")
+	return &typedTool[P]{
+		name:        strings.TrimSpace(name),
+		description: strings.TrimSpace(description),
+		schema:      string(b),
+		fn:          fn,
+	}
+}
+
+type typedTool[P any] struct {
+	name        string
+	description string
+	schema      string
+	fn          TypedFunc[P]
+}
+
+func (t *typedTool[P]) Name() string            { return t.name }
+func (t *typedTool[P]) Description() string     { return t.description }
+func (t *typedTool[P]) ParameterSchema() string { return t.schema }
+
+func (t *typedTool[P]) Execute(ctx context.Context, params map[string]any) (string, error) {
+	p, err := DecodeParams[P](params)
+	if err != nil {
+		return "", err
+	}
+	return t.fn(ctx, p)
+}
+
+// DecodeParams decodes a tool params map into P using the same rules as NewTyped.
+func DecodeParams[P any](params map[string]any) (P, error) {
+	var out P
+	t := reflect.TypeOf(out)
+	isPtr := t != nil && t.Kind() == reflect.Pointer
+	st := t
+	if isPtr {
+		st = t.Elem()
+	}
+	if st == nil || st.Kind() != reflect.Struct {
+		return out, fmt.Errorf("params type must be a struct")
+	}
+	fields := typedFields(st)
+
+	normalized := make(map[string]any, len(params))
+	for k, v := range params {
+		normalized[k] = v
+	}
+	for _, f := range fields {
+		v, ok := normalized[f.name]
+		if !ok || v == nil {
+			if f.required {
+				return out, fmt.Errorf("missing required param: %s", f.name)
+			}
+			continue
+		}
+		cv, err := coerceParam(f, v)
+		if err != nil {
+			return out, err
+		}
+		if f.required && f.kind == reflect.String {
+			if s, _ := cv.(string); strings.TrimSpace(s) == "" {
+				return out, fmt.Errorf("missing required param: %s", f.name)
+			}
+		}
+		if len(f.enum) > 0 && !enumContains(f, cv) {
+			return out, fmt.Errorf("invalid %s: %v (expected %s)", f.name, cv, strings.Join(f.enum, "|"))
+		}
+		normalized[f.name] = cv
+	}
+
+	b, err := json.Marshal(normalized)
+	if err != nil {
+		return out, fmt.Errorf("invalid params: %w", err)
+	}
+	target := reflect.New(st)
+	if err := json.Unmarshal(b, target.Interface()); err != nil {
+		return out, fmt.Errorf("invalid params: %w", err)
+	}
+	if isPtr {
+		return target.Interface().(P), nil
+	}
+	return target.Elem().Interface().(P), nil
+}
+
+type typedField struct {
+	name     string
+	desc     string
+	enum     []string
+	required bool
+	kind     reflect.Kind
+	typ      reflect.Type
+}
+
+func typedFields(st reflect.Type) []typedField {
+	var out []typedField
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		omitEmpty := false
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if strings.TrimSpace(parts[0]) != "" {
+				name = strings.TrimSpace(parts[0])
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+		ft := sf.Type
+		isPtr := ft.Kind() == reflect.Pointer
+		if isPtr {
+			ft = ft.Elem()
+		}
+		var enum []string
+		for _, v := range strings.Split(sf.Tag.Get("enum"), "|") {
+			if v = strings.TrimSpace(v); v != "" {
+				enum = append(enum, v)
+			}
+		}
+		out = append(out, typedField{
+			name:     name,
+			desc:     strings.TrimSpace(sf.Tag.Get("desc")),
+			enum:     enum,
+			required: !omitEmpty && !isPtr,
+			kind:     ft.Kind(),
+			typ:      ft,
+		})
+	}
+	return out
+}
+
+func typedProperties(fields []typedField) map[string]any {
+	props := make(map[string]any, len(fields))
+	for _, f := range fields {
+		p := schemaForType(f.typ)
+		if f.desc != "" {
+			p["description"] = f.desc
+		}
+		if len(f.enum) > 0 {
+			p["enum"] = enumValues(f)
+		}
+		props[f.name] = p
+	}
+	return props
+}
+
+func typedRequired(fields []typedField) []string {
+	var out []string
+	for _, f := range fields {
+		if f.required {
+			out = append(out, f.name)
+		}
+	}
+	return out
+}
+
+func schemaForType(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object"}
+	case reflect.Struct:
+		fields := typedFields(t)
+		s := map[string]any{"type": "object", "properties": typedProperties(fields)}
+		if req := typedRequired(fields); len(req) > 0 {
+			s["required"] = req
+		}
+		return s
+	default:
+		return map[string]any{}
+	}
+}
+
+// coerceParam converts common LLM drift (numbers/bools as strings) into the
+// field's JSON type so json.Unmarshal accepts it.
+func coerceParam(f typedField, v any) (any, error) {
+	s, isString := v.(string)
+	switch f.kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if isString {
+			var n json.Number = json.Number(strings.TrimSpace(s))
+			if _, err := n.Float64(); err != nil {
+				return nil, fmt.Errorf("param '%s' must be a number", f.name)
+			}
+			return n, nil
+		}
+	case reflect.Bool:
+		if isString {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "true", "1", "yes":
+				return true, nil
+			case "false", "0", "no", "":
+				return false, nil
+			default:
+				return nil, fmt.Errorf("param '%s' must be a boolean", f.name)
+			}
+		}
+	case reflect.String:
+		if !isString {
+			return nil, fmt.Errorf("param '%s' must be a string", f.name)
+		}
+	}
+	return v, nil
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// enumValues returns the schema enum for f: numbers for number fields,
+// strings otherwise.
+func enumValues(f typedField) []any {
+	out := make([]any, 0, len(f.enum))
+	for _, e := range f.enum {
+		if isNumberKind(f.kind) {
+			out = append(out, json.Number(e))
+		} else {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// enumContains reports whether the coerced value v is one of f's enum values.
+// Number fields compare numerically, so 2, 2.0 and "2" all match "2".
+func enumContains(f typedField, v any) bool {
+	if !isNumberKind(f.kind) {
+		s, _ := v.(string)
+		for _, e := range f.enum {
+			if e == s {
+				return true
+			}
+		}
+		return false
+	}
+	n, err := json.Number(fmt.Sprint(v)).Float64()
+	if err != nil {
+		return false
+	}
+	for _, e := range f.enum {
+		if en, err := json.Number(e).Float64(); err == nil && en == n {
+			return true
+		}
+	}
+	return false
+}
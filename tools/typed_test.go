@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type greetParams struct {
+	Name  string   `json:"name" desc:"Who to greet."`
+	Times int      `json:"times,omitempty" desc:"Repeat count." enum:"1|2|3"`
+	Style string   `json:"style,omitempty" enum:"plain|shout"`
+	Tags  []string `json:"tags,omitempty"`
+	Loud  *bool    `json:"loud"`
+}
+
+func newGreetTool() Tool {
+	return NewTyped("greet", "Greets someone.", func(_ context.Context, p greetParams) (string, error) {
+		n := p.Times
+		if n <= 0 {
+			n = 1
+		}
+		s := strings.Repeat("hi "+p.Name+" ", n)
+		if p.Style == "shout" || (p.Loud != nil && *p.Loud) {
+			s = strings.ToUpper(s)
+		}
+		return strings.TrimSpace(s), nil
+	})
+}
+
+func TestNewTyped_Schema(t *testing.T) {
+	tool := newGreetTool()
+	var s struct {
+		Type       string                    `json:"type"`
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal([]byte(tool.ParameterSchema()), &s); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if s.Type != "object" {
+		t.Fatalf("type=%q, want object", s.Type)
+	}
+	if len(s.Required) != 1 || s.Required[0] != "name" {
+		t.Fatalf("required=%v, want [name]", s.Required)
+	}
+	cases := map[string]string{"name": "string", "times": "integer", "style": "string", "tags": "array", "loud": "boolean"}
+	for k, want := range cases {
+		if got := s.Properties[k]["type"]; got != want {
+			t.Fatalf("properties[%s].type=%v, want %s", k, got, want)
+		}
+	}
+	if s.Properties["name"]["description"] != "Who to greet." {
+		t.Fatalf("missing description: %v", s.Properties["name"])
+	}
+	if enum, _ := s.Properties["style"]["enum"].([]any); len(enum) != 2 {
+		t.Fatalf("style enum=%v", s.Properties["style"]["enum"])
+	}
+	if enum, _ := s.Properties["times"]["enum"].([]any); len(enum) != 3 || enum[0] != 1.0 {
+		t.Fatalf("times enum=%v", s.Properties["times"]["enum"])
+	}
+}
+
+func TestNewTyped_Execute(t *testing.T) {
+	tool := newGreetTool()
+	out, err := tool.Execute(context.Background(), map[string]any{"name": "bob", "times": "2", "loud": "true"})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if out != "HI BOB HI BOB" {
+		t.Fatalf("got %q", out)
+	}
+}
+
+func TestNewTyped_Validation(t *testing.T) {
+	tool := newGreetTool()
+	cases := []struct {
+		name    string
+		params  map[string]any
+		wantErr string
+	}{
+		{name: "missing_required", params: map[string]any{}, wantErr: "missing required param: name"},
+		{name: "blank_required", params: map[string]any{"name": "  "}, wantErr: "missing required param: name"},
+		{name: "bad_enum", params: map[string]any{"name": "x", "style": "whisper"}, wantErr: "invalid style"},
+		{name: "bad_numeric_enum", params: map[string]any{"name": "x", "times": 5.0}, wantErr: "invalid times"},
+		{name: "bad_number", params: map[string]any{"name": "x", "times": "many"}, wantErr: "must be a number"},
+		{name: "wrong_type", params: map[string]any{"name": 3.0}, wantErr: "must be a string"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tool.Execute(context.Background(), tc.params)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err=%v, want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Guest program used by wasm tests (built with GOOS=wasip1 GOARCH=wasm).
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+func main() {
+	var p map[string]any
+	if err := json.NewDecoder(os.Stdin).Decode(&p); err != nil {
+		fmt.Fprintln(os.Stderr, "bad input:", err)
+		os.Exit(2)
+	}
+	switch p["mode"] {
+	case "echo":
+		fmt.Print(strings.ToUpper(fmt.Sprint(p["text"])))
+	case "spin":
+		for {
+		}
+	case "write":
+		if err := os.WriteFile("/out.txt", []byte("from wasm"), 0o644); err != nil {
+			fmt.Print("write error: ", err)
+			os.Exit(1)
+		}
+		fmt.Print("written")
+	case "escape":
+		_, err := os.ReadFile("/../../etc/passwd")
+		fmt.Print(err != nil)
+	case "net":
+		_, err := net.Dial("tcp", "127.0.0.1:80")
+		fmt.Print(err != nil)
+	case "fail":
+		fmt.Fprint(os.Stderr, "boom")
+		os.Exit(7)
+	}
+}
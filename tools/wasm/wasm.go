@@ -0,0 +1,239 @@
+// Package wasm runs WASI (wasip1) command modules as agent tools using the
+// pure-Go wazero runtime.
+//
+// Contract: the tool params are written to the module's stdin as one JSON
+// object; whatever the module writes to stdout is the observation. A non-zero
+// exit code is reported as a tool error (stdout/stderr are still returned).
+//
+// Sandbox: modules get no network access, no host environment variables and
+// a filesystem that only exposes the configured root (file_cache_dir) as "/".
+// Memory is capped via MaxMemoryMB and execution is bounded by Timeout, which
+// interrupts the running module (wazero has no instruction-level fuel, so the
+// wall-clock deadline plays that role).
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quailyquaily/mistermorph/internal/pathutil"
+	"github.com/quailyquaily/mistermorph/internal/strutil"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// ToolConfig describes one WASM tool under `tools.wasm.tools`.
+type ToolConfig struct {
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	// Module is the path to a .wasm file compiled for wasip1.
+	Module string `mapstructure:"module"`
+	// Parameters is the JSON schema shown to the model (as a JSON string).
+	Parameters string `mapstructure:"parameters"`
+	// ReadOnly mounts the filesystem root read-only.
+	ReadOnly bool `mapstructure:"read_only"`
+
+	Timeout        time.Duration `mapstructure:"timeout"`
+	MaxMemoryMB    int           `mapstructure:"max_memory_mb"`
+	MaxOutputBytes int           `mapstructure:"max_output_bytes"`
+}
+
+func (c ToolConfig) withDefaults() ToolConfig {
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MaxMemoryMB <= 0 {
+		c.MaxMemoryMB = 64
+	}
+	if c.MaxOutputBytes <= 0 {
+		c.MaxOutputBytes = 256 * 1024
+	}
+	return c
+}
+
+// Tool is a compiled WASI module exposed as tools.Tool.
+type Tool struct {
+	cfg     ToolConfig
+	rootDir string
+	schema  string
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+
+	// Instances share one runtime; serialize runs to keep memory bounded.
+	mu sync.Mutex
+}
+
+// Load compiles the module. rootDir becomes the guest's "/" and is created if missing.
+func Load(ctx context.Context, cfg ToolConfig, rootDir string) (*Tool, error) {
+	cfg = cfg.withDefaults()
+	cfg.Name = strings.TrimSpace(cfg.Name)
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("wasm tool: missing name")
+	}
+	modPath := pathutil.ExpandHomePath(strings.TrimSpace(cfg.Module))
+	if modPath == "" {
+		return nil, fmt.Errorf("wasm tool %q: missing module", cfg.Name)
+	}
+	bin, err := os.ReadFile(modPath)
+	if err != nil {
+		return nil, fmt.Errorf("wasm tool %q: %w", cfg.Name, err)
+	}
+
+	rootDir = pathutil.ExpandHomePath(strings.TrimSpace(rootDir))
+	if rootDir == "" {
+		return nil, fmt.Errorf("wasm tool %q: file_cache_dir is not configured", cfg.Name)
+	}
+	rootAbs, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(rootAbs, 0o700); err != nil {
+		return nil, err
+	}
+
+	schema := `{"type":"object","properties":{}}`
+	if s := strings.TrimSpace(cfg.Parameters); s != "" {
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("wasm tool %q: invalid parameters schema: %w", cfg.Name, err)
+		}
+		b, _ := json.MarshalIndent(v, "", "  ")
+		schema = string(b)
+	}
+
+	// 64 KiB per wasm page.
+	pages := uint32(cfg.MaxMemoryMB * 16)
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(pages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		_ = rt.Close(ctx)
+		return nil, err
+	}
+	compiled, err := rt.CompileModule(ctx, bin)
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, fmt.Errorf("wasm tool %q: compile: %w", cfg.Name, err)
+	}
+	return &Tool{cfg: cfg, rootDir: rootAbs, schema: schema, runtime: rt, compiled: compiled}, nil
+}
+
+func (t *Tool) Name() string { return t.cfg.Name }
+
+func (t *Tool) Description() string {
+	d := strings.TrimSpace(t.cfg.Description)
+	if d == "" {
+		d = "WASM tool " + t.cfg.Name + "."
+	}
+	return d + " Runs sandboxed (no network; filesystem limited to file_cache_dir)."
+}
+
+func (t *Tool) ParameterSchema() string { return t.schema }
+
+func (t *Tool) Execute(ctx context.Context, params map[string]any) (string, error) {
+	if params == nil {
+		params = map[string]any{}
+	}
+	in, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	runCtx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: t.cfg.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: 16 * 1024}
+	fsCfg := wazero.NewFSConfig()
+	if t.cfg.ReadOnly {
+		fsCfg = fsCfg.WithReadOnlyDirMount(t.rootDir, "/")
+	} else {
+		fsCfg = fsCfg.WithDirMount(t.rootDir, "/")
+	}
+	modCfg := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(t.cfg.Name).
+		WithStdin(bytes.NewReader(in)).
+		WithStdout(stdout).
+		WithStderr(stderr).
+		WithFSConfig(fsCfg).
+		WithSysWalltime().
+		WithSysNanotime()
+
+	mod, err := t.runtime.InstantiateModule(runCtx, t.compiled, modCfg)
+	if mod != nil {
+		_ = mod.Close(context.Background())
+	}
+	out := stdout.String()
+	if stdout.truncated {
+		out = strutil.TruncateUTF8(out, t.cfg.MaxOutputBytes) + "\n...(truncated)"
+	}
+	if err != nil {
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) {
+			switch exitErr.ExitCode() {
+			case 0:
+				return out, nil
+			case sys.ExitCodeDeadlineExceeded:
+				return out, fmt.Errorf("wasm tool %s timed out after %s", t.cfg.Name, t.cfg.Timeout)
+			case sys.ExitCodeContextCanceled:
+				return out, ctx.Err()
+			default:
+				return withStderr(out, stderr.String()), fmt.Errorf("wasm tool %s exited with code %d", t.cfg.Name, exitErr.ExitCode())
+			}
+		}
+		return withStderr(out, stderr.String()), fmt.Errorf("wasm tool %s: %w", t.cfg.Name, err)
+	}
+	return out, nil
+}
+
+// Close releases the runtime and compiled module.
+func (t *Tool) Close(ctx context.Context) error {
+	if t == nil || t.runtime == nil {
+		return nil
+	}
+	return t.runtime.Close(ctx)
+}
+
+func withStderr(out, errOut string) string {
+	errOut = strings.TrimSpace(errOut)
+	if errOut == "" {
+		return out
+	}
+	return out + "\n\nstderr:\n" + errOut
+}
+
+type limitedBuffer struct {
+	limit     int
+	truncated bool
+	buf       bytes.Buffer
+}
+
+func (w *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := w.limit - w.buf.Len()
+	if remaining <= 0 {
+		w.truncated = true
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		w.buf.Write(p[:remaining])
+		w.truncated = true
+		return len(p), nil
+	}
+	return w.buf.Write(p)
+}
+
+func (w *limitedBuffer) String() string { return w.buf.String() }
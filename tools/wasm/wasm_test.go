@@ -0,0 +1,116 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	guestOnce sync.Once
+	guestPath string
+	guestErr  string
+)
+
+// buildGuest compiles testdata/guest for wasip1 once per test binary.
+func buildGuest(t *testing.T) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping wasm guest build in -short mode")
+	}
+	guestOnce.Do(func() {
+		goBin, err := exec.LookPath("go")
+		if err != nil {
+			guestErr = "go toolchain not available"
+			return
+		}
+		dir, err := os.MkdirTemp("", "wasm-guest-")
+		if err != nil {
+			guestErr = err.Error()
+			return
+		}
+		out := filepath.Join(dir, "guest.wasm")
+		cmd := exec.Command(goBin, "build", "-o", out, "./testdata/guest")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+		if b, err := cmd.CombinedOutput(); err != nil {
+			guestErr = fmt.Sprintf("cannot build wasip1 guest: %v\n%s", err, b)
+			return
+		}
+		guestPath = out
+	})
+	if guestPath == "" {
+		t.Skip(guestErr)
+	}
+	return guestPath
+}
+
+func TestTool_Execute(t *testing.T) {
+	mod := buildGuest(t)
+	root := t.TempDir()
+	ctx := context.Background()
+
+	tool, err := Load(ctx, ToolConfig{
+		Name:       "guest",
+		Module:     mod,
+		Parameters: `{"type":"object","properties":{"mode":{"type":"string"}}}`,
+		Timeout:    2 * time.Second,
+	}, root)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	defer tool.Close(ctx)
+
+	if !strings.Contains(tool.ParameterSchema(), `"mode"`) {
+		t.Fatalf("schema: %s", tool.ParameterSchema())
+	}
+
+	out, err := tool.Execute(ctx, map[string]any{"mode": "echo", "text": "hi"})
+	if err != nil || out != "HI" {
+		t.Fatalf("echo: %q,%v", out, err)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"mode": "write"})
+	if err != nil || out != "written" {
+		t.Fatalf("write: %q,%v", out, err)
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "out.txt")); err != nil || string(b) != "from wasm" {
+		t.Fatalf("file not written under root: %q,%v", b, err)
+	}
+
+	if out, _ := tool.Execute(ctx, map[string]any{"mode": "escape"}); out != "true" {
+		t.Fatalf("expected escape to fail, got %q", out)
+	}
+	if out, _ := tool.Execute(ctx, map[string]any{"mode": "net"}); out != "true" {
+		t.Fatalf("expected network to be unavailable, got %q", out)
+	}
+
+	out, err = tool.Execute(ctx, map[string]any{"mode": "fail"})
+	if err == nil || !strings.Contains(err.Error(), "code 7") || !strings.Contains(out, "boom") {
+		t.Fatalf("fail: %q,%v", out, err)
+	}
+}
+
+func TestTool_Timeout(t *testing.T) {
+	mod := buildGuest(t)
+	ctx := context.Background()
+	tool, err := Load(ctx, ToolConfig{Name: "guest", Module: mod, Timeout: 300 * time.Millisecond}, t.TempDir())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	defer tool.Close(ctx)
+
+	start := time.Now()
+	_, err = tool.Execute(ctx, map[string]any{"mode": "spin"})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("timeout not enforced promptly: %s", time.Since(start))
+	}
+}